@@ -3,7 +3,6 @@ package btree
 import (
 	"main/config"
 	"main/record"
-	"time"
 )
 
 // used for every key value pair in a node
@@ -77,8 +76,8 @@ func (btree *BTree) searchForValue(node *BTreeNode, key string) *record.Record {
 }
 
 // search for a key in the B-tree
+// the record keeps its own timestamp, it decides which version is newer during compaction
 func (btree *BTree) SearchForInsertion(key string, value record.Record) bool {
-	value.Timestamp = time.Now().Unix()
 	return btree.searchForInsertion(btree.root, key, value)
 }
 
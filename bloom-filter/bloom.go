@@ -1,58 +1,189 @@
 package bloom
 
 import (
+	"bytes"
 	"encoding/binary"
+	"main/envelope"
+	"main/hashing"
+	"math/bits"
 	"os"
 )
 
+// broj bita u bloku blokovskog filtera, jedna linija kesa
+const blockBits = 512
+
 type BloomFilter struct {
-	m   uint32         // duzina bloom  filtera
-	k   uint32         // broj hash funkcija
-	arr []byte         // niz bajtova
-	hf  []HashWithSeed // niz hash funkcija
+	m       uint32         // duzina bloom  filtera u bitima
+	k       uint32         // broj hash funkcija
+	arr     []uint64       // niz bita
+	blocked bool           // svih k bita kljuca je u istom bloku od blockBits bita
+	hf      []HashWithSeed // hash funkcije filtera iz verzije 1, nil za novije
+	hash    byte           // hash funkcija iz paketa hashing, od jednog hasha se dobija k indeksa
+	hashFn  hashing.Hash128
+	seed    uint64
 }
 
-func NewBloomFilter(expectedElements int, falsePositiveRate float64) *BloomFilter {
+// filteri sa istim parametrima i seed-om se mogu spajati, m i k su uvek
+// bar 1 da bi filter mogao da se procita
+func NewBloomFilter(expectedElements int, falsePositiveRate float64, seed uint64) *BloomFilter {
 	bf := new(BloomFilter)
 	bf.m = CalculateM(expectedElements, falsePositiveRate)
 	bf.k = CalculateK(expectedElements, bf.m)
-	bf.hf = CreateHashFunctions(bf.k)
-	bf.arr = make([]byte, bf.m)
+	bf.hash = hashing.Default
+	bf.hashFn, _ = hashing.Get(bf.hash)
+	bf.seed = seed
+	bf.arr = make([]uint64, words(bf.m))
+	return bf
+}
+
+// NewBlockedBloomFilter pravi filter kod kog je provera jedan promasaj kesa,
+// po cenu nesto vece verovatnoce laznih pozitivnih odgovora
+func NewBlockedBloomFilter(expectedElements int, falsePositiveRate float64, seed uint64) *BloomFilter {
+	bf := NewBloomFilter(expectedElements, falsePositiveRate, seed)
+	bf.blocked = true
+	bf.m = (bf.m + blockBits - 1) / blockBits * blockBits
+	bf.arr = make([]uint64, words(bf.m))
 	return bf
 }
 
+func words(m uint32) int {
+	return int((uint64(m) + 63) / 64)
+}
+
+// i-ti bit kljuca sa hashom h1, h2
+func (bf *BloomFilter) index(h1, h2 uint64, i uint32) uint64 {
+	if !bf.blocked {
+		return hashing.Index(h1, h2, i, uint64(bf.m))
+	}
+	block := h1 % uint64(bf.m/blockBits)
+	return block*blockBits + hashing.Index(h2, bits.RotateLeft64(h1, 32)|1, i, blockBits)
+}
+
+func (bf *BloomFilter) set(i uint64) {
+	bf.arr[i/64] |= 1 << (i % 64)
+}
+
+func (bf *BloomFilter) isSet(i uint64) bool {
+	return bf.arr[i/64]&(1<<(i%64)) != 0
+}
+
 func (bf *BloomFilter) AddElement(key string) {
 	var i uint32
 	keyConverted := []byte(key)
+	if bf.hf != nil {
+		for i = 0; i < bf.k; i++ {
+			bf.set(bf.hf[i].Hash(keyConverted) % uint64(bf.m))
+		}
+		return
+	}
+	h1, h2 := bf.hashFn(keyConverted, bf.seed)
 	for i = 0; i < bf.k; i++ {
-		iHashed := bf.hf[i].Hash(keyConverted) % uint64(bf.m)
-		bf.arr[iHashed] = 1
+		bf.set(bf.index(h1, h2, i))
 	}
 }
 
 func (bf *BloomFilter) CheckElement(key string) bool {
 	var i uint32
 	keyConverted := []byte(key)
+	if bf.hf != nil {
+		for i = 0; i < bf.k; i++ {
+			if !bf.isSet(bf.hf[i].Hash(keyConverted) % uint64(bf.m)) {
+				return false
+			}
+		}
+		return true
+	}
+	h1, h2 := bf.hashFn(keyConverted, bf.seed)
 	for i = 0; i < bf.k; i++ {
-		iHashed := bf.hf[i].Hash(keyConverted) % uint64(bf.m)
-		if bf.arr[iHashed] == 0 {
+		if !bf.isSet(bf.index(h1, h2, i)) {
 			return false
 		}
 	}
 	return true
 }
 
+// posle unije filter sadrzi elemente oba filtera
+func (bf *BloomFilter) Union(other *BloomFilter) error {
+	if !bf.compatible(other) {
+		return envelope.ErrIncompatible
+	}
+	for i := range bf.arr {
+		bf.arr[i] |= other.arr[i]
+	}
+	return nil
+}
+
+// presek ima samo bite postavljene u oba filtera, pa moze dati lazno
+// pozitivan odgovor i za element koji je dodat samo u jedan od njih
+func (bf *BloomFilter) Intersect(other *BloomFilter) error {
+	if !bf.compatible(other) {
+		return envelope.ErrIncompatible
+	}
+	for i := range bf.arr {
+		bf.arr[i] &= other.arr[i]
+	}
+	return nil
+}
+
+// filteri iste duzine sa istim hash funkcijama
+func (bf *BloomFilter) compatible(other *BloomFilter) bool {
+	if bf.m != other.m || bf.k != other.k || bf.blocked != other.blocked || len(bf.hf) != len(other.hf) {
+		return false
+	}
+	for i := range bf.hf {
+		if !bytes.Equal(bf.hf[i].Seed, other.hf[i].Seed) {
+			return false
+		}
+	}
+	return bf.hash == other.hash && bf.seed == other.seed
+}
+
 func (bf *BloomFilter) hfLength() int {
 	return len(bf.hf) * 32
 }
 
+// verzija formata koji ToBytes upisuje, verzija 1 ima k hash funkcija sa md5,
+// a verzije 1 i 2 imaju po jedan bajt za svaki bit
+const (
+	legacyVersion = 1
+	byteVersion   = 2
+	formatVersion = 3
+)
+
+const flagBlocked = 1
+
+// opcije (1B) | hash funkcija (1B) | seed (8B) | m (uvarint) | k (uvarint) | biti
 func (bf *BloomFilter) ToBytes() []byte {
-	bufferSize := 8 + len(bf.arr) + bf.hfLength()
+	if bf.hf != nil {
+		return envelope.Wrap(envelope.Bloom, legacyVersion, bf.legacyBytes())
+	}
+	buffer := make([]byte, 10, 10+2*binary.MaxVarintLen32+len(bf.arr)*8)
+	if bf.blocked {
+		buffer[0] = flagBlocked
+	}
+	buffer[1] = bf.hash
+	binary.BigEndian.PutUint64(buffer[2:10], bf.seed)
+	buffer = binary.AppendUvarint(buffer, uint64(bf.m))
+	buffer = binary.AppendUvarint(buffer, uint64(bf.k))
+	for _, word := range bf.arr {
+		buffer = binary.LittleEndian.AppendUint64(buffer, word)
+	}
+	// poslednja rec se upisuje samo do m-tog bita
+	buffer = buffer[:len(buffer)-len(bf.arr)*8+int((uint64(bf.m)+7)/8)]
+	return envelope.Wrap(envelope.Bloom, formatVersion, buffer)
+}
+
+func (bf *BloomFilter) legacyBytes() []byte {
+	bufferSize := 8 + int(bf.m) + bf.hfLength()
 	buffer := make([]byte, bufferSize)
 	binary.BigEndian.PutUint32(buffer[0:4], bf.m)
-	copy(buffer[4:len(bf.arr)+4], bf.arr)
-	binary.BigEndian.PutUint32(buffer[len(bf.arr)+4:len(bf.arr)+8], bf.k)
-	offSet := len(bf.arr) + 8
+	for i := uint64(0); i < uint64(bf.m); i++ {
+		if bf.isSet(i) {
+			buffer[4+i] = 1
+		}
+	}
+	binary.BigEndian.PutUint32(buffer[bf.m+4:bf.m+8], bf.k)
+	offSet := bf.m + 8
 	for i := 0; i < len(bf.hf); i++ {
 		copy(buffer[offSet:offSet+32], bf.hf[i].Seed)
 		offSet += 32
@@ -60,19 +191,123 @@ func (bf *BloomFilter) ToBytes() []byte {
 	return buffer
 }
 
-func FromBytes(data []byte) *BloomFilter {
+// cita filter upisan sa ToBytes, ili bez zaglavlja iz starijih verzija
+func FromBytes(data []byte) (*BloomFilter, error) {
+	if !envelope.IsWrapped(data) {
+		return loadLegacy(data)
+	}
+	version, payload, err := envelope.Unwrap(envelope.Bloom, data)
+	if err != nil {
+		return nil, err
+	}
+	switch version {
+	case legacyVersion:
+		return loadLegacy(payload)
+	case byteVersion:
+		return loadByteFilter(payload)
+	case formatVersion:
+	default:
+		return nil, envelope.UnsupportedVersion(version)
+	}
+
+	if len(payload) < 10 || payload[0]&^flagBlocked != 0 {
+		return nil, envelope.ErrMalformed
+	}
 	bf := new(BloomFilter)
+	bf.blocked = payload[0]&flagBlocked != 0
+	bf.hash = payload[1]
+	bf.seed = binary.BigEndian.Uint64(payload[2:10])
+	data = payload[10:]
+	m, n := binary.Uvarint(data)
+	if n <= 0 || m == 0 || m > 1<<32-1 {
+		return nil, envelope.ErrMalformed
+	}
+	data = data[n:]
+	k, n := binary.Uvarint(data)
+	if n <= 0 || k > 1<<32-1 {
+		return nil, envelope.ErrMalformed
+	}
+	data = data[n:]
+	bf.m, bf.k = uint32(m), uint32(k)
+	if uint64(len(data)) != (m+7)/8 || bf.blocked && m%blockBits != 0 {
+		return nil, envelope.ErrMalformed
+	}
+	err = bf.resolveHash()
+	if err != nil {
+		return nil, err
+	}
+
+	bf.arr = make([]uint64, words(bf.m))
+	padded := make([]byte, len(bf.arr)*8)
+	copy(padded, data)
+	for i := range bf.arr {
+		bf.arr[i] = binary.LittleEndian.Uint64(padded[i*8:])
+	}
+	return bf, nil
+}
+
+func (bf *BloomFilter) resolveHash() error {
+	var found bool
+	bf.hashFn, found = hashing.Get(bf.hash)
+	if !found {
+		return envelope.UnknownHash(bf.hash)
+	}
+	return nil
+}
+
+// postavlja bite za bajtove niza razlicite od nule
+func (bf *BloomFilter) packBytes(data []byte) {
+	bf.arr = make([]uint64, words(bf.m))
+	for i, b := range data {
+		if b != 0 {
+			bf.set(uint64(i))
+		}
+	}
+}
 
+// filter iz verzije 2: m (4B) | k (4B) | hash funkcija (1B) | seed (8B) | niz
+func loadByteFilter(payload []byte) (*BloomFilter, error) {
+	if len(payload) < 17 {
+		return nil, envelope.ErrMalformed
+	}
+	bf := new(BloomFilter)
+	bf.m = binary.BigEndian.Uint32(payload[0:4])
+	bf.k = binary.BigEndian.Uint32(payload[4:8])
+	bf.hash = payload[8]
+	bf.seed = binary.BigEndian.Uint64(payload[9:17])
+	if bf.m == 0 || uint64(len(payload)) != 17+uint64(bf.m) {
+		return nil, envelope.ErrMalformed
+	}
+	err := bf.resolveHash()
+	if err != nil {
+		return nil, err
+	}
+	bf.packBytes(payload[17:])
+	return bf, nil
+}
+
+// filter iz verzije 1: m (4B) | niz | k (4B) | k seed-ova po 32B
+func loadLegacy(data []byte) (*BloomFilter, error) {
+	if len(data) < 8 {
+		return nil, envelope.ErrMalformed
+	}
+	bf := new(BloomFilter)
 	bf.m = binary.BigEndian.Uint32(data[0:4])
-	bf.arr = data[4 : bf.m+4]
+	if bf.m == 0 || uint64(len(data)) < uint64(bf.m)+8 {
+		return nil, envelope.ErrMalformed
+	}
 	bf.k = binary.BigEndian.Uint32(data[bf.m+4 : bf.m+8])
+	if bf.k == 0 || uint64(len(data)) != uint64(bf.m)+8+uint64(bf.k)*32 {
+		return nil, envelope.ErrMalformed
+	}
+	bf.packBytes(data[4 : bf.m+4])
 	offSet := bf.m + 8
 	bf.hf = make([]HashWithSeed, bf.k)
 	for i := 0; i < int(bf.k); i++ {
 		bf.hf[i] = HashWithSeed{Seed: data[offSet : offSet+32]}
 		offSet += 32
 	}
-	return bf
+	return bf, nil
 }
 
 func (bf *BloomFilter) WriteToBinFile(filepath string) {
@@ -84,26 +319,10 @@ func (bf *BloomFilter) WriteToBinFile(filepath string) {
 	f.Write(data)
 }
 
-func LoadBloomFilter(filepath string) *BloomFilter {
-	bf := new(BloomFilter)
-
-	f, _ := os.OpenFile(filepath, os.O_RDONLY, 0644)
-	defer f.Close()
-
-	stat, _ := f.Stat()
-
-	data := make([]byte, stat.Size())
-	f.Read(data)
-
-	bf.m = binary.BigEndian.Uint32(data[0:4])
-	bf.arr = data[4 : bf.m+4]
-	bf.k = binary.BigEndian.Uint32(data[bf.m+4 : bf.m+8])
-	offSet := bf.m + 8
-	bf.hf = make([]HashWithSeed, bf.k)
-	for i := 0; i < int(bf.k); i++ {
-		bf.hf[i] = HashWithSeed{Seed: data[offSet : offSet+32]}
-		offSet += 32
+func LoadBloomFilter(filepath string) (*BloomFilter, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
 	}
-
-	return bf
+	return FromBytes(data)
 }
@@ -0,0 +1,166 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"main/envelope"
+	"math"
+	"testing"
+)
+
+func TestRoundTripAtEdgeParameters(t *testing.T) {
+	tests := []struct {
+		name              string
+		expectedElements  int
+		falsePositiveRate float64
+	}{
+		{"no elements", 0, 0.01},
+		{"negative elements", -5, 0.01},
+		{"one element", 1, 0.5},
+		{"rate of one", 10, 1},
+		{"rate above one", 10, 2},
+		{"negative rate", 10, -0.1},
+		{"nan rate", 10, math.NaN()},
+		{"tiny rate", 1, 1e-300},
+		{"m not multiple of 64", 7, 0.3},
+		{"typical", 1000, 0.01},
+	}
+	constructors := []struct {
+		name string
+		new  func(int, float64, uint64) *BloomFilter
+	}{
+		{"plain", NewBloomFilter},
+		{"blocked", NewBlockedBloomFilter},
+	}
+	for _, tt := range tests {
+		for _, c := range constructors {
+			t.Run(tt.name+"/"+c.name, func(t *testing.T) {
+				bf := c.new(tt.expectedElements, tt.falsePositiveRate, 42)
+				if bf.m < 1 || bf.k < 1 {
+					t.Fatalf("m = %d, k = %d, both must be at least 1", bf.m, bf.k)
+				}
+				var added []string
+				for i := 0; i < max(tt.expectedElements, 3); i++ {
+					element := fmt.Sprint("element", i)
+					bf.AddElement(element)
+					added = append(added, element)
+				}
+
+				loaded, err := FromBytes(bf.ToBytes())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if loaded.m != bf.m || loaded.k != bf.k || loaded.blocked != bf.blocked || loaded.seed != bf.seed {
+					t.Fatalf("loaded m=%d k=%d blocked=%v seed=%d, want m=%d k=%d blocked=%v seed=%d",
+						loaded.m, loaded.k, loaded.blocked, loaded.seed, bf.m, bf.k, bf.blocked, bf.seed)
+				}
+				for i := range bf.arr {
+					if loaded.arr[i] != bf.arr[i] {
+						t.Fatalf("word %d = %x, want %x", i, loaded.arr[i], bf.arr[i])
+					}
+				}
+				for _, element := range added {
+					if !loaded.CheckElement(element) {
+						t.Fatalf("%q is missing after the round trip", element)
+					}
+				}
+			})
+		}
+	}
+}
+
+// udeo laznih pozitivnih odgovora za elemente koji nisu dodati
+func falsePositiveRate(bf *BloomFilter, added int) float64 {
+	for i := 0; i < added; i++ {
+		bf.AddElement(fmt.Sprint("added", i))
+	}
+	positives := 0
+	const checked = 20000
+	for i := 0; i < checked; i++ {
+		if bf.CheckElement(fmt.Sprint("other", i)) {
+			positives++
+		}
+	}
+	return float64(positives) / checked
+}
+
+func TestFalsePositiveRate(t *testing.T) {
+	const elements, rate = 5000, 0.01
+	if got := falsePositiveRate(NewBloomFilter(elements, rate, 1), elements); got > 1.5*rate {
+		t.Fatalf("false positive rate of the plain filter = %v, want about %v", got, rate)
+	}
+	// blokovski filter je nesto losiji, ali istog reda velicine
+	if got := falsePositiveRate(NewBlockedBloomFilter(elements, rate, 1), elements); got > 3*rate {
+		t.Fatalf("false positive rate of the blocked filter = %v, want about %v", got, rate)
+	}
+}
+
+// svi biti kljuca blokovskog filtera su u jednom bloku
+func TestBlockedFilterKeepsKeyInOneBlock(t *testing.T) {
+	bf := NewBlockedBloomFilter(1000, 0.01, 1)
+	if bf.m%blockBits != 0 {
+		t.Fatalf("m = %d is not a multiple of the block size", bf.m)
+	}
+	for i := 0; i < 100; i++ {
+		h1, h2 := bf.hashFn([]byte(fmt.Sprint("element", i)), bf.seed)
+		block := bf.index(h1, h2, 0) / blockBits
+		for j := uint32(1); j < bf.k; j++ {
+			if index := bf.index(h1, h2, j); index/blockBits != block {
+				t.Fatalf("bit %d of element %d is in block %d, want %d", j, i, index/blockBits, block)
+			}
+		}
+	}
+}
+
+func TestUnionRequiresSameParameters(t *testing.T) {
+	base := NewBloomFilter(100, 0.01, 1)
+	others := map[string]*BloomFilter{
+		"size":    NewBloomFilter(1000, 0.01, 1),
+		"seed":    NewBloomFilter(100, 0.01, 2),
+		"blocked": NewBlockedBloomFilter(100, 0.01, 1),
+	}
+	for name, other := range others {
+		if err := base.Union(other); !errors.Is(err, envelope.ErrIncompatible) {
+			t.Fatalf("union with a different %s: %v, want ErrIncompatible", name, err)
+		}
+		if err := base.Intersect(other); !errors.Is(err, envelope.ErrIncompatible) {
+			t.Fatalf("intersection with a different %s: %v, want ErrIncompatible", name, err)
+		}
+	}
+}
+
+// filter iz verzije 2 ima bajt za svaki bit
+func TestLoadByteVersion(t *testing.T) {
+	bf := NewBloomFilter(10, 0.1, 7)
+	bf.AddElement("element")
+	payload := binary.BigEndian.AppendUint32(nil, bf.m)
+	payload = binary.BigEndian.AppendUint32(payload, bf.k)
+	payload = append(payload, bf.hash)
+	payload = binary.BigEndian.AppendUint64(payload, bf.seed)
+	for i := uint64(0); i < uint64(bf.m); i++ {
+		if bf.isSet(i) {
+			payload = append(payload, 1)
+		} else {
+			payload = append(payload, 0)
+		}
+	}
+
+	loaded, err := FromBytes(envelope.Wrap(envelope.Bloom, byteVersion, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.CheckElement("element") || loaded.Union(bf) != nil {
+		t.Fatal("filter of version 2 does not match the filter it was written from")
+	}
+
+	damaged := map[string][]byte{
+		"truncated":     payload[:len(payload)-1],
+		"hash function": append(append(append([]byte(nil), payload[:8]...), 200), payload[9:]...),
+	}
+	for name, data := range damaged {
+		if _, err := FromBytes(envelope.Wrap(envelope.Bloom, byteVersion, data)); !errors.Is(err, envelope.ErrMalformed) {
+			t.Fatalf("%s: FromBytes returned %v, want ErrMalformed", name, err)
+		}
+	}
+}
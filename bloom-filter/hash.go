@@ -3,9 +3,9 @@ package bloom
 import (
 	"crypto/md5"
 	"encoding/binary"
-	"time"
 )
 
+// hash funkcija filtera iz verzije 1
 type HashWithSeed struct {
 	Seed []byte
 }
@@ -15,15 +15,3 @@ func (h HashWithSeed) Hash(data []byte) uint64 {
 	fn.Write(append(data, h.Seed...))
 	return binary.BigEndian.Uint64(fn.Sum(nil))
 }
-
-func CreateHashFunctions(k uint32) []HashWithSeed {
-	h := make([]HashWithSeed, k)
-	ts := uint32(time.Now().Unix())
-	for i := uint32(0); i < k; i++ {
-		seed := make([]byte, 32)
-		binary.BigEndian.PutUint32(seed, uint32(ts+i))
-		hfn := HashWithSeed{Seed: seed}
-		h[i] = hfn
-	}
-	return h
-}
@@ -2,10 +2,20 @@ package bloom
 
 import "math"
 
+// najveca duzina filtera, deljiva sa blockBits da bi i blokovski filter stao u uint32
+const maxBits = 1<<32 - blockBits
+
+// filter ima bar jedan bit i za parametre od kojih bi bio prazan, jer se
+// prazan filter ne moze procitati
 func CalculateM(expectedElements int, falsePositiveRate float64) uint32 {
-	return uint32(math.Ceil(float64(expectedElements) * math.Abs(math.Log(falsePositiveRate)) / math.Pow(math.Log(2), float64(2))))
+	m := math.Ceil(float64(expectedElements) * math.Abs(math.Log(falsePositiveRate)) / math.Pow(math.Log(2), float64(2)))
+	if !(m >= 1) {
+		return 1
+	}
+	return uint32(min(m, maxBits))
 }
 
 func CalculateK(expectedElements int, m uint32) uint32 {
-	return uint32(math.Ceil((float64(m) / float64(expectedElements)) * math.Log(2)))
+	k := math.Ceil((float64(m) / float64(max(expectedElements, 1))) * math.Log(2))
+	return uint32(max(k, 1))
 }
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"container/list"
+	"main/record"
+)
+
+type arcEntry struct {
+	key     string
+	record  record.Record
+	element *list.Element
+	list    *list.List
+}
+
+// Adaptive Replacement Cache (Megiddo, Modha): t1 cuva zapise vidjene jednom, t2 zapise vidjene
+// vise puta, a b1 i b2 samo kljuceve nedavno izbacenih zapisa. Na osnovu pogodaka u b1 i b2
+// pomera se ciljna velicina p za t1, pa se kes sam prilagodjava izmedju LRU i LFU ponasanja.
+type ARC struct {
+	capacity int
+	p        int
+	t1       *list.List
+	t2       *list.List
+	b1       *list.List
+	b2       *list.List
+	entries  map[string]*arcEntry
+}
+
+func NewARC(capacity int) *ARC {
+	return &ARC{
+		capacity: capacity,
+		t1:       list.New(),
+		t2:       list.New(),
+		b1:       list.New(),
+		b2:       list.New(),
+		entries:  make(map[string]*arcEntry),
+	}
+}
+
+func (arc *ARC) Get(key string) (record.Record, bool) {
+	entry, ok := arc.entries[key]
+	if !ok || entry.list == arc.b1 || entry.list == arc.b2 {
+		return record.Record{}, false
+	}
+	arc.moveTo(entry, arc.t2)
+	return entry.record, true
+}
+
+func (arc *ARC) Set(key string, rec record.Record) {
+	if arc.capacity <= 0 {
+		return
+	}
+
+	entry, ok := arc.entries[key]
+	switch {
+	case ok && (entry.list == arc.t1 || entry.list == arc.t2):
+		entry.record = rec
+		arc.moveTo(entry, arc.t2)
+	case ok && entry.list == arc.b1:
+		arc.p = min(arc.capacity, arc.p+max(arc.b2.Len()/arc.b1.Len(), 1))
+		arc.replace(false)
+		entry.record = rec
+		arc.moveTo(entry, arc.t2)
+	case ok && entry.list == arc.b2:
+		arc.p = max(0, arc.p-max(arc.b1.Len()/arc.b2.Len(), 1))
+		arc.replace(true)
+		entry.record = rec
+		arc.moveTo(entry, arc.t2)
+	default:
+		if arc.t1.Len()+arc.b1.Len() == arc.capacity {
+			if arc.t1.Len() < arc.capacity {
+				arc.removeLRU(arc.b1)
+				arc.replace(false)
+			} else {
+				arc.removeLRU(arc.t1)
+			}
+		} else {
+			total := arc.t1.Len() + arc.t2.Len() + arc.b1.Len() + arc.b2.Len()
+			if total >= arc.capacity {
+				if total == 2*arc.capacity {
+					arc.removeLRU(arc.b2)
+				}
+				arc.replace(false)
+			}
+		}
+		entry = &arcEntry{key: key, record: rec, list: arc.t1}
+		entry.element = arc.t1.PushFront(entry)
+		arc.entries[key] = entry
+	}
+}
+
+// prebacuje najdavnije korisceni zapis iz t1 ili t2 u odgovarajucu listu izbacenih kljuceva
+func (arc *ARC) replace(inB2 bool) {
+	if arc.t1.Len() > 0 && (arc.t1.Len() > arc.p || (inB2 && arc.t1.Len() == arc.p)) {
+		entry := arc.t1.Back().Value.(*arcEntry)
+		entry.record = record.Record{}
+		arc.moveTo(entry, arc.b1)
+	} else if arc.t2.Len() > 0 {
+		entry := arc.t2.Back().Value.(*arcEntry)
+		entry.record = record.Record{}
+		arc.moveTo(entry, arc.b2)
+	}
+}
+
+func (arc *ARC) moveTo(entry *arcEntry, target *list.List) {
+	entry.list.Remove(entry.element)
+	entry.list = target
+	entry.element = target.PushFront(entry)
+}
+
+func (arc *ARC) removeLRU(l *list.List) {
+	if l.Len() == 0 {
+		return
+	}
+	entry := l.Remove(l.Back()).(*arcEntry)
+	delete(arc.entries, entry.key)
+}
+
+func (arc *ARC) Remove(key string) {
+	if entry, ok := arc.entries[key]; ok {
+		entry.list.Remove(entry.element)
+		delete(arc.entries, key)
+	}
+}
+
+func (arc *ARC) Len() int {
+	return arc.t1.Len() + arc.t2.Len()
+}
+
+func (arc *ARC) Name() string {
+	return "arc"
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"container/list"
+	"main/config"
+	"sync"
+)
+
+// blok se identifikuje putanjom fajla (data, index ili summary fajl tabele) i pocetnim offsetom
+type BlockKey struct {
+	Table  string
+	Offset int64
+}
+
+type BlockCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Blocks    int
+	Bytes     int64
+}
+
+func (s BlockCacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type blockEntry struct {
+	key  BlockKey
+	data []byte
+}
+
+// deljeni LRU kes blokova SSTabela, ogranicen ukupnom velicinom blokova u
+// bajtovima. Kapacitet 0 iskljucuje kes.
+type BlockCache struct {
+	mu        sync.Mutex
+	blockSize int
+	capacity  int64 // bajtova
+	size      int64 // bajtova u kesu
+	blocks    map[BlockKey]*list.Element
+	order     *list.List // na pocetku je poslednje korisceni blok
+	stats     BlockCacheStats
+}
+
+func NewBlockCache(config config.Config) *BlockCache {
+	return &BlockCache{
+		blockSize: config.BlockSize,
+		capacity:  int64(config.BlockCacheBytes),
+		blocks:    make(map[BlockKey]*list.Element),
+		order:     list.New(),
+	}
+}
+
+func (bc *BlockCache) BlockSize() int {
+	return bc.blockSize
+}
+
+func (bc *BlockCache) Get(key BlockKey) ([]byte, bool) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	element, ok := bc.blocks[key]
+	if !ok {
+		bc.stats.Misses++
+		return nil, false
+	}
+	bc.stats.Hits++
+	bc.order.MoveToFront(element)
+	return element.Value.(*blockEntry).data, true
+}
+
+func (bc *BlockCache) Set(key BlockKey, data []byte) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if element, ok := bc.blocks[key]; ok {
+		bc.remove(element)
+	}
+	// blok veci od kapaciteta se ne kesira
+	if int64(len(data)) > bc.capacity {
+		return
+	}
+
+	// izbacujemo najdavnije koriscene blokove dok novi ne stane
+	for bc.size+int64(len(data)) > bc.capacity {
+		bc.remove(bc.order.Back())
+		bc.stats.Evictions++
+	}
+
+	bc.blocks[key] = bc.order.PushFront(&blockEntry{key: key, data: data})
+	bc.size += int64(len(data))
+}
+
+func (bc *BlockCache) remove(element *list.Element) {
+	entry := element.Value.(*blockEntry)
+	bc.order.Remove(element)
+	delete(bc.blocks, entry.key)
+	bc.size -= int64(len(entry.data))
+}
+
+// brise sve blokove jednog fajla, poziva se kada se fajl brise ili ponovo pravi
+func (bc *BlockCache) EvictTable(table string) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	for key, element := range bc.blocks {
+		if key.Table == table {
+			bc.remove(element)
+		}
+	}
+}
+
+func (bc *BlockCache) Stats() BlockCacheStats {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	stats := bc.stats
+	stats.Blocks = bc.order.Len()
+	stats.Bytes = bc.size
+	return stats
+}
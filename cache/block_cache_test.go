@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"main/config"
+	"testing"
+)
+
+func newTestBlockCache(capacity int) *BlockCache {
+	return NewBlockCache(config.Config{BlockSize: 4, BlockCacheBytes: capacity})
+}
+
+func block(size int) []byte {
+	return make([]byte, size)
+}
+
+func TestBlockCacheBoundedByBytes(t *testing.T) {
+	bc := newTestBlockCache(10)
+	a := BlockKey{Table: "t", Offset: 0}
+	b := BlockKey{Table: "t", Offset: 4}
+	c := BlockKey{Table: "t", Offset: 8}
+	bc.Set(a, block(4))
+	bc.Set(b, block(4))
+	// a je skorije koriscen, pa se izbacuje b
+	bc.Get(a)
+	bc.Set(c, block(4))
+
+	if _, found := bc.Get(b); found {
+		t.Fatal("the least recently used block was not evicted")
+	}
+	for _, key := range []BlockKey{a, c} {
+		if _, found := bc.Get(key); !found {
+			t.Fatalf("block %v was evicted", key)
+		}
+	}
+	stats := bc.Stats()
+	if stats.Bytes != 8 || stats.Blocks != 2 || stats.Evictions != 1 {
+		t.Fatalf("stats = %+v, want 8 bytes in 2 blocks after 1 eviction", stats)
+	}
+
+	// manji blokovi, npr. na kraju fajla, zauzimaju manje mesta
+	bc.Set(BlockKey{Table: "u", Offset: 0}, block(1))
+	bc.Set(BlockKey{Table: "u", Offset: 4}, block(1))
+	if stats = bc.Stats(); stats.Bytes != 10 || stats.Blocks != 4 {
+		t.Fatalf("stats = %+v, want 10 bytes in 4 blocks", stats)
+	}
+}
+
+func TestBlockCacheReplaceAndEvictTable(t *testing.T) {
+	bc := newTestBlockCache(10)
+	key := BlockKey{Table: "t", Offset: 0}
+	bc.Set(key, block(4))
+	bc.Set(key, block(2))
+	bc.Set(BlockKey{Table: "u", Offset: 0}, block(4))
+	if stats := bc.Stats(); stats.Bytes != 6 || stats.Blocks != 2 {
+		t.Fatalf("stats = %+v, want 6 bytes in 2 blocks", stats)
+	}
+
+	bc.EvictTable("t")
+	if _, found := bc.Get(key); found {
+		t.Fatal("block of an evicted table is still cached")
+	}
+	if stats := bc.Stats(); stats.Bytes != 4 || stats.Blocks != 1 {
+		t.Fatalf("stats = %+v, want 4 bytes in 1 block", stats)
+	}
+}
+
+func TestBlockCacheDisabled(t *testing.T) {
+	bc := newTestBlockCache(0)
+	key := BlockKey{Table: "t", Offset: 0}
+	bc.Set(key, block(4))
+	if _, found := bc.Get(key); found {
+		t.Fatal("a cache with capacity 0 holds a block")
+	}
+
+	// blok veci od kapaciteta ne izbacuje ostale
+	bc = newTestBlockCache(4)
+	bc.Set(key, block(4))
+	bc.Set(BlockKey{Table: "t", Offset: 4}, block(5))
+	if _, found := bc.Get(key); !found {
+		t.Fatal("a block larger than the cache evicted the others")
+	}
+	if stats := bc.Stats(); stats.Bytes != 4 || stats.Evictions != 0 {
+		t.Fatalf("stats = %+v, want 4 bytes and no evictions", stats)
+	}
+}
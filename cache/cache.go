@@ -6,33 +6,64 @@ import (
 )
 
 type Cache struct {
-	data   map[string]record.Record
+	policy EvictionPolicy
 	config config.Config
+	hits   uint64
+	misses uint64
+}
+
+type CacheStats struct {
+	Policy string
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
 }
 
 func NewCache(config config.Config) *Cache {
 	return &Cache{
-		data: make(map[string]record.Record),
+		policy: NewEvictionPolicy(config),
+		config: config,
 	}
 }
 
 func (c *Cache) Set(key string, record record.Record) {
-	// Proverava se da li je pun kes
-	if len(c.data) >= c.config.CacheMaxSize {
-		// Uklanjanje najredje upotrebljivanog(prvi u mapi)
-		for k := range c.data {
-			delete(c.data, k)
-			break
-		}
-	}
-
-	// Postavlja par kljuc-vrednsot
-	c.data[key] = record
+	// politika izbacivanja sama odlucuje koga izbacuje kada je kes pun
+	c.policy.Set(key, record)
 }
 
 // Vraca vrednost iz kesa pridruzenu uz kljuc iz argumenta funkcije
 func (c *Cache) Get(key string) (*record.Record, bool) {
-	// Proverava da li kljuc postoji u kesu
-	record, ok := c.data[key]
+	record, ok := c.policy.Get(key)
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
 	return &record, ok
 }
+
+// izbacuje zapis kljuca iz kesa
+func (c *Cache) Remove(key string) {
+	c.policy.Remove(key)
+}
+
+// izbacuje sve zapise iz kesa, statistika ostaje
+func (c *Cache) Clear() {
+	c.policy = NewEvictionPolicy(c.config)
+}
+
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Policy: c.policy.Name(),
+		Hits:   c.hits,
+		Misses: c.misses,
+		Size:   c.policy.Len(),
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"main/cms"
+	"main/record"
+)
+
+// izbacuje najredje korisceni zapis, frekvencije pristupa se procenjuju preko CountMinSketch-a
+// pa se pamte i za kljuceve koji trenutno nisu u kesu
+type LFU struct {
+	capacity   int
+	entries    map[string]record.Record
+	frequency  *cms.CountMinSketch
+	accesses   int
+	sampleSize int
+	seed       uint64
+}
+
+func NewLFU(capacity int, seed uint64) *LFU {
+	return &LFU{
+		capacity:   capacity,
+		entries:    make(map[string]record.Record),
+		frequency:  cms.NewCountMinSketch(0.01, 0.01, seed),
+		sampleSize: 10 * capacity,
+		seed:       seed,
+	}
+}
+
+// posle sampleSize pristupa frekvencije krecu od nule, da bi se kes prilagodio promeni obrasca pristupa
+func (lfu *LFU) recordAccess(key string) {
+	lfu.accesses++
+	if lfu.accesses > lfu.sampleSize {
+		lfu.frequency = cms.NewCountMinSketch(0.01, 0.01, lfu.seed)
+		lfu.accesses = 0
+	}
+	lfu.frequency.AddElement(key)
+}
+
+func (lfu *LFU) Get(key string) (record.Record, bool) {
+	lfu.recordAccess(key)
+	rec, ok := lfu.entries[key]
+	return rec, ok
+}
+
+func (lfu *LFU) Set(key string, rec record.Record) {
+	if lfu.capacity <= 0 {
+		return
+	}
+	lfu.recordAccess(key)
+
+	if _, ok := lfu.entries[key]; ok || len(lfu.entries) < lfu.capacity {
+		lfu.entries[key] = rec
+		return
+	}
+
+	victim, victimFrequency := lfu.leastFrequent()
+	// novi kljuc ulazi samo ako nije redji od zapisa koji bi izbacio, tako jedan prolaz kroz mnogo
+	// kljuceva (scan) ne izbacuje zapise kojima se cesto pristupa
+	if lfu.frequency.NumberOfRepetitions(key) < victimFrequency {
+		return
+	}
+	delete(lfu.entries, victim)
+	lfu.entries[key] = rec
+}
+
+func (lfu *LFU) leastFrequent() (string, uint32) {
+	var victim string
+	var victimFrequency uint32
+	first := true
+	for key := range lfu.entries {
+		frequency := lfu.frequency.NumberOfRepetitions(key)
+		if first || frequency < victimFrequency {
+			victim = key
+			victimFrequency = frequency
+			first = false
+		}
+	}
+	return victim, victimFrequency
+}
+
+func (lfu *LFU) Remove(key string) {
+	delete(lfu.entries, key)
+}
+
+func (lfu *LFU) Len() int {
+	return len(lfu.entries)
+}
+
+func (lfu *LFU) Name() string {
+	return "lfu"
+}
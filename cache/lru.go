@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"container/list"
+	"main/record"
+)
+
+type lruEntry struct {
+	key    string
+	record record.Record
+}
+
+// izbacuje najdavnije korisceni zapis
+type LRU struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // na pocetku je poslednje korisceni zapis
+}
+
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (lru *LRU) Get(key string) (record.Record, bool) {
+	element, ok := lru.entries[key]
+	if !ok {
+		return record.Record{}, false
+	}
+	lru.order.MoveToFront(element)
+	return element.Value.(*lruEntry).record, true
+}
+
+func (lru *LRU) Set(key string, rec record.Record) {
+	if lru.capacity <= 0 {
+		return
+	}
+
+	if element, ok := lru.entries[key]; ok {
+		element.Value.(*lruEntry).record = rec
+		lru.order.MoveToFront(element)
+		return
+	}
+
+	if lru.order.Len() >= lru.capacity {
+		oldest := lru.order.Back()
+		lru.order.Remove(oldest)
+		delete(lru.entries, oldest.Value.(*lruEntry).key)
+	}
+
+	lru.entries[key] = lru.order.PushFront(&lruEntry{key: key, record: rec})
+}
+
+func (lru *LRU) Remove(key string) {
+	if element, ok := lru.entries[key]; ok {
+		lru.order.Remove(element)
+		delete(lru.entries, key)
+	}
+}
+
+func (lru *LRU) Len() int {
+	return lru.order.Len()
+}
+
+func (lru *LRU) Name() string {
+	return "lru"
+}
@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"main/config"
+	"main/record"
+)
+
+// politika izbacivanja zapisa iz kesa, bira se preko CachePolicy u konfiguraciji
+type EvictionPolicy interface {
+	Get(key string) (record.Record, bool)
+	Set(key string, record record.Record)
+	Remove(key string)
+	Len() int
+	Name() string
+}
+
+func NewEvictionPolicy(config config.Config) EvictionPolicy {
+	switch config.CachePolicy {
+	case "lfu":
+		return NewLFU(config.CacheMaxSize, config.HashSeed)
+	case "arc":
+		return NewARC(config.CacheMaxSize)
+	default:
+		return NewLRU(config.CacheMaxSize)
+	}
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"fmt"
+	"main/record"
+	"testing"
+)
+
+func set(policy EvictionPolicy, keys ...string) {
+	for _, key := range keys {
+		policy.Set(key, record.Record{Key: key, Value: []byte(key)})
+	}
+}
+
+func get(policy EvictionPolicy, key string, times int) {
+	for i := 0; i < times; i++ {
+		policy.Get(key)
+	}
+}
+
+func expectCached(t *testing.T, policy EvictionPolicy, cached map[string]bool) {
+	t.Helper()
+	for key, want := range cached {
+		rec, found := policy.Get(key)
+		if found != want {
+			t.Fatalf("%s: %q cached = %v, want %v", policy.Name(), key, found, want)
+		}
+		if found && rec.Key != key {
+			t.Fatalf("%s: %q holds the record of %q", policy.Name(), key, rec.Key)
+		}
+	}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	lru := NewLRU(2)
+	set(lru, "a", "b")
+	get(lru, "a", 1)
+	set(lru, "c")
+	// c je korisceno posle a, pa se izbacuje a
+	set(lru, "d")
+	expectCached(t, lru, map[string]bool{"a": false, "b": false, "c": true, "d": true})
+}
+
+func TestLFUEvictsLeastFrequentlyUsed(t *testing.T) {
+	lfu := NewLFU(2, 0)
+	set(lfu, "a")
+	get(lfu, "a", 3)
+	set(lfu, "b")
+	get(lfu, "b", 1)
+
+	// c je redje od oba zapisa u kesu, pa ne ulazi
+	set(lfu, "c")
+	expectCached(t, lfu, map[string]bool{"a": true, "b": true, "c": false})
+
+	// posle vise pristupa c izbacuje najredje korisceni b
+	get(lfu, "c", 3)
+	set(lfu, "c")
+	if lfu.Len() != 2 {
+		t.Fatalf("lfu holds %d records, want 2", lfu.Len())
+	}
+	expectCached(t, lfu, map[string]bool{"a": true, "b": false, "c": true})
+}
+
+func TestARCKeepsFrequentKeysDuringScan(t *testing.T) {
+	arc := NewARC(2)
+	lru := NewLRU(2)
+	for _, policy := range []EvictionPolicy{arc, lru} {
+		set(policy, "hot")
+		get(policy, "hot", 1)
+		for i := 0; i < 5; i++ {
+			set(policy, fmt.Sprint("scan", i))
+		}
+	}
+
+	// zapis vidjen vise puta je u t2, a zapisi iz skeniranja se smenjuju u t1
+	expectCached(t, arc, map[string]bool{"hot": true, "scan4": true, "scan3": false, "scan0": false})
+	expectCached(t, lru, map[string]bool{"hot": false, "scan4": true, "scan3": true})
+	if arc.Len() != 2 {
+		t.Fatalf("arc holds %d records, want 2", arc.Len())
+	}
+
+	// kljuc nedavno izbacen iz t1 je u b1, pogodak u b1 ga vraca u t2
+	set(arc, "scan3")
+	expectCached(t, arc, map[string]bool{"scan3": true})
+	if arc.Len() > 2 {
+		t.Fatalf("arc holds %d records, capacity is 2", arc.Len())
+	}
+}
+
+func TestPoliciesWithZeroCapacity(t *testing.T) {
+	for _, policy := range []EvictionPolicy{NewLRU(0), NewLFU(0, 0), NewARC(0)} {
+		set(policy, "a")
+		expectCached(t, policy, map[string]bool{"a": false})
+	}
+}
@@ -1,24 +1,33 @@
 package cms
 
 import (
+	"bytes"
 	"encoding/binary"
 	"main/config"
+	"main/envelope"
+	"main/hashing"
+	"math"
 	"os"
-	"sort"
 )
 
 type CountMinSketch struct {
 	m      uint32         // duzina cms-a
 	k      uint32         // broj hash funkcija
-	hf     []HashWithSeed // niz hash funkcija
-	matrix [][]uint32     // matrix bajtova
+	hf     []HashWithSeed // hash funkcije sketch-a iz verzije 1, nil za novije
+	hash   byte           // hash funkcija iz paketa hashing, od jednog hasha se dobija k kolona
+	hashFn hashing.Hash128
+	seed   uint64
+	matrix [][]uint32 // matrix bajtova
 }
 
-func NewCountMinSketch(epsilon float64, delta float64) *CountMinSketch {
+// sketch-evi sa istim parametrima i seed-om se mogu spajati
+func NewCountMinSketch(epsilon float64, delta float64, seed uint64) *CountMinSketch {
 	cms := new(CountMinSketch)
 	cms.m = CalculateM(epsilon)
 	cms.k = CalculateK(delta)
-	cms.hf = CreateHashFunctions(cms.k)
+	cms.hash = hashing.Default
+	cms.hashFn, _ = hashing.Get(cms.hash)
+	cms.seed = seed
 	cms.matrix = make([][]uint32, cms.k)
 
 	for i := range cms.matrix {
@@ -28,28 +37,66 @@ func NewCountMinSketch(epsilon float64, delta float64) *CountMinSketch {
 	return cms
 }
 
+// hash kljuca od kog se dobijaju kolone, sketch iz verzije 1 hesira za svaki red posebno
+func (cms *CountMinSketch) hashKey(key []byte) (uint64, uint64) {
+	if cms.hf != nil {
+		return 0, 0
+	}
+	return cms.hashFn(key, cms.seed)
+}
+
+// kolona kljuca u redu i
+func (cms *CountMinSketch) column(key []byte, i uint32, h1, h2 uint64) uint64 {
+	if cms.hf != nil {
+		return cms.hf[i].Hash(key) % uint64(cms.m)
+	}
+	return hashing.Index(h1, h2, i, uint64(cms.m))
+}
+
 func (cms *CountMinSketch) AddElement(key string) {
 	keyConverted := []byte(key)
+	h1, h2 := cms.hashKey(keyConverted)
 	for i := uint32(0); i < cms.k; i++ {
-		j := cms.hf[i].Hash(keyConverted) % uint64(cms.m)
+		j := cms.column(keyConverted, i, h1, h2)
 		cms.matrix[i][j] += 1
 	}
 }
 
 func (cms *CountMinSketch) NumberOfRepetitions(key string) uint32 {
-	arrOfRepetitions := make([]uint32, cms.k)
 	keyConverted := []byte(key)
+	h1, h2 := cms.hashKey(keyConverted)
+	minimum := uint32(math.MaxUint32)
 
+	// svaki red precenjuje broj ponavljanja zbog kolizija, pa je najbolja procena najmanja vrednost
 	for i := uint32(0); i < cms.k; i++ {
-		j := cms.hf[i].Hash(keyConverted) % uint64(cms.m)
-		arrOfRepetitions = append(arrOfRepetitions, cms.matrix[i][j])
+		j := cms.column(keyConverted, i, h1, h2)
+		if cms.matrix[i][j] < minimum {
+			minimum = cms.matrix[i][j]
+		}
 	}
 
-	sort.Slice(arrOfRepetitions, func(i, j int) bool {
-		return arrOfRepetitions[i] > arrOfRepetitions[j]
-	})
+	return minimum
+}
 
-	return arrOfRepetitions[0]
+// sabira matrice, pa sketch broji elemente dodate u oba
+func (cms *CountMinSketch) Merge(other *CountMinSketch) error {
+	if cms.m != other.m || cms.k != other.k || len(cms.hf) != len(other.hf) {
+		return envelope.ErrIncompatible
+	}
+	for i := range cms.hf {
+		if !bytes.Equal(cms.hf[i].Seed, other.hf[i].Seed) {
+			return envelope.ErrIncompatible
+		}
+	}
+	if cms.hash != other.hash || cms.seed != other.seed {
+		return envelope.ErrIncompatible
+	}
+	for i := range cms.matrix {
+		for j := range cms.matrix[i] {
+			cms.matrix[i][j] += other.matrix[i][j]
+		}
+	}
+	return nil
 }
 
 func (cms *CountMinSketch) hfLength() int {
@@ -60,7 +107,27 @@ func (cms *CountMinSketch) matrixLength() uint32 {
 	return cms.m * cms.k * 4
 }
 
+// verzija formata koji ToBytes upisuje, verzija 1 ima k hash funkcija sa md5
+const (
+	legacyVersion = 1
+	formatVersion = 2
+)
+
+// m (4B) | k (4B) | hash funkcija (1B) | seed (8B) | matrica po redovima
 func (cms *CountMinSketch) ToBytes() []byte {
+	if cms.hf != nil {
+		return envelope.Wrap(envelope.CMS, legacyVersion, cms.legacyBytes())
+	}
+	buffer := make([]byte, 17+int(cms.matrixLength()))
+	binary.BigEndian.PutUint32(buffer[0:4], cms.m)
+	binary.BigEndian.PutUint32(buffer[4:8], cms.k)
+	buffer[8] = cms.hash
+	binary.BigEndian.PutUint64(buffer[9:17], cms.seed)
+	cms.putMatrix(buffer[17:])
+	return envelope.Wrap(envelope.CMS, formatVersion, buffer)
+}
+
+func (cms *CountMinSketch) legacyBytes() []byte {
 	bufferSize := 8 + cms.hfLength() + int(cms.matrixLength())
 	buffer := make([]byte, bufferSize)
 	binary.BigEndian.PutUint32(buffer[0:4], cms.m)
@@ -70,13 +137,34 @@ func (cms *CountMinSketch) ToBytes() []byte {
 		copy(buffer[offSet:offSet+32], cms.hf[i].Seed)
 		offSet += 32
 	}
+	cms.putMatrix(buffer[offSet:])
+	return buffer
+}
+
+func (cms *CountMinSketch) putMatrix(buffer []byte) {
+	offSet := 0
 	for i := uint32(0); i < cms.k; i++ {
 		for j := uint32(0); j < cms.m; j++ {
 			binary.BigEndian.PutUint32(buffer[offSet:offSet+4], cms.matrix[i][j])
 			offSet += 4
 		}
 	}
-	return buffer
+}
+
+func (cms *CountMinSketch) loadMatrix(data []byte) {
+	cms.matrix = make([][]uint32, cms.k)
+
+	for i := range cms.matrix {
+		cms.matrix[i] = make([]uint32, cms.m)
+	}
+
+	offSet := 0
+	for i := uint32(0); i < cms.k; i++ {
+		for j := uint32(0); j < cms.m; j++ {
+			cms.matrix[i][j] = binary.BigEndian.Uint32(data[offSet : offSet+4])
+			offSet += 4
+		}
+	}
 }
 
 func (cms *CountMinSketch) WriteToBinFile() error {
@@ -92,10 +180,54 @@ func (cms *CountMinSketch) WriteToBinFile() error {
 	return err
 }
 
-func LoadCMS(data []byte) *CountMinSketch {
+// cita sketch upisan sa ToBytes, ili bez zaglavlja iz starijih verzija
+func LoadCMS(data []byte) (*CountMinSketch, error) {
+	if !envelope.IsWrapped(data) {
+		return loadLegacy(data)
+	}
+	version, payload, err := envelope.Unwrap(envelope.CMS, data)
+	if err != nil {
+		return nil, err
+	}
+	switch version {
+	case legacyVersion:
+		return loadLegacy(payload)
+	case formatVersion:
+	default:
+		return nil, envelope.UnsupportedVersion(version)
+	}
+
+	if len(payload) < 17 {
+		return nil, envelope.ErrMalformed
+	}
+	cms := new(CountMinSketch)
+	cms.m = binary.BigEndian.Uint32(payload[0:4])
+	cms.k = binary.BigEndian.Uint32(payload[4:8])
+	cms.hash = payload[8]
+	cms.seed = binary.BigEndian.Uint64(payload[9:17])
+	if cms.m == 0 || uint64(len(payload)) != 17+uint64(cms.k)*uint64(cms.m)*4 {
+		return nil, envelope.ErrMalformed
+	}
+	var found bool
+	cms.hashFn, found = hashing.Get(cms.hash)
+	if !found {
+		return nil, envelope.UnknownHash(cms.hash)
+	}
+	cms.loadMatrix(payload[17:])
+	return cms, nil
+}
+
+// sketch iz verzije 1: m (4B) | k (4B) | k seed-ova po 32B | matrica
+func loadLegacy(data []byte) (*CountMinSketch, error) {
+	if len(data) < 8 {
+		return nil, envelope.ErrMalformed
+	}
 	cms := new(CountMinSketch)
 	cms.m = binary.BigEndian.Uint32(data[0:4])
 	cms.k = binary.BigEndian.Uint32(data[4:8])
+	if cms.m == 0 || cms.k == 0 || uint64(len(data)) != 8+uint64(cms.k)*32+uint64(cms.k)*uint64(cms.m)*4 {
+		return nil, envelope.ErrMalformed
+	}
 
 	offSet := 8
 	cms.hf = make([]HashWithSeed, cms.k)
@@ -103,18 +235,6 @@ func LoadCMS(data []byte) *CountMinSketch {
 		cms.hf[i] = HashWithSeed{Seed: data[offSet : offSet+32]}
 		offSet += 32
 	}
-
-	cms.matrix = make([][]uint32, cms.k)
-
-	for i := range cms.matrix {
-		cms.matrix[i] = make([]uint32, cms.m)
-	}
-
-	for i := uint32(0); i < cms.k; i++ {
-		for j := uint32(0); j < cms.m; j++ {
-			cms.matrix[i][j] = binary.BigEndian.Uint32(data[offSet : offSet+4])
-			offSet += 4
-		}
-	}
-	return cms
+	cms.loadMatrix(data[offSet:])
+	return cms, nil
 }
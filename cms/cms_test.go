@@ -0,0 +1,60 @@
+package cms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"main/envelope"
+	"testing"
+)
+
+func newFilled(seed uint64) *CountMinSketch {
+	cms := NewCountMinSketch(0.01, 0.01, seed)
+	for i := 0; i < 100; i++ {
+		cms.AddElement(fmt.Sprint("element", i%10))
+	}
+	return cms
+}
+
+// sketch-evi sa istim seed-om su isti i kada su napravljeni u razlicitim
+// pokretanjima, pa se mogu spajati
+func TestSameSeedIsDeterministic(t *testing.T) {
+	first, second := newFilled(7), newFilled(7)
+	if !bytes.Equal(first.ToBytes(), second.ToBytes()) {
+		t.Fatal("sketches with the same seed and elements differ")
+	}
+	if bytes.Equal(first.ToBytes(), newFilled(8).ToBytes()) {
+		t.Fatal("sketches with different seeds are the same")
+	}
+
+	err := first.Merge(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := first.NumberOfRepetitions("element3"); count < 20 {
+		t.Fatalf("count after merge = %d, want at least 20", count)
+	}
+	if err = first.Merge(newFilled(8)); !errors.Is(err, envelope.ErrIncompatible) {
+		t.Fatalf("merge of different seeds: %v, want ErrIncompatible", err)
+	}
+}
+
+func TestSeedSurvivesRoundTrip(t *testing.T) {
+	cms := newFilled(1 << 40)
+	loaded, err := LoadCMS(cms.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.seed != cms.seed {
+		t.Fatalf("loaded seed = %d, want %d", loaded.seed, cms.seed)
+	}
+	for i := 0; i < 10; i++ {
+		element := fmt.Sprint("element", i)
+		if got, want := loaded.NumberOfRepetitions(element), cms.NumberOfRepetitions(element); got != want {
+			t.Fatalf("count of %q after loading = %d, want %d", element, got, want)
+		}
+	}
+	if err = loaded.Merge(cms); err != nil {
+		t.Fatalf("merge with the sketch it was loaded from: %v", err)
+	}
+}
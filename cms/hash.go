@@ -3,9 +3,9 @@ package cms
 import (
 	"crypto/md5"
 	"encoding/binary"
-	"time"
 )
 
+// hash funkcija sketch-a iz verzije 1
 type HashWithSeed struct {
 	Seed []byte
 }
@@ -18,15 +18,3 @@ func (h HashWithSeed) Hash(data []byte) uint64 {
 	}
 	return binary.BigEndian.Uint64(fn.Sum(nil))
 }
-
-func CreateHashFunctions(k uint32) []HashWithSeed {
-	h := make([]HashWithSeed, k)
-	ts := uint32(time.Now().Unix())
-	for i := uint32(0); i < k; i++ {
-		seed := make([]byte, 32)
-		binary.BigEndian.PutUint32(seed, uint32(ts+i))
-		hfn := HashWithSeed{Seed: seed}
-		h[i] = hfn
-	}
-	return h
-}
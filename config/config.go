@@ -10,12 +10,15 @@ const (
 	WAL_DIRECTORY              = "data/wal/"
 	SEGMENT_FILE_PATH          = "data/wal/wal_"
 	SSTABLE_DIRECTORY          = "data/sstable/"
+	FAMILY_DIRECTORY           = "data/families/"
+	FAMILY_CONFIG_FILE         = "config.json"
+	VALUE_LOG_DIRECTORY        = "data/vlog/"
 	TOKENBUCKET_STATE          = "data/token_bucket/token_bucket_state.bin"
 	CMS_FILE_PATH              = "data/cms/cms.bin"
 	HLL_FILE_PATH              = "data/hll/hll.bin"
 	KEY_DICTIONARY_FILE_PATH   = "data/keyDictionary/keyDictionary.bin"
 	HLL_MIN_PRECISION          = 4
-	HLL_MAX_PRECISION          = 16
+	HLL_MAX_PRECISION          = 18
 	CONFIG_NUMBER_OF_LEVELS    = 5
 	CONFIG_MAX_TABLES          = 4
 	CONFIG_SEGMENT_SIZE        = 3
@@ -29,11 +32,18 @@ const (
 	CONFIG_MEMTABLE_STRUCTURE  = "skiplist"
 	CONFIG_NUMBER_OF_MEMTABLES = 2
 	CONFIG_CACHE_MAX_SIZE      = 3
+	CONFIG_CACHE_POLICY        = "lru"
 	CONFIG_COMPACT_BY          = "byte"
 	CONFIG_MAX_BYTES_SSTABLES  = 128
 	CONFIG_COMPACT_TYPE        = "size_tiered"
 	CONFIG_COMPRESS            = false
+	CONFIG_BLOCKED_FILTER      = false
+	CONFIG_HASH_SEED           = 0
 	CONFIG_M                   = 4
+	CONFIG_BLOCK_SIZE          = 4096
+	CONFIG_BLOCK_CACHE_BYTES   = 1 << 20
+	CONFIG_VALUE_THRESHOLD     = 1024
+	CONFIG_VALUE_LOG_FILE_SIZE = 1 << 20
 )
 
 type Config struct {
@@ -48,9 +58,12 @@ type Config struct {
 	// skiplist
 	MaxHeight int `json:"MaxHeight"`
 	// sstable
-	NumberOfSSTables int `json:"NumberOfSSTables"`
-	IndexInterval    int `json:"IndexInterval"`
-	SummaryInterval  int `json:"SummaryInterval"`
+	NumberOfSSTables int    `json:"NumberOfSSTables"`
+	FlushedSequence  uint64 `json:"FlushedSequence"` // zapisi do ovog broja su u sstabelama
+	IndexInterval    int    `json:"IndexInterval"`
+	SummaryInterval  int    `json:"SummaryInterval"`
+	// bloom filter sstabele sa blokovima velicine linije kesa
+	BlockedFilter bool `json:"BlockedFilter"`
 	// tokenBucket
 	Capacity uint64 `json:"Capacity"`
 	Rate     uint64 `json:"Rate"`
@@ -61,9 +74,46 @@ type Config struct {
 	MemtableStructure string `json:"MemtableStructure"`
 	NumberOfMemtables int    `json:"NumberOfMemtables"`
 	// cache
-	CacheMaxSize int `json:"CacheMaxSize"`
+	CacheMaxSize int    `json:"CacheMaxSize"`
+	CachePolicy  string `json:"CachePolicy"`
+	BlockSize    int    `json:"BlockSize"`
+	// ukupna velicina blokova u kesu blokova u bajtovima (0 iskljucuje)
+	BlockCacheBytes int `json:"BlockCacheBytes"`
+	// value log, vrednosti vece od ValueThreshold bajtova se cuvaju odvojeno (0 iskljucuje)
+	ValueThreshold   int `json:"ValueThreshold"`
+	ValueLogFileSize int `json:"ValueLogFileSize"`
 	//other
 	Compress bool `json:"Compress"`
+	// seed hash funkcija novih bloom filtera i cms-ova
+	HashSeed uint64 `json:"HashSeed"`
+	// familija kolona na koju se opcije odnose, prazno za podrazumevanu
+	Family string `json:"-"`
+}
+
+// opcije koje svaka familija kolona ima za sebe, ostale uzima iz glavnog config fajla
+type familyConfig struct {
+	NumberOfLevels    int    `json:"NumberOfLevels"`
+	MaxTabels         int    `json:"MaxTables"`
+	CompactBy         string `json:"CompactBy"`
+	MaxBytesSSTables  int    `json:"MaxBytesSSTables"`
+	CompactType       string `json:"CompactType"`
+	NumberOfSSTables  int    `json:"NumberOfSSTables"`
+	FlushedSequence   uint64 `json:"FlushedSequence"`
+	MaxSize           int    `json:"MaxSize"`
+	MemtableStructure string `json:"MemtableStructure"`
+	NumberOfMemtables int    `json:"NumberOfMemtables"`
+}
+
+// direktorijum sa sstabelama familije
+func (cfg *Config) SSTableDirectory() string {
+	if cfg.Family == "" {
+		return SSTABLE_DIRECTORY
+	}
+	return FamilyDirectory(cfg.Family)
+}
+
+func FamilyDirectory(family string) string {
+	return FAMILY_DIRECTORY + family + "/"
 }
 
 func (cfg *Config) checkValidity() {
@@ -120,6 +170,26 @@ func (cfg *Config) checkValidity() {
 		cfg.CacheMaxSize = CONFIG_CACHE_MAX_SIZE
 	}
 
+	if cfg.CachePolicy != "lru" && cfg.CachePolicy != "lfu" && cfg.CachePolicy != "arc" {
+		cfg.CachePolicy = CONFIG_CACHE_POLICY
+	}
+
+	if cfg.BlockSize <= 0 {
+		cfg.BlockSize = CONFIG_BLOCK_SIZE
+	}
+
+	if cfg.BlockCacheBytes < 0 {
+		cfg.BlockCacheBytes = CONFIG_BLOCK_CACHE_BYTES
+	}
+
+	if cfg.ValueThreshold < 0 {
+		cfg.ValueThreshold = CONFIG_VALUE_THRESHOLD
+	}
+
+	if cfg.ValueLogFileSize <= 0 {
+		cfg.ValueLogFileSize = CONFIG_VALUE_LOG_FILE_SIZE
+	}
+
 	if cfg.CompactBy != "byte" && cfg.CompactBy != "amount" {
 		cfg.CompactBy = CONFIG_COMPACT_BY
 	}
@@ -159,10 +229,17 @@ func LoadConfig(cfg *Config) error {
 		cfg.MemtableStructure = CONFIG_MEMTABLE_STRUCTURE
 		cfg.NumberOfMemtables = CONFIG_NUMBER_OF_MEMTABLES
 		cfg.CacheMaxSize = CONFIG_CACHE_MAX_SIZE
+		cfg.CachePolicy = CONFIG_CACHE_POLICY
+		cfg.BlockSize = CONFIG_BLOCK_SIZE
+		cfg.BlockCacheBytes = CONFIG_BLOCK_CACHE_BYTES
+		cfg.ValueThreshold = CONFIG_VALUE_THRESHOLD
+		cfg.ValueLogFileSize = CONFIG_VALUE_LOG_FILE_SIZE
 		cfg.CompactBy = CONFIG_COMPACT_BY
 		cfg.MaxBytesSSTables = CONFIG_MAX_BYTES_SSTABLES
 		cfg.CompactType = CONFIG_COMPACT_TYPE
 		cfg.Compress = CONFIG_COMPRESS
+		cfg.BlockedFilter = CONFIG_BLOCKED_FILTER
+		cfg.HashSeed = CONFIG_HASH_SEED
 		cfg.M = CONFIG_M
 	} else {
 		err = json.Unmarshal(jsonFile, &cfg)
@@ -175,8 +252,32 @@ func LoadConfig(cfg *Config) error {
 	return nil
 }
 
-/* Upisuje opcije u config JSON fajl */
+// ucitava opcije familije kolona, preko opcija iz glavnog config fajla
+func LoadFamilyConfig(cfg *Config, family string) error {
+	err := LoadConfig(cfg)
+	if err != nil {
+		return err
+	}
+	cfg.Family = family
+	cfg.FlushedSequence = 0
+	jsonFile, err := os.ReadFile(FamilyDirectory(family) + FAMILY_CONFIG_FILE)
+	if err != nil {
+		return err
+	}
+	// fajl familije ima samo njene opcije, pa se ostale ne menjaju
+	err = json.Unmarshal(jsonFile, cfg)
+	if err != nil {
+		return err
+	}
+	cfg.checkValidity()
+	return nil
+}
+
+/* Upisuje opcije u config JSON fajl, za familiju kolona samo njene opcije u njen direktorijum */
 func (c *Config) WriteConfig() error {
+	if c.Family != "" {
+		return c.writeFamilyConfig()
+	}
 	jsonData, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return err
@@ -184,3 +285,22 @@ func (c *Config) WriteConfig() error {
 	os.WriteFile(ALL_CONFIG_FILE_PATH, jsonData, 0644)
 	return nil
 }
+
+func (c *Config) writeFamilyConfig() error {
+	jsonData, err := json.MarshalIndent(familyConfig{
+		NumberOfLevels:    c.NumberOfLevels,
+		MaxTabels:         c.MaxTabels,
+		CompactBy:         c.CompactBy,
+		MaxBytesSSTables:  c.MaxBytesSSTables,
+		CompactType:       c.CompactType,
+		NumberOfSSTables:  c.NumberOfSSTables,
+		FlushedSequence:   c.FlushedSequence,
+		MaxSize:           c.MaxSize,
+		MemtableStructure: c.MemtableStructure,
+		NumberOfMemtables: c.NumberOfMemtables,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(FamilyDirectory(c.Family)+FAMILY_CONFIG_FILE, jsonData, 0644)
+}
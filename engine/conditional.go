@@ -0,0 +1,53 @@
+package engine
+
+import "errors"
+
+// CompareAndSwap upisuje vrednost samo ako je najnovija ziva verzija kljuca
+// expected, kako je vraca Record.Version. Vraca da li je vrednost upisana.
+func (e *Engine) CompareAndSwap(key string, expected uint64, value []byte) (bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	matches, err := e.versionMatches(key, expected)
+	if err != nil || !matches {
+		return false, err
+	}
+	return true, e.put(e.defaultFamily, key, value, false)
+}
+
+// CompareAndDelete brise kljuc samo ako je njegova najnovija ziva verzija expected.
+func (e *Engine) CompareAndDelete(key string, expected uint64) (bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	matches, err := e.versionMatches(key, expected)
+	if err != nil || !matches {
+		return false, err
+	}
+	return true, e.put(e.defaultFamily, key, nil, true)
+}
+
+// PutIfAbsent upisuje vrednost samo ako kljuc nema zivu verziju.
+func (e *Engine) PutIfAbsent(key string, value []byte) (bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	_, err := e.live(e.defaultFamily, key)
+	if err == nil {
+		return false, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+	return true, e.put(e.defaultFamily, key, value, false)
+}
+
+// kljuc koji ne postoji se nikad ne poklapa
+func (e *Engine) versionMatches(key string, expected uint64) (bool, error) {
+	current, err := e.live(e.defaultFamily, key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return current.Version() == expected, nil
+}
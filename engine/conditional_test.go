@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func version(t *testing.T, e *Engine, key string) uint64 {
+	t.Helper()
+	r, err := e.Get(key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	return r.Version()
+}
+
+func TestCompareAndSwapVersionConflict(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	err := e.Put("key", []byte("first"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := version(t, e, "key")
+	err = e.Put("key", []byte("second"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	swapped, err := e.CompareAndSwap("key", stale, []byte("lost"))
+	if err != nil || swapped {
+		t.Fatalf("CompareAndSwap with a stale version = %v, %v, want false", swapped, err)
+	}
+	expectValue(t, e, "key", "second")
+
+	swapped, err = e.CompareAndSwap("key", version(t, e, "key"), []byte("third"))
+	if err != nil || !swapped {
+		t.Fatalf("CompareAndSwap with the current version = %v, %v, want true", swapped, err)
+	}
+	expectValue(t, e, "key", "third")
+
+	// verzija se menja i kada je nova vrednost ista
+	current := version(t, e, "key")
+	err = e.Put("key", []byte("third"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped, err = e.CompareAndSwap("key", current, []byte("lost"))
+	if err != nil || swapped {
+		t.Fatalf("CompareAndSwap after a rewrite of the same value = %v, %v, want false", swapped, err)
+	}
+
+	swapped, err = e.CompareAndSwap("missing", 0, []byte("lost"))
+	if err != nil || swapped {
+		t.Fatalf("CompareAndSwap of a missing key = %v, %v, want false", swapped, err)
+	}
+}
+
+func TestCompareAndDeleteVersionConflict(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	err := e.Put("key", []byte("first"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := version(t, e, "key")
+	err = e.Put("key", []byte("second"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := e.CompareAndDelete("key", stale)
+	if err != nil || deleted {
+		t.Fatalf("CompareAndDelete with a stale version = %v, %v, want false", deleted, err)
+	}
+	expectValue(t, e, "key", "second")
+
+	current := version(t, e, "key")
+	deleted, err = e.CompareAndDelete("key", current)
+	if err != nil || !deleted {
+		t.Fatalf("CompareAndDelete with the current version = %v, %v, want true", deleted, err)
+	}
+	_, err = e.Get("key")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after CompareAndDelete: %v, want ErrNotFound", err)
+	}
+
+	// obrisan kljuc nema verziju, ni onu pre brisanja
+	deleted, err = e.CompareAndDelete("key", current)
+	if err != nil || deleted {
+		t.Fatalf("CompareAndDelete of a deleted key = %v, %v, want false", deleted, err)
+	}
+}
+
+func TestPutIfAbsent(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	written, err := e.PutIfAbsent("key", []byte("first"))
+	if err != nil || !written {
+		t.Fatalf("PutIfAbsent of a missing key = %v, %v, want true", written, err)
+	}
+	written, err = e.PutIfAbsent("key", []byte("second"))
+	if err != nil || written {
+		t.Fatalf("PutIfAbsent of an existing key = %v, %v, want false", written, err)
+	}
+	expectValue(t, e, "key", "first")
+
+	err = e.Delete("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err = e.PutIfAbsent("key", []byte("third"))
+	if err != nil || !written {
+		t.Fatalf("PutIfAbsent of a deleted key = %v, %v, want true", written, err)
+	}
+	expectValue(t, e, "key", "third")
+}
+
+// verzije ostaju iste posle upisa u sstabelu i ponovnog pokretanja
+func TestVersionSurvivesFlushAndReopen(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	err := e.Put("key", []byte("value"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := version(t, e, "key")
+	fill(t, e, "fill", 20)
+
+	e = openEngine(t, nil)
+	if after := version(t, e, "key"); after != before {
+		t.Fatalf("version after reopen = %d, want %d", after, before)
+	}
+	swapped, err := e.CompareAndSwap("key", before, []byte("new"))
+	if err != nil || !swapped {
+		t.Fatalf("CompareAndSwap after reopen = %v, %v, want true", swapped, err)
+	}
+	expectValue(t, e, "key", "new")
+}
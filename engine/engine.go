@@ -1,10 +1,7 @@
 package engine
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io"
+	"bytes"
 	"main/bloom-filter"
 	"main/cache"
 	"main/cms"
@@ -12,505 +9,618 @@ import (
 	hll "main/hyperloglog"
 	"main/lsm"
 	"main/memtable"
+	mergeoperator "main/mergeOperator"
 	"main/record"
 	"main/simhash"
 	"main/sstable"
 	tokenbucket "main/tokenBucket"
+	valuelog "main/valueLog"
 	"main/wal"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Engine struct {
-	config                config.Config
-	Cache                 cache.Cache
-	Wal                   wal.Wal
-	Tbucket               tokenbucket.TokenBucket
-	all_memtables         []*memtable.Memtable
-	active_memtable_index int
-	KeyDictionary         map[int]string
+	config         config.Config
+	Cache          cache.Cache
+	BlockCache     *cache.BlockCache
+	Wal            wal.Wal
+	Tbucket        tokenbucket.TokenBucket
+	ValueLog       *valuelog.ValueLog
+	mergeOperators *mergeoperator.Registry
+	defaultFamily  *ColumnFamily
+	systemFamily   *ColumnFamily // vidi system.go
+	families       map[string]*ColumnFamily
+	nextFamilyID   uint32
+	walRuns        []walRun // WAL od najstarijeg zapisa, vidi truncateWal
+	sequence       uint64   // redni broj poslednjeg upisa
+
+	// citanja i upisi su serijalizovani da bi uslovni upisi videli trenutnu
+	// verziju, iterator se pravi pod zakljucavanjem i posle cita svoj snimak
+	lock *sync.Mutex
 }
 
-// inicijalno pravljenje svih struktura
-func (e *Engine) Engine() {
+// inicijalno pravljenje svih struktura, greska pri oporavku iz WAL-a se vraca
+// ali je engine i tada spreman za rad sa zapisima koji su procitani
+func (e *Engine) Engine() error {
 	err := config.LoadConfig(&e.config)
 	if err != nil {
 		e.config.WriteConfig()
 	}
 
-	// DESERIALIZE KEY DICT
 	// posto lsm nije struktura, zvacemo ga iz package-a
-	// e.KeyDictionary = make(map[int]string)
 	e.Cache = *cache.NewCache(e.config)
-	wal, _ := wal.LoadWal(e.config.SegmentSize)
+	e.BlockCache = cache.NewBlockCache(e.config)
+	sstable.SetBlockCache(e.BlockCache)
+	err = sstable.MigrateKeyDictionary(e.config.SSTableDirectory(), e.config.Compress)
+	if err != nil {
+		return err
+	}
+	wal, err := wal.LoadWal(e.config.SegmentSize)
+	if err != nil {
+		return err
+	}
 	e.Wal = *wal
+	e.ValueLog, err = valuelog.LoadValueLog(e.config.ValueLogFileSize)
+	if err != nil {
+		return err
+	}
 	e.Tbucket = *tokenbucket.LoadTokenBucket(e.config)
-	e.all_memtables = memtable.LoadAllMemtables(e.config)
-	e.active_memtable_index = 0
-
-	e.recover()
-	if e.config.Compress {
-		f, _ := os.Open(config.KEY_DICTIONARY_FILE_PATH)
-		defer f.Close()
-
-		keyDictionaryBytes, _ := io.ReadAll(f)
-		if len(keyDictionaryBytes) == 0 {
-			e.KeyDictionary = make(map[int]string)
-		} else {
-			keyDictionary, _ := e.DeserializeMap(keyDictionaryBytes)
-			e.KeyDictionary = keyDictionary
-		}
-	} else {
-		e.KeyDictionary = nil
+	e.lock = new(sync.Mutex)
+	err = e.loadColumnFamilies()
+	if err != nil {
+		return err
+	}
+	err = e.loadSystemFamily()
+	if err != nil {
+		return err
 	}
+	// svaki vec upisan zapis ima manji redni broj, i kada sat posle ponovnog
+	// pokretanja kasni za zapisima u sstabelama
+	e.sequence = uint64(time.Now().UnixNano())
+	for _, cf := range e.families {
+		e.sequence = max(e.sequence, cf.config.FlushedSequence)
+	}
+	// starije verzije su cuvale strukture u podrazumevanoj familiji, pa se
+	// njihovi operandi spajaju dok se kljucevi ne premeste
+	legacy := systemOperators()
+	legacy.RegisterAll(e.operators())
+	e.defaultFamily.operators = legacy
+	err = e.recover()
+	moveErr := e.moveSystemKeys()
+	if moveErr == nil {
+		e.defaultFamily.operators = e.operators()
+	}
+	if err == nil {
+		err = moveErr
+	}
+	return err
 }
 
+// Put upisuje zapis u WAL i memtabelu. Vrednosti vece od ValueThreshold idu
+// u value log, a upisuje se samo pokazivac na njih.
 func (e *Engine) Put(key string, value []byte, deleted bool) error {
-	recordToAdd := e.Wal.AddRecord(key, value, deleted, &e.KeyDictionary)
-	e.addRecordToMemtable(*recordToAdd)
-	e.Cache.Set(key, *recordToAdd)
-	return nil
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.put(e.defaultFamily, key, value, deleted)
 }
 
-func (e *Engine) Get(key string, author_change bool) *record.Record {
-	var record *record.Record
-	if !author_change && e.checkSpecialKey(key) {
-		return nil
+// PutWithExpiry upisuje zapis koji se posle expiry cita kao obrisan.
+func (e *Engine) PutWithExpiry(key string, value []byte, expiry time.Time) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	cf := e.defaultFamily
+	recordToAdd, err := e.newRecord(cf, key, value, false)
+	if err != nil {
+		return err
 	}
-	// going through memtable
-	i := e.active_memtable_index
-	//is active memtable empty, if it is try previous
-	if e.all_memtables[i].CurrentSize == 0 {
-		previous_index := e.active_memtable_index - 1
-		//we made a full circle
-		if previous_index < 0 {
-			previous_index = e.config.NumberOfMemtables - 1
-		}
-		i = previous_index
+	recordToAdd.Expiry = expiry.Unix()
+	walSize, err := e.log(cf, recordToAdd)
+	if err != nil {
+		return err
 	}
-	for j := 0; j < e.config.NumberOfMemtables; j++ {
-		//we haven't found a record with the given key
-		if e.all_memtables[i].CurrentSize == 0 {
-			break
-		}
-
-		record = e.all_memtables[i].Search(key)
-		//we found record with the key
-		if record != nil && !record.Tombstone {
-			return record
-		}
+	return e.apply(cf, *recordToAdd, walSize)
+}
 
-		//going to next memtable
-		i = i - 1
-		if i < 0 {
-			i = e.config.NumberOfMemtables - 1
-		}
+// put ocekuje da je lock zakljucan
+func (e *Engine) put(cf *ColumnFamily, key string, value []byte, deleted bool) error {
+	recordToAdd, err := e.newRecord(cf, key, value, deleted)
+	if err != nil {
+		return err
 	}
-	//going through cache
-	record, found := e.Cache.Get(key)
-	//we found it in cache
-	if found && !record.Tombstone {
-		return record
+	walSize, err := e.log(cf, recordToAdd)
+	if err != nil {
+		return err
 	}
+	return e.apply(cf, *recordToAdd, walSize)
+}
 
-	//going through sstable
-	record, _ = sstable.Search(key, &e.KeyDictionary)
-	//we found it in sstable
-	if record != nil && !record.Tombstone {
-		return record
+// zapis za upis ili brisanje, velike vrednosti podrazumevane familije idu u
+// value log, cije ciscenje trazi kljuceve samo u toj familiji
+func (e *Engine) newRecord(cf *ColumnFamily, key string, value []byte, deleted bool) (*record.Record, error) {
+	if cf == e.defaultFamily && !deleted && e.config.ValueThreshold > 0 && len(value) > e.config.ValueThreshold {
+		pointer, err := e.ValueLog.Append(key, value)
+		if err != nil {
+			return nil, err
+		}
+		return record.NewPointerRecord(key, pointer.ToBytes()), nil
 	}
-
-	//we haven't found a record with the given key or it's already been deleted
-	return nil
+	return record.NewRecord(key, value, deleted), nil
 }
 
-func (e *Engine) Delete(key string) error {
-	record := e.Get(key, false)
-	if record == nil {
-		return errors.New("record not found or unable to be deleted")
-	}
-	e.Put(record.Key, record.Value, true)
-	return nil
+// dodeljuje zapisu sledeci redni broj i upisuje ga u WAL, vraca velicinu
+// zapisa u WAL-u
+func (e *Engine) log(cf *ColumnFamily, r *record.Record) (int, error) {
+	r.Family = cf.id
+	e.assignSequence(r)
+	return len(r.ToBytes()), e.Wal.Append(r)
 }
 
-// bloomfilter options
+func (e *Engine) assignSequence(r *record.Record) {
+	// redni brojevi prate sat, pa rastu i posle ponovnog pokretanja
+	e.sequence = max(e.sequence+1, uint64(time.Now().UnixNano()))
+	r.SeqNum = e.sequence
+}
 
-func (e *Engine) BloomFilterCreateNewInstance(key string, expectedElements int, falsePositiveRate float64) {
-	if expectedElements > 100 || expectedElements < 0 || falsePositiveRate > 95.0 || falsePositiveRate < 0 {
-		bloomFilter := bloom.NewBloomFilter(100, 95.0)
-		value := bloomFilter.ToBytes()
-		e.Put("bf_"+key, value, false)
-	} else {
-		bloomFilter := bloom.NewBloomFilter(expectedElements, falsePositiveRate)
-		value := bloomFilter.ToBytes()
-		e.Put("bf_"+key, value, false)
-	}
+// cini upis ili brisanje zapisano u WAL vidljivim
+func (e *Engine) apply(cf *ColumnFamily, r record.Record, walSize int) error {
+	r.Family = 0
+	cf.cache.Set(r.Key, r)
+	return e.addRecordToMemtable(cf, r, walSize)
 }
 
-func (e *Engine) BloomFilterAddElement(key, element string) {
-	bloom_record := e.Get(key, true)
-	if bloom_record != nil && strings.HasPrefix(key, "bf_") {
-		bloomFilter := *bloom.FromBytes(bloom_record.Value)
-		bloomFilter.AddElement(element)
-		value := bloomFilter.ToBytes()
-		e.Put(key, value, false)
-	}
+// Get vraca najnoviju zivu verziju kljuca, ErrNotFound ako je nema.
+func (e *Engine) Get(key string) (*record.Record, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.get(e.defaultFamily, key)
 }
 
-func (e *Engine) BloomFilterCheckElement(key, element string) {
-	bloom_record := e.Get(key, true)
-	if bloom_record != nil && strings.HasPrefix(key, "bf_") {
-		bloomFilter := *bloom.FromBytes(bloom_record.Value)
-		fmt.Println(bloomFilter.CheckElement(element))
+// get ocekuje da je lock zakljucan
+func (e *Engine) get(cf *ColumnFamily, key string) (*record.Record, error) {
+	record, err := e.live(cf, key)
+	if err != nil {
+		return nil, err
 	}
+	// zapis moze biti u memtabeli ili kesu, pozivalac dobija kopiju kao da
+	// je procitan iz sstabele
+	copied := *record
+	copied.Value = bytes.Clone(record.Value)
+	return e.ValueLog.Resolve(&copied)
 }
 
-// hyperloglog options
+// najnovija verzija kljuca, i tombstone, vrednosti iz value loga se ne citaju.
+// Operandi spajanja se spajaju sa verzijom ispod njih.
+func (e *Engine) newest(cf *ColumnFamily, key string) (*record.Record, error) {
+	var v versions
+	cacheable, err := e.findVersions(cf, key, &v, true)
+	if err != nil {
+		return nil, err
+	}
+	if !v.found() {
+		return nil, ErrNotFound
+	}
 
-func (e *Engine) HLLCreateNewInstance(key string, p int) {
-	if p > 10 || p < 0 {
-		hloglog := hll.NewHyperLogLog(4)
-		data := hloglog.ToBytes()
-		e.Put("hll_"+key, data, false)
-	} else {
-		hloglog := hll.NewHyperLogLog(uint8(p))
-		data := hloglog.ToBytes()
-		e.Put("hll_"+key, data, false)
+	newest := v.base
+	if len(v.merges) > 0 {
+		newest, err = cf.operators.Fold(key, v.base, v.merges, e.ValueLog)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cacheable {
+		cf.cache.Set(key, *newest)
 	}
+	return newest, nil
 }
 
-func (e *Engine) HLLDeleteInstance(key string) {
-	if strings.HasPrefix(key, "hll_") {
-		e.Delete(key)
-	} else {
-		fmt.Println("Such HyperLogLog doesn't exist")
+// najnovija verzija kljuca ako nije obrisana, vrednosti iz value loga se ne citaju
+func (e *Engine) live(cf *ColumnFamily, key string) (*record.Record, error) {
+	record, err := e.newest(cf, key)
+	if err != nil {
+		return nil, err
 	}
+	if record.Tombstone || record.Expired(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return record, nil
 }
 
-func (e *Engine) HLLAddElement(keyhll, key string) {
-	record := e.Get(keyhll, true)
-	//hyperloglog not found
-	if record != nil && strings.HasPrefix(keyhll, "hll_") {
-		data := record.Value
-		hloglog := hll.LoadHLL(data)
-		//adding a key
-		hloglog.AddElement(key)
-		e.Put(keyhll, hloglog.ToBytes(), false)
-	} else {
-		fmt.Println("Such HyperLogLog doesn't exist")
-	}
+func (e *Engine) Delete(key string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.delete(e.defaultFamily, key)
 }
 
-func (e *Engine) HLLCardinality(key string) {
-	record := e.Get(key, true)
-	//hyperloglog not found
-	if record != nil && strings.HasPrefix(key, "hll_") {
-		data := record.Value
-		hloglog := hll.LoadHLL(data)
-		estimation := hloglog.Estimate()
-		fmt.Println("The estimation of unique element is: ", estimation)
-	} else {
-		fmt.Println("Such HyperLogLog doesn't exist")
+// delete ocekuje da je lock zakljucan
+func (e *Engine) delete(cf *ColumnFamily, key string) error {
+	_, err := e.live(cf, key)
+	if err != nil {
+		return err
 	}
+	// vrednost tombstone-a se nikad ne cita
+	return e.put(cf, key, nil, true)
 }
 
-// 	cms options
-
-func (e *Engine) CMSCreateNewInstance(key string, epsilon, delta float64) {
-	if epsilon > 1 || epsilon < 0 || delta > 1 || delta < 0 {
-		cms := cms.NewCountMinSketch(0.1, 0.1)
-		e.Put("cms_"+key, cms.ToBytes(), false)
-	} else {
-		cms := cms.NewCountMinSketch(epsilon, delta)
-		e.Put("cms_"+key, cms.ToBytes(), false)
+// DeleteRange brise sve kljuceve u [start, end).
+func (e *Engine) DeleteRange(start, end string) error {
+	if start >= end {
+		return ErrInvalidRange
 	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.deleteRange(e.defaultFamily, start, end)
 }
 
-func (e *Engine) CMSAddElement(key, value string) {
-	record := e.Get(key, true)
-	if record != nil && strings.HasPrefix(key, "cms_") {
-		cms := *cms.LoadCMS(record.Value)
-		cms.AddElement(value)
-		e.Put(record.Key, cms.ToBytes(), false)
+// deleteRange ocekuje da je lock zakljucan
+func (e *Engine) deleteRange(cf *ColumnFamily, start, end string) error {
+	rangeTombstone := record.NewRangeTombstone(start, end)
+	walSize, err := e.log(cf, rangeTombstone)
+	if err != nil {
+		return err
 	}
+	rangeTombstone.Family = 0
+	// verzije obrisanih kljuceva u kesu vise ne vaze
+	cf.cache.Clear()
+	return e.addRecordToMemtable(cf, *rangeTombstone, walSize)
 }
 
-func (e *Engine) CMSCheckFrequency(key, value string) {
-	record := e.Get(key, true)
-	if record != nil && strings.HasPrefix(key, "cms_") {
-		cms := *cms.LoadCMS(record.Value)
-		fmt.Println(cms.NumberOfRepetitions(value))
+// TakeToken uzima token iz ogranicivaca, ErrRateLimited ako ih vise nema.
+func (e *Engine) TakeToken() error {
+	if !e.Tbucket.Take() {
+		return ErrRateLimited
 	}
+	return nil
 }
 
-// simhash options
+// bloomfilter options, strukture se imenuju bez prefiksa svog tipa. Operacije
+// nad strukturom koja ne postoji vracaju ErrNotFound, a ErrWrongType ako ime
+// pripada strukturi drugog tipa.
 
-func (e *Engine) CalculateFingerprintSimHash(key string, text string) error {
-	fingerprint := simhash.CalculateFingerprint(text)
-	value := simhash.ToBytes(fingerprint)
-	err := e.Put("sh_"+key, value, false)
-	if err != nil {
-		return err
+// BloomFilterCreateNewInstance pravi filter za expectedElements elemenata,
+// ErrInvalidParameters osim ako je expectedElements >= 1 i 0 < falsePositiveRate < 1.
+func (e *Engine) BloomFilterCreateNewInstance(name string, expectedElements int, falsePositiveRate float64) error {
+	if expectedElements < 1 || !(falsePositiveRate > 0 && falsePositiveRate < 1) {
+		return ErrInvalidParameters
 	}
-	fmt.Println("fingerprint = " + string(value))
+	bloomFilter := bloom.NewBloomFilter(expectedElements, falsePositiveRate, e.config.HashSeed)
+	value := bloomFilter.ToBytes()
+	return e.putStructure("bf_"+name, value)
+}
 
-	return nil
+func (e *Engine) BloomFilterDeleteInstance(name string) error {
+	return e.deleteStructure(name, "bf_")
 }
 
-func (e *Engine) CalculateHammingDistanceSimHash(key1, key2 string) error {
-	record1 := e.Get(key1, true)
-	if record1 == nil {
-		return errors.New("key not found")
+func (e *Engine) BloomFilterAddElement(name, element string) error {
+	return e.addToStructure(name, "bf_", element)
+}
+
+// BloomContains vraca da li je element mozda dodat u filter, false znaci da
+// sigurno nije.
+func (e *Engine) BloomContains(name, element string) (bool, error) {
+	bloom_record, err := e.getStructure(name, "bf_")
+	if err != nil {
+		return false, err
 	}
-	record2 := e.Get(key2, true)
-	if record2 == nil {
-		return errors.New("key not found")
+	bloomFilter, err := bloom.FromBytes(bloom_record.Value)
+	if err != nil {
+		return false, err
 	}
+	return bloomFilter.CheckElement(element), nil
+}
 
-	fingerprint1 := simhash.LoadFromBytes(record1.Value)
-	fingerprint2 := simhash.LoadFromBytes(record2.Value)
-	hamming := simhash.HammingDistance(fingerprint1, fingerprint2)
-	fmt.Println("Hamming distance = " + fmt.Sprint(hamming))
+// BloomUnion dodaje elemente izvornih filtera u dest. Svi filteri moraju
+// imati istu velicinu i hash funkcije, inace se vraca ErrIncompatible.
+func (e *Engine) BloomUnion(dest string, sources ...string) error {
+	return e.mergeStructures(dest, sources, "bf_", func(value []byte, others [][]byte) ([]byte, error) {
+		return mergeBloomFilters(value, others, (*bloom.BloomFilter).Union)
+	})
+}
 
-	return nil
+// BloomIntersect zadrzava u dest samo bitove postavljene u svim izvornim
+// filterima, rezultat moze prijaviti element dodat samo u jedan od njih.
+func (e *Engine) BloomIntersect(dest string, sources ...string) error {
+	return e.mergeStructures(dest, sources, "bf_", func(value []byte, others [][]byte) ([]byte, error) {
+		return mergeBloomFilters(value, others, (*bloom.BloomFilter).Intersect)
+	})
 }
 
-func (e *Engine) recover() error {
-	all_records, err := e.Wal.IndependentLoadAllRecords()
+func mergeBloomFilters(value []byte, others [][]byte, merge func(*bloom.BloomFilter, *bloom.BloomFilter) error) ([]byte, error) {
+	bloomFilter, err := bloom.FromBytes(value)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	for i := len(all_records) - 1; i >= 0; i-- {
-		e.addRecordToMemtable(all_records[i])
+	for _, other := range others {
+		otherFilter, err := bloom.FromBytes(other)
+		if err != nil {
+			return nil, err
+		}
+		err = merge(bloomFilter, otherFilter)
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	return nil
+	return bloomFilter.ToBytes(), nil
 }
 
-func (e *Engine) addRecordToMemtable(recordToAdd record.Record) {
-	successful := e.all_memtables[e.active_memtable_index].Insert(recordToAdd)
-	if !successful {
-		// poveca pokazivac na aktivnu memtablelu i podeli po modulu da bi mogli da se pozicioniramo u listi
-		e.active_memtable_index = (e.active_memtable_index + 1) % e.config.NumberOfMemtables
+// hyperloglog options
 
-		if e.all_memtables[e.active_memtable_index].CurrentSize == e.config.MaxSize {
-			memSize := e.all_memtables[e.active_memtable_index].SizeOfRecordsInWal
-			all_records := e.all_memtables[e.active_memtable_index].Flush()
-			e.Wal.DeleteWalSegmentsEngine(memSize)
-			sstable.NewSSTable(all_records, &e.config, 1, &e.KeyDictionary)
-			lsm.Compact(&e.config, &e.KeyDictionary)
-			e.all_memtables[e.active_memtable_index] = memtable.MemtableConstructor(e.config)
-		}
-		e.all_memtables[e.active_memtable_index].Insert(recordToAdd)
+// HLLCreateNewInstance pravi HyperLogLog sa 2^p registara, ErrInvalidParameters
+// ako p nije u [config.HLL_MIN_PRECISION, config.HLL_MAX_PRECISION].
+func (e *Engine) HLLCreateNewInstance(name string, p int) error {
+	if p > config.HLL_MAX_PRECISION || p < config.HLL_MIN_PRECISION {
+		return ErrInvalidParameters
 	}
+	hloglog := hll.NewHyperLogLog(uint8(p))
+	data := hloglog.ToBytes()
+	return e.putStructure("hll_"+name, data)
 }
 
-func (e *Engine) PrefixScan(prefix string, pageNumber, pageSize int) []record.Record {
-	var page []record.Record
-	currentPage := 1
+func (e *Engine) HLLDeleteInstance(name string) error {
+	return e.deleteStructure(name, "hll_")
+}
 
-	sstables := allSSTables()
-	var sstablesOffsets []int
-	memtables := e.all_memtables
-	var memtableIndexes []int
+func (e *Engine) HLLAddElement(name, element string) error {
+	return e.addToStructure(name, "hll_", element)
+}
 
-	i := 0
-	for i < len(memtables) && len(memtables) != 0 {
-		record, index := memtable.FindFirstPrefixMemtable(*memtables[i], prefix, e.config.MemtableStructure)
-		if record != nil {
-			page = append(page, *record)
-			memtableIndexes = append(memtableIndexes, index)
-			i++
-		} else {
-			memtables = append(memtables[:i], memtables[i+1:]...)
-		}
+// HLLEstimate vraca procenjen broj jedinstvenih elemenata.
+func (e *Engine) HLLEstimate(name string) (float64, error) {
+	record, err := e.getStructure(name, "hll_")
+	if err != nil {
+		return 0, err
 	}
+	hloglog, err := hll.LoadHLL(record.Value)
+	if err != nil {
+		return 0, err
+	}
+	return hloglog.Estimate(), nil
+}
 
-	i = 0
-	for i < len(sstables) && len(sstables) != 0 {
-		record, offset, _ := sstable.FindFirstPrefixSSTable(sstables[i][0], sstables[i][1], prefix)
-		if record != nil {
-			page = append(page, *record)
-			sstablesOffsets = append(sstablesOffsets, offset)
-			i++
-		} else {
-			sstables = append(sstables[:i], sstables[i+1:]...)
+// HLLMerge spaja izvorne HyperLogLog-ove u dest, koji zatim procenjuje
+// kardinalnost njihove unije. Svi moraju imati istu preciznost.
+func (e *Engine) HLLMerge(dest string, sources ...string) error {
+	return e.mergeStructures(dest, sources, "hll_", func(value []byte, others [][]byte) ([]byte, error) {
+		hloglog, err := hll.LoadHLL(value)
+		if err != nil {
+			return nil, err
 		}
-	}
+		for _, other := range others {
+			otherHLL, err := hll.LoadHLL(other)
+			if err != nil {
+				return nil, err
+			}
+			err = hloglog.Merge(otherHLL)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return hloglog.ToBytes(), nil
+	})
+}
 
-	if len(memtables) == 0 && len(sstables) == 0 {
-		return nil
+// 	cms options
+
+// CMSCreateNewInstance pravi sketch sa greskom epsilon i verovatnocom greske
+// delta, ErrInvalidParameters osim ako je 0 < epsilon < 1 i 0 < delta < 1.
+func (e *Engine) CMSCreateNewInstance(name string, epsilon, delta float64) error {
+	if !(epsilon > 0 && epsilon < 1) || !(delta > 0 && delta < 1) {
+		return ErrInvalidParameters
 	}
+	cms := cms.NewCountMinSketch(epsilon, delta, e.config.HashSeed)
+	return e.putStructure("cms_"+name, cms.ToBytes())
+}
 
-	page = sortAndRemoveSame(page)
+func (e *Engine) CMSDeleteInstance(name string) error {
+	return e.deleteStructure(name, "cms_")
+}
+
+func (e *Engine) CMSAddElement(name, element string) error {
+	return e.addToStructure(name, "cms_", element)
+}
 
-	if pageNumber == 1 && len(page) >= pageSize {
-		return page[:pageSize]
+// CMSCount vraca procenjen broj dodavanja elementa, nikad manji od stvarnog.
+func (e *Engine) CMSCount(name, element string) (uint32, error) {
+	record, err := e.getStructure(name, "cms_")
+	if err != nil {
+		return 0, err
+	}
+	cms, err := cms.LoadCMS(record.Value)
+	if err != nil {
+		return 0, err
 	}
+	return cms.NumberOfRepetitions(element), nil
+}
 
-	for len(memtables) != 0 && len(sstables) != 0 {
-		i := 0
-		for i < len(memtables) && len(memtables) != 0 {
-			record, index := memtable.GetNextPrefixMemtable(*memtables[i], prefix, memtableIndexes[i], e.config.MemtableStructure)
-			if record != nil {
-				page = append(page, *record)
-				memtableIndexes = append(memtableIndexes, index)
-				i++
-			} else {
-				memtables = append(memtables[:i], memtables[i+1:]...)
-			}
+// CMSMerge dodaje brojace izvornih sketch-eva u dest. Svi moraju imati iste
+// dimenzije i hash funkcije.
+func (e *Engine) CMSMerge(dest string, sources ...string) error {
+	return e.mergeStructures(dest, sources, "cms_", func(value []byte, others [][]byte) ([]byte, error) {
+		sketch, err := cms.LoadCMS(value)
+		if err != nil {
+			return nil, err
 		}
-
-		i = 0
-		for i < len(sstables) && len(sstables) != 0 {
-			record, offset, _ := sstable.GetNextPrefixSSTable(sstables[i][0], sstables[i][1], prefix, int64(sstablesOffsets[i]))
-			if record != nil {
-				page = append(page, *record)
-				sstablesOffsets = append(sstablesOffsets, offset)
-				i++
-			} else {
-				sstables = append(sstables[:i], sstables[i+1:]...)
+		for _, other := range others {
+			otherSketch, err := cms.LoadCMS(other)
+			if err != nil {
+				return nil, err
+			}
+			err = sketch.Merge(otherSketch)
+			if err != nil {
+				return nil, err
 			}
 		}
+		return sketch.ToBytes(), nil
+	})
+}
 
-		page = sortAndRemoveSame(page)
+// simhash options
 
-		if len(page) >= pageSize && currentPage == pageNumber {
-			break
-		} else if len(page) >= pageSize && currentPage < pageNumber {
-			currentPage++
-			page = page[pageSize:]
-		} else if len(memtables) == 0 && len(sstables) == 0 && currentPage < pageNumber {
-			return nil
-		} else {
-			continue
-		}
+// CalculateFingerprintSimHash cuva otisak teksta pod datim imenom i vraca ga
+// u hex obliku.
+func (e *Engine) CalculateFingerprintSimHash(name string, text string) (string, error) {
+	fingerprint := simhash.CalculateFingerprint(text)
+	value := simhash.ToBytes(fingerprint)
+	err := e.putStructure("sh_"+name, value)
+	if err != nil {
+		return "", err
+	}
+	return simhash.ToHex(fingerprint), nil
+}
 
+// SimHashDistance vraca Hamingovo rastojanje dva sacuvana otiska.
+func (e *Engine) SimHashDistance(name1, name2 string) (int, error) {
+	record1, err := e.getStructure(name1, "sh_")
+	if err != nil {
+		return 0, err
+	}
+	record2, err := e.getStructure(name2, "sh_")
+	if err != nil {
+		return 0, err
 	}
 
-	if len(page) <= pageSize {
-		return page
-	} else {
-		return page[:pageSize]
+	fingerprint1, err := simhash.LoadFromBytes(record1.Value)
+	if err != nil {
+		return 0, err
 	}
+	fingerprint2, err := simhash.LoadFromBytes(record2.Value)
+	if err != nil {
+		return 0, err
+	}
+	return simhash.HammingDistance(fingerprint1, fingerprint2), nil
 }
 
-func (e *Engine) RangeScan(minKey, maxKey string, pageNumber, pageSize int) []record.Record {
-	var page []record.Record
-	currentPage := 1
-
-	sstables := allSSTables()
-	var sstablesOffsets []int
-	memtables := e.all_memtables
-	var memtableIndexes []int
+func (e *Engine) recover() error {
+	// i kod ostecenja se ponavljaju zapisi pre ostecenog
+	all_records, err := e.Wal.IndependentLoadAllRecords()
 
-	i := 0
-	for i < len(memtables) && len(memtables) != 0 {
-		record, index := memtable.FindMinRangeScanMemtable(*memtables[i], minKey, maxKey, e.config.MemtableStructure)
-		if record != nil {
-			page = append(page, *record)
-			memtableIndexes = append(memtableIndexes, index)
-			i++
-		} else {
-			memtables = append(memtables[:i], memtables[i+1:]...)
+	// zapisi se ponavljaju redom upisa da bi noviji zamenili starije, zapisi
+	// grupe tek kada se procita njen poslednji zapis
+	var batch []record.Record
+	for i := 0; i < len(all_records); i++ {
+		e.sequence = max(e.sequence, all_records[i].SeqNum)
+		batch = append(batch, all_records[i])
+		if all_records[i].Batch {
+			continue
 		}
-	}
-
-	i = 0
-	for i < len(sstables) && len(sstables) != 0 {
-		record, offset, _ := sstable.FindMinKeyRangeScanSSTable(sstables[i][0], sstables[i][1], minKey, maxKey)
-		if record != nil {
-			page = append(page, *record)
-			sstablesOffsets = append(sstablesOffsets, offset)
-			i++
-		} else {
-			sstables = append(sstables[:i], sstables[i+1:]...)
+		for _, batchRecord := range batch {
+			walSize := len(batchRecord.ToBytes())
+			cf := e.familyByID(batchRecord.Family)
+			if cf == nil || cf.flushed(batchRecord) {
+				// familija je obrisana ili je zapis vec u njenoj sstabeli
+				e.trackWal(nil, walSize)
+				continue
+			}
+			batchRecord.Batch = false
+			batchRecord.Family = 0
+			addErr := e.addRecordToMemtable(cf, batchRecord, walSize)
+			if addErr != nil && err == nil {
+				err = addErr
+			}
 		}
+		batch = batch[:0]
 	}
-
-	if len(memtables) == 0 && len(sstables) == 0 {
-		return nil
+	// grupa bez poslednjeg zapisa nikad nije potvrdjena
+	for _, batchRecord := range batch {
+		e.trackWal(nil, len(batchRecord.ToBytes()))
 	}
 
-	page = sortAndRemoveSame(page)
-
-	if pageNumber == 1 && len(page) >= pageSize {
-		return page[:pageSize]
-	}
+	return err
+}
 
-	for len(memtables) != 0 && len(sstables) != 0 {
-		i := 0
-		for i < len(memtables) && len(memtables) != 0 {
-			record, index := memtable.GetNextMinRangeScanMemtable(*memtables[i], minKey, maxKey, memtableIndexes[i], e.config.MemtableStructure)
-			if record != nil {
-				page = append(page, *record)
-				memtableIndexes = append(memtableIndexes, index)
-				i++
-			} else {
-				memtables = append(memtables[:i], memtables[i+1:]...)
-			}
+// walSize je velicina zapisa u WAL-u
+func (e *Engine) addRecordToMemtable(cf *ColumnFamily, recordToAdd record.Record, walSize int) error {
+	var err error
+	if recordToAdd.RangeTombstone {
+		cf.memtables[cf.active].DeleteRange(recordToAdd)
+	} else if recordToAdd.Merge {
+		err = e.mergeIntoMemtable(cf, recordToAdd)
+	} else if !cf.memtables[cf.active].Insert(recordToAdd) {
+		err = e.rotateMemtable(cf)
+		// memtabela ostaje puna ako njen upis u sstabelu nije uspeo
+		if !cf.memtables[cf.active].Insert(recordToAdd) && err == nil {
+			err = ErrMemtableFull
 		}
+	}
+	e.trackWal(cf.memtables[cf.active], walSize)
+	return err
+}
 
-		i = 0
-		for i < len(sstables) && len(sstables) != 0 {
-			record, offset, _ := sstable.GetNextMinRangeScanSSTable(sstables[i][0], sstables[i][1], minKey, maxKey, int64(sstablesOffsets[i]))
-			if record != nil {
-				page = append(page, *record)
-				sstablesOffsets = append(sstablesOffsets, offset)
-				i++
-			} else {
-				sstables = append(sstables[:i], sstables[i+1:]...)
-			}
+// sledeca memtabela postaje aktivna, ako je puna prvo se upisuje u sstabelu
+func (e *Engine) rotateMemtable(cf *ColumnFamily) error {
+	var err error
+	// poveca pokazivac na aktivnu memtablelu i podeli po modulu da bi mogli da se pozicioniramo u listi
+	cf.active = (cf.active + 1) % cf.config.NumberOfMemtables
+
+	if cf.memtables[cf.active].CurrentSize == cf.config.MaxSize {
+		err = e.flushMemtable(cf, cf.active)
+		truncateErr := e.truncateWal()
+		if err == nil {
+			err = truncateErr
 		}
+	}
+	return err
+}
 
-		page = sortAndRemoveSame(page)
+// upisuje memtabelu u sstabelu i zamenjuje je praznom
+func (e *Engine) flushMemtable(cf *ColumnFamily, i int) error {
+	rangeTombstones := cf.memtables[i].RangeTombstones()
+	// memtabela se zamenjuje tek kada je njena tabela upisana
+	all_records := cf.memtables[i].Records()
+	flushed := cf.markFlushed(append(all_records, rangeTombstones...))
+	_, err := sstable.NewSSTable(all_records, rangeTombstones, &cf.config, 1)
+	if err != nil {
+		cf.config.FlushedSequence = flushed
+		return err
+	}
+	// zapisi su u novoj tabeli i ako kompakcija ne uspe
+	cf.memtables[i] = memtable.MemtableConstructor(cf.config)
+	// kompakcija brise tabele koje otvoreni iteratori citaju, pa se odlaze
+	// do prvog flush-a posle njihovog zatvaranja
+	if cf.iterators.Load() > 0 {
+		return nil
+	}
+	_, err = lsm.Compact(&cf.config, cf.operators, e.ValueLog)
+	return err
+}
 
-		if len(page) >= pageSize && currentPage == pageNumber {
-			break
-		} else if len(page) >= pageSize && currentPage < pageNumber {
-			currentPage++
-			page = page[pageSize:]
-		} else if len(memtables) == 0 && len(sstables) == 0 && currentPage < pageNumber {
-			return nil
-		} else {
-			continue
-		}
+// PrefixScan vraca najvise pageSize zapisa sa datim prefiksom i token za
+// sledecu stranu, prazan token pocinje skeniranje od pocetka
+func (e *Engine) PrefixScan(prefix, token string, pageSize int, descending bool) ([]record.Record, string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 
+	it, err := e.newPrefixIterator(e.defaultFamily, prefix)
+	if err != nil {
+		return nil, "", err
 	}
+	defer it.Close()
 
-	if len(page) <= pageSize {
-		return page
-	} else {
-		return page[:pageSize]
-	}
+	return scanPage(it, scanBounds("prefix", prefix), token, pageSize, descending)
 }
 
-func sortAndRemoveSame(page []record.Record) []record.Record {
-	sort.Slice(page, func(i, j int) bool {
-		if page[i].Key != page[j].Key {
-			return page[i].Key < page[j].Key
-		}
-		return page[i].Timestamp > page[j].Timestamp
-	})
+func (e *Engine) RangeScan(minKey, maxKey, token string, pageSize int, descending bool) ([]record.Record, string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 
-	var result []record.Record
-	seen := make(map[string]bool)
-	for _, r := range page {
-		if !seen[r.Key] {
-			seen[r.Key] = true
-			result = append(result, r)
-		}
+	it, err := e.newRangeIterator(e.defaultFamily, minKey, maxKey)
+	if err != nil {
+		return nil, "", err
 	}
+	defer it.Close()
 
-	return result
+	return scanPage(it, scanBounds("range", minKey, maxKey), token, pageSize, descending)
 }
 
-func allSSTables() [][]int {
+func allSSTables(directory string) [][]int {
 	var data [][]int
-	files, _ := os.ReadDir(config.SSTABLE_DIRECTORY)
+	files, _ := os.ReadDir(directory)
 
 	for _, file := range files {
 		if strings.Contains(file.Name(), "sstable_data") {
@@ -527,20 +637,3 @@ func allSSTables() [][]int {
 
 	return data
 }
-
-func (e *Engine) SerializeMap(m map[int]string) ([]byte, error) {
-	return json.Marshal(m)
-}
-
-func (e *Engine) DeserializeMap(data []byte) (map[int]string, error) {
-	var m map[int]string
-	err := json.Unmarshal(data, &m)
-	return m, err
-}
-
-func (e *Engine) checkSpecialKey(key string) bool {
-	if strings.HasPrefix(key, "bf_") || strings.HasPrefix(key, "cms_") || strings.HasPrefix(key, "hll_") || strings.HasPrefix(key, "sh_") || strings.HasPrefix(key, "tb_") {
-		return true
-	}
-	return false
-}
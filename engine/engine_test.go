@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"main/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// putanje podataka su relativne, pa test radi u privremenom direktorijumu,
+// a bez config fajla engine koristi podrazumevana podesavanja
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, sub := range []string{"data/wal", "data/sstable", "config"} {
+		err = os.MkdirAll(filepath.Join(dir, sub), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// otvara engine nad trenutnim direktorijumom, operatori se registruju pre oporavka
+func openEngine(t *testing.T, operators map[string]MergeOperator) *Engine {
+	t.Helper()
+	e := &Engine{}
+	for prefix, operator := range operators {
+		e.RegisterMergeOperator(prefix, operator)
+	}
+	err := e.Engine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func fill(t *testing.T, e *Engine, prefix string, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		err := e.Put(fmt.Sprintf("%s%d", prefix, i), []byte("x"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func expectValue(t *testing.T, e *Engine, key, want string) {
+	t.Helper()
+	r, err := e.Get(key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	if string(r.Value) != want {
+		t.Fatalf("Get(%q) = %q, want %q", key, r.Value, want)
+	}
+}
+
+// upisuje config sa podrazumevanim podesavanjima koja change menja
+func writeConfig(t *testing.T, change func(cfg *config.Config)) {
+	t.Helper()
+	var cfg config.Config
+	config.LoadConfig(&cfg)
+	change(&cfg)
+	err := cfg.WriteConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetReturnsCopy(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	err := e.Put("key", []byte("value"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := e.Get("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Value[0] = 'X'
+	r.Tombstone = true
+	expectValue(t, e, "key", "value")
+
+	_, err = e.Get("missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing key: %v, want ErrNotFound", err)
+	}
+}
+
+// upis koji ne stane u memtabelu jer flush nije uspeo vraca gresku, a posle
+// ponovnog pokretanja se vraca iz WAL-a
+func TestPutWhenFlushFails(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	err := os.RemoveAll("data/sstable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed string
+	for i := 0; i < 30 && failed == ""; i++ {
+		key := fmt.Sprint("k", i)
+		if e.Put(key, []byte("x"), false) != nil {
+			failed = key
+		}
+	}
+	if failed == "" {
+		t.Fatal("Put succeeded without a table directory")
+	}
+
+	err = os.MkdirAll("data/sstable", 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = openEngine(t, nil)
+	expectValue(t, e, failed, "x")
+}
+
+// istekao zapis se ne cita ni pre ni posle kompakcije, a ne otkriva ni
+// stariju verziju kljuca
+func TestExpiredRecordsAreNotRead(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	err := e.Put("gone", []byte("old"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fill(t, e, "a", 10)
+	err = e.PutWithExpiry("gone", []byte("new"), time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.PutWithExpiry("kept", []byte("value"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for round := 0; round < 2; round++ {
+		if _, err = e.Get("gone"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("round %d: Get of an expired key: %v, want ErrNotFound", round, err)
+		}
+		expectValue(t, e, "kept", "value")
+		it, err := e.NewRangeIterator("g", "l")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !it.Valid() || it.Key() != "kept" {
+			t.Fatalf("round %d: iterator starts at %q, want kept", round, it.Key())
+		}
+		it.Close()
+
+		// flush i kompakcija prepisuju oba kljuca
+		fill(t, e, "b", 20)
+	}
+}
+
+func TestDeleteRange(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	fill(t, e, "a", 10)
+	// deo kljuceva je u kesu
+	expectValue(t, e, "a4", "x")
+	err := e.DeleteRange("a3", "a7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.DeleteRange("b", "a"); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("DeleteRange with start after end: %v, want ErrInvalidRange", err)
+	}
+
+	check := func(stage string) {
+		t.Helper()
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprint("a", i)
+			_, err := e.Get(key)
+			deleted := i >= 3 && i < 7
+			if deleted && !errors.Is(err, ErrNotFound) {
+				t.Fatalf("%s: Get(%q) = %v, want ErrNotFound", stage, key, err)
+			} else if !deleted && err != nil {
+				t.Fatalf("%s: Get(%q): %v", stage, key, err)
+			}
+		}
+		it, err := e.NewPrefixIterator("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer it.Close()
+		var keys []string
+		for ; it.Valid(); it.Next() {
+			keys = append(keys, it.Key())
+		}
+		if got := fmt.Sprint(keys); got != "[a0 a1 a2 a7 a8 a9]" {
+			t.Fatalf("%s: iterator returned %s", stage, got)
+		}
+	}
+	check("memtable")
+	e = openEngine(t, nil)
+	check("after restart")
+	fill(t, e, "b", 30)
+	check("after compaction")
+
+	// upis posle brisanja opsega je noviji od njega
+	err = e.Put("a4", []byte("new"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectValue(t, e, "a4", "new")
+}
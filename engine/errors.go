@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"errors"
+	"main/envelope"
+	mergeoperator "main/mergeOperator"
+	"main/record"
+)
+
+var (
+	// ErrNotFound se vraca za kljuceve koji nisu upisani ili su obrisani.
+	ErrNotFound = record.ErrNotFound
+	// ErrRateLimited se vraca kada u token bucket-u nema vise tokena.
+	ErrRateLimited = errors.New("rate limit exceeded")
+	// ErrWrongType se vraca kada operacija dobije ime strukture drugog tipa,
+	// ili je sacuvana vrednost drugog tipa.
+	ErrWrongType = envelope.ErrWrongType
+	// ErrMalformedStructure se vraca kada sacuvana struktura ne prodje proveru
+	// kontrolne sume ili ne moze da se procita.
+	ErrMalformedStructure = envelope.ErrMalformed
+	// ErrIncompatible se vraca pri spajanju struktura sa razlicitim
+	// parametrima ili hash funkcijama.
+	ErrIncompatible = envelope.ErrIncompatible
+	// ErrInvalidParameters se vraca kada parametri nove strukture nisu u
+	// dozvoljenom opsegu.
+	ErrInvalidParameters = errors.New("invalid structure parameters")
+	// ErrMemtableFull se vraca kada zapis ne stane u memtabelu ni posle
+	// rotacije. Zapis je u WAL-u, pa se vraca pri oporavku.
+	ErrMemtableFull = errors.New("memtable is full")
+	// ErrInvalidRange se vraca kada pocetak opsega nije pre njegovog kraja.
+	ErrInvalidRange = errors.New("range start must be less than range end")
+	// ErrNoMergeOperator vraca Merge za kljuceve bez registrovanog operatora.
+	ErrNoMergeOperator = mergeoperator.ErrNoOperator
+	// ErrInvalidOperand vraca Merge kada operator odbije operand, a citanje
+	// kada operandi ne mogu da se spoje sa vrednoscu kljuca.
+	ErrInvalidOperand = mergeoperator.ErrUnfoldable
+	// ErrTxnConflict vraca Commit kada se kljuc koji je transakcija procitala
+	// promenio posle citanja.
+	ErrTxnConflict = errors.New("transaction conflict")
+	// ErrTxnDone se vraca pri koriscenju zavrsene ili ponistene transakcije.
+	ErrTxnDone = errors.New("transaction already finished")
+	// ErrNoColumnFamily se vraca za familiju kolona koja ne postoji ili je obrisana.
+	ErrNoColumnFamily = errors.New("column family not found")
+	// ErrColumnFamilyExists se vraca pri pravljenju familije cije je ime zauzeto.
+	ErrColumnFamilyExists = errors.New("column family already exists")
+	// ErrInvalidColumnFamily se vraca za neispravno ime familije i pri
+	// brisanju podrazumevane familije.
+	ErrInvalidColumnFamily = errors.New("invalid column family")
+)
+
+// ErrCorruption opisuje osteceni zapis na disku, errors.As daje fajl i poziciju.
+type ErrCorruption = record.ErrCorruption
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"main/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNotFound(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	_, err := e.Get("missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing key: %v, want ErrNotFound", err)
+	}
+
+	fill(t, e, "k", 5)
+	err = e.Delete("k0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// brisanje mora biti vidljivo i kada je zapis vec u sstabeli
+	fill(t, e, "other", 20)
+	_, err = e.Get("k0")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a deleted key: %v, want ErrNotFound", err)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	chdirTemp(t)
+	writeConfig(t, func(cfg *config.Config) {
+		cfg.Capacity = 2
+		cfg.Rate = 1
+	})
+	e := openEngine(t, nil)
+	for i := 0; i < 2; i++ {
+		if err := e.TakeToken(); err != nil {
+			t.Fatalf("token %d: %v", i, err)
+		}
+	}
+	if err := e.TakeToken(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("TakeToken with an empty bucket: %v, want ErrRateLimited", err)
+	}
+}
+
+func TestCorruptionIsTyped(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	fill(t, e, "k", 20)
+
+	// menja vrednosti zapisa u svim tabelama, pa im crc ne odgovara
+	tables, err := filepath.Glob("data/sstable/*_sstable_data_*")
+	if err != nil || len(tables) == 0 {
+		t.Fatalf("no tables were written: %v", err)
+	}
+	for _, table := range tables {
+		data, err := os.ReadFile(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(table, bytes.ReplaceAll(data, []byte("x"), []byte("y")), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e = openEngine(t, nil)
+	_, err = e.Get("k0")
+	var corruption *ErrCorruption
+	if !errors.As(err, &corruption) {
+		t.Fatalf("Get of a damaged record: %v, want *ErrCorruption", err)
+	}
+	if !strings.Contains(corruption.File, "sstable_data") {
+		t.Fatalf("corruption reported in %q, want the data file", corruption.File)
+	}
+}
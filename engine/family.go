@@ -0,0 +1,440 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"main/cache"
+	"main/config"
+	"main/memtable"
+	mergeoperator "main/mergeOperator"
+	"main/record"
+	"os"
+	"sort"
+	"sync/atomic"
+)
+
+// DefaultColumnFamily je familija koju koriste metode samog Engine-a.
+const DefaultColumnFamily = "default"
+
+// familije osim podrazumevane i sledeci slobodan id, podrazumevana familija
+// ima id 0 i cuva tabele u config.SSTABLE_DIRECTORY
+const familyManifestPath = config.FAMILY_DIRECTORY + "families.json"
+
+type familyManifest struct {
+	NextID   uint32            `json:"NextID"`
+	Families map[string]uint32 `json:"Families"`
+}
+
+// ColumnFamily je imenovan prostor kljuceva sa svojim memtabelama,
+// sstabelama i podesavanjima kompakcije. Sve familije dele WAL, pa
+// transakcija moze atomicno pisati u vise njih. Samo podrazumevana familija
+// cuva velike vrednosti u value logu.
+type ColumnFamily struct {
+	engine    *Engine
+	name      string
+	id        uint32
+	config    config.Config
+	cache     *cache.Cache
+	memtables []*memtable.Memtable
+	active    int
+	dropped   bool
+	operators *mergeoperator.Registry // samo sistemska familija ima operatore struktura
+	iterators atomic.Int32            // otvoreni iteratori, kompakcija ceka da se zatvore
+}
+
+// ColumnFamilyOptions menjaju podesavanja iz config-a za novu familiju,
+// nulta vrednost zadrzava podesavanje engine-a.
+type ColumnFamilyOptions struct {
+	MemtableStructure string
+	MaxSize           int
+	NumberOfMemtables int
+	CompactBy         string
+	CompactType       string
+	MaxTables         int
+	MaxBytesSSTables  int
+	NumberOfLevels    int
+}
+
+func newColumnFamily(e *Engine, name string, id uint32, cfg config.Config) *ColumnFamily {
+	cf := &ColumnFamily{engine: e, name: name, id: id, config: cfg, operators: e.operators()}
+	cf.cache = cache.NewCache(cfg)
+	cf.memtables = memtable.LoadAllMemtables(cfg)
+	return cf
+}
+
+// pravi podrazumevanu familiju i ucitava ostale iz manifesta
+func (e *Engine) loadColumnFamilies() error {
+	defaultFamily := newColumnFamily(e, DefaultColumnFamily, 0, e.config)
+	defaultFamily.cache = &e.Cache
+	e.defaultFamily = defaultFamily
+	e.families = map[string]*ColumnFamily{DefaultColumnFamily: defaultFamily}
+	e.nextFamilyID = 1
+
+	data, err := os.ReadFile(familyManifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var manifest familyManifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return err
+	}
+	e.nextFamilyID = max(manifest.NextID, 1)
+	for name, id := range manifest.Families {
+		var cfg config.Config
+		err = config.LoadFamilyConfig(&cfg, name)
+		if err != nil {
+			return err
+		}
+		e.families[name] = newColumnFamily(e, name, id, cfg)
+	}
+	return nil
+}
+
+func (e *Engine) writeManifest() error {
+	manifest := familyManifest{NextID: e.nextFamilyID, Families: make(map[string]uint32)}
+	for name, cf := range e.families {
+		if cf != e.defaultFamily {
+			manifest.Families[name] = cf.id
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(config.FAMILY_DIRECTORY, 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(familyManifestPath, data, 0644)
+}
+
+// familija zapisa procitanog iz WAL-a, nil ako je obrisana
+func (e *Engine) familyByID(id uint32) *ColumnFamily {
+	for _, cf := range e.families {
+		if cf.id == id {
+			return cf
+		}
+	}
+	return nil
+}
+
+// CreateColumnFamily pravi praznu familiju. Ime sme sadrzati samo slova,
+// cifre, '_' i '-' i ne sme pocinjati sa '_'.
+func (e *Engine) CreateColumnFamily(name string, options ColumnFamilyOptions) (*ColumnFamily, error) {
+	if !validFamilyName(name) {
+		return nil, ErrInvalidColumnFamily
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, found := e.families[name]; found {
+		return nil, ErrColumnFamilyExists
+	}
+	return e.createColumnFamily(name, options)
+}
+
+// createColumnFamily ocekuje da je lock zakljucan
+func (e *Engine) createColumnFamily(name string, options ColumnFamilyOptions) (*ColumnFamily, error) {
+	cfg := e.config
+	cfg.Family = name
+	cfg.NumberOfSSTables = 0
+	options.apply(&cfg)
+	// familija sa istim imenom je mogla ranije biti obrisana
+	err := os.RemoveAll(config.FamilyDirectory(name))
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(config.FamilyDirectory(name), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.WriteConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cf := newColumnFamily(e, name, e.nextFamilyID, cfg)
+	e.families[name] = cf
+	e.nextFamilyID++
+	// familija postoji kada je upisana u manifest
+	err = e.writeManifest()
+	if err != nil {
+		delete(e.families, name)
+		return nil, err
+	}
+	return cf, nil
+}
+
+func (options ColumnFamilyOptions) apply(cfg *config.Config) {
+	if options.MemtableStructure != "" {
+		cfg.MemtableStructure = options.MemtableStructure
+	}
+	if options.MaxSize > 0 {
+		cfg.MaxSize = options.MaxSize
+	}
+	if options.NumberOfMemtables > 0 {
+		cfg.NumberOfMemtables = options.NumberOfMemtables
+	}
+	if options.CompactBy != "" {
+		cfg.CompactBy = options.CompactBy
+	}
+	if options.CompactType != "" {
+		cfg.CompactType = options.CompactType
+	}
+	if options.MaxTables > 0 {
+		cfg.MaxTabels = options.MaxTables
+	}
+	if options.MaxBytesSSTables > 0 {
+		cfg.MaxBytesSSTables = options.MaxBytesSSTables
+	}
+	if options.NumberOfLevels > 0 {
+		cfg.NumberOfLevels = options.NumberOfLevels
+	}
+}
+
+func validFamilyName(name string) bool {
+	if name == "" || name == DefaultColumnFamily || name[0] == '_' {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// ColumnFamily vraca familiju sa datim imenom.
+func (e *Engine) ColumnFamily(name string) (*ColumnFamily, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	cf, found := e.families[name]
+	if !found || cf == e.systemFamily {
+		return nil, ErrNoColumnFamily
+	}
+	return cf, nil
+}
+
+// ColumnFamilies vraca imena svih familija, i podrazumevane.
+// Interna sistemska familija se ne navodi.
+func (e *Engine) ColumnFamilies() []string {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var names []string
+	for name, cf := range e.families {
+		if cf != e.systemFamily {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DropColumnFamily brise familiju i sve njene podatke. Njeni zapisi koji su
+// ostali u WAL-u se preskacu pri oporavku. Podrazumevana familija se ne moze obrisati.
+func (e *Engine) DropColumnFamily(name string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	cf, found := e.families[name]
+	if !found || cf == e.systemFamily {
+		return ErrNoColumnFamily
+	}
+	if cf == e.defaultFamily {
+		return ErrInvalidColumnFamily
+	}
+
+	delete(e.families, name)
+	err := e.writeManifest()
+	if err != nil {
+		e.families[name] = cf
+		return err
+	}
+	cf.dropped = true
+	cf.memtables = nil
+	err = os.RemoveAll(config.FamilyDirectory(name))
+	if err != nil {
+		return err
+	}
+	// zapisi familije vise ne zadrzavaju WAL
+	return e.truncateWal()
+}
+
+// Name vraca ime familije.
+func (cf *ColumnFamily) Name() string {
+	return cf.name
+}
+
+// lock zakljucava engine, ErrNoColumnFamily ako je familija obrisana
+func (cf *ColumnFamily) lock() error {
+	cf.engine.lock.Lock()
+	if cf.dropped {
+		cf.engine.lock.Unlock()
+		return ErrNoColumnFamily
+	}
+	return nil
+}
+
+func (cf *ColumnFamily) Put(key string, value []byte) error {
+	if err := cf.lock(); err != nil {
+		return err
+	}
+	defer cf.engine.lock.Unlock()
+	return cf.engine.put(cf, key, value, false)
+}
+
+// Get vraca najnoviju zivu verziju kljuca, ErrNotFound ako je nema.
+func (cf *ColumnFamily) Get(key string) (*record.Record, error) {
+	if err := cf.lock(); err != nil {
+		return nil, err
+	}
+	defer cf.engine.lock.Unlock()
+	return cf.engine.get(cf, key)
+}
+
+func (cf *ColumnFamily) Delete(key string) error {
+	if err := cf.lock(); err != nil {
+		return err
+	}
+	defer cf.engine.lock.Unlock()
+	return cf.engine.delete(cf, key)
+}
+
+// DeleteRange brise sve kljuceve familije u [start, end).
+func (cf *ColumnFamily) DeleteRange(start, end string) error {
+	if start >= end {
+		return ErrInvalidRange
+	}
+	if err := cf.lock(); err != nil {
+		return err
+	}
+	defer cf.engine.lock.Unlock()
+	return cf.engine.deleteRange(cf, start, end)
+}
+
+// Merge dodaje operand kljucu, vidi Engine.Merge.
+func (cf *ColumnFamily) Merge(key string, operand []byte) error {
+	if err := cf.lock(); err != nil {
+		return err
+	}
+	defer cf.engine.lock.Unlock()
+	return cf.engine.merge(cf, key, operand)
+}
+
+// NewIterator vraca iterator kroz familiju postavljen na njen prvi zivi zapis.
+func (cf *ColumnFamily) NewIterator() (Iterator, error) {
+	if err := cf.lock(); err != nil {
+		return nil, err
+	}
+	defer cf.engine.lock.Unlock()
+	return cf.engine.newMergingIterator(cf)
+}
+
+func (cf *ColumnFamily) NewPrefixIterator(prefix string) (Iterator, error) {
+	if err := cf.lock(); err != nil {
+		return nil, err
+	}
+	defer cf.engine.lock.Unlock()
+	return cf.engine.newPrefixIterator(cf, prefix)
+}
+
+func (cf *ColumnFamily) NewRangeIterator(minKey, maxKey string) (Iterator, error) {
+	if err := cf.lock(); err != nil {
+		return nil, err
+	}
+	defer cf.engine.lock.Unlock()
+	return cf.engine.newRangeIterator(cf, minKey, maxKey)
+}
+
+// pamti najveci redni broj zapisa memtabele koja se upisuje u sstabelu. Broj
+// se cuva sa brojem sstabela, pa oporavak ne ponavlja njene zapise koji su
+// ostali u WAL-u jer ga drze druge familije. Vraca prethodni broj, koji se
+// vraca ako upis sstabele nije uspeo.
+func (cf *ColumnFamily) markFlushed(records []record.Record) uint64 {
+	previous := cf.config.FlushedSequence
+	for _, r := range records {
+		cf.config.FlushedSequence = max(cf.config.FlushedSequence, r.SeqNum)
+	}
+	return previous
+}
+
+// da li je zapis iz WAL-a vec u sstabeli familije, zapisi starog formata
+// nemaju redni broj i uvek se ponavljaju
+func (cf *ColumnFamily) flushed(r record.Record) bool {
+	return r.SeqNum != 0 && r.SeqNum <= cf.config.FlushedSequence
+}
+
+func (cf *ColumnFamily) previousMemtable(i int) int {
+	i--
+	if i < 0 {
+		i = cf.config.NumberOfMemtables - 1
+	}
+	return i
+}
+
+// deo WAL-a koji su upisali uzastopni zapisi jedne memtabele
+type walRun struct {
+	memtable *memtable.Memtable // nil ako zapisi nisu ni u jednoj memtabeli
+	size     int
+}
+
+// pamti da poslednjih walSize bajtova WAL-a pripada memtabeli
+func (e *Engine) trackWal(mt *memtable.Memtable, walSize int) {
+	last := len(e.walRuns) - 1
+	if last >= 0 && e.walRuns[last].memtable == mt {
+		e.walRuns[last].size += walSize
+		return
+	}
+	e.walRuns = append(e.walRuns, walRun{memtable: mt, size: walSize})
+}
+
+// bajtovi WAL-a koje drze familije koje se retko prazne, preko toga se
+// prazni familija sa najstarijim zapisom
+const maxWalSize = 1 << 16
+
+// zapisi svih familija su izmesani u WAL-u, pa se on skracuje samo do prvog
+// zapisa memtabele koja jos nije upisana u sstabelu
+func (e *Engine) truncateWal() error {
+	for {
+		owners := make(map[*memtable.Memtable]*ColumnFamily)
+		for _, cf := range e.families {
+			for _, mt := range cf.memtables {
+				owners[mt] = cf
+			}
+		}
+		size, runs := 0, 0
+		for runs < len(e.walRuns) && owners[e.walRuns[runs].memtable] == nil {
+			size += e.walRuns[runs].size
+			runs++
+		}
+		if size > 0 {
+			// ako WAL nije skracen, delovi ostaju zapamceni
+			if err := e.Wal.DeleteWalSegmentsEngine(size); err != nil {
+				return err
+			}
+		}
+		e.walRuns = e.walRuns[runs:]
+
+		held := 0
+		for _, run := range e.walRuns {
+			held += run.size
+		}
+		if held <= maxWalSize {
+			return nil
+		}
+		oldest := e.walRuns[0].memtable
+		cf := owners[oldest]
+		for i, mt := range cf.memtables {
+			if mt == oldest {
+				if err := e.flushMemtable(cf, i); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func createFamily(t *testing.T, e *Engine, name string, options ColumnFamilyOptions) *ColumnFamily {
+	t.Helper()
+	cf, err := e.CreateColumnFamily(name, options)
+	if err != nil {
+		t.Fatalf("CreateColumnFamily(%q): %v", name, err)
+	}
+	return cf
+}
+
+func expectFamilyValue(t *testing.T, cf *ColumnFamily, key, want string) {
+	t.Helper()
+	r, err := cf.Get(key)
+	if err != nil {
+		t.Fatalf("%s: Get(%q): %v", cf.Name(), key, err)
+	}
+	if string(r.Value) != want {
+		t.Fatalf("%s: Get(%q) = %q, want %q", cf.Name(), key, r.Value, want)
+	}
+}
+
+func TestColumnFamiliesAreSeparate(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	users := createFamily(t, e, "users", ColumnFamilyOptions{MaxSize: 4})
+	err := e.Put("key", []byte("default"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// dovoljno upisa da familija ima svoje sstabele
+	for i := 0; i < 20; i++ {
+		err = users.Put(fmt.Sprint("user", i), []byte("x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = users.Put("key", []byte("users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectValue(t, e, "key", "default")
+	expectFamilyValue(t, users, "key", "users")
+	expectFamilyValue(t, users, "user3", "x")
+	_, err = e.Get("user3")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a key of another family: %v, want ErrNotFound", err)
+	}
+
+	it, err := users.NewPrefixIterator("user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := collect(t, it)
+	it.Close()
+	if len(keys) != 11 {
+		t.Fatalf("prefix iterator of the family returned %v", keys)
+	}
+
+	e = openEngine(t, nil)
+	users, err = e.ColumnFamily("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectValue(t, e, "key", "default")
+	expectFamilyValue(t, users, "key", "users")
+	expectFamilyValue(t, users, "user19", "x")
+}
+
+func TestCreateAndDropColumnFamily(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	for _, name := range []string{"", "default", "_hidden", "a/b", "a b"} {
+		_, err := e.CreateColumnFamily(name, ColumnFamilyOptions{})
+		if !errors.Is(err, ErrInvalidColumnFamily) {
+			t.Fatalf("CreateColumnFamily(%q): %v, want ErrInvalidColumnFamily", name, err)
+		}
+	}
+	cf := createFamily(t, e, "logs", ColumnFamilyOptions{})
+	_, err := e.CreateColumnFamily("logs", ColumnFamilyOptions{})
+	if !errors.Is(err, ErrColumnFamilyExists) {
+		t.Fatalf("second CreateColumnFamily: %v, want ErrColumnFamilyExists", err)
+	}
+	createFamily(t, e, "events", ColumnFamilyOptions{})
+	want := []string{"default", "events", "logs"}
+	if names := e.ColumnFamilies(); !reflect.DeepEqual(names, want) {
+		t.Fatalf("ColumnFamilies() = %v, want %v", names, want)
+	}
+
+	err = cf.Put("key", []byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.DropColumnFamily(DefaultColumnFamily)
+	if !errors.Is(err, ErrInvalidColumnFamily) {
+		t.Fatalf("DropColumnFamily(default): %v, want ErrInvalidColumnFamily", err)
+	}
+	err = e.DropColumnFamily("logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cf.Put("key", nil); !errors.Is(err, ErrNoColumnFamily) {
+		t.Fatalf("Put into a dropped family: %v, want ErrNoColumnFamily", err)
+	}
+	if _, err = cf.Get("key"); !errors.Is(err, ErrNoColumnFamily) {
+		t.Fatalf("Get from a dropped family: %v, want ErrNoColumnFamily", err)
+	}
+	if _, err = e.ColumnFamily("logs"); !errors.Is(err, ErrNoColumnFamily) {
+		t.Fatalf("ColumnFamily of a dropped family: %v, want ErrNoColumnFamily", err)
+	}
+
+	// zapisi obrisane familije ostaju u WAL-u, ali se ne vracaju u novu
+	// familiju sa istim imenom
+	e = openEngine(t, nil)
+	want = []string{"default", "events"}
+	if names := e.ColumnFamilies(); !reflect.DeepEqual(names, want) {
+		t.Fatalf("ColumnFamilies() after reopen = %v, want %v", names, want)
+	}
+	cf = createFamily(t, e, "logs", ColumnFamilyOptions{})
+	if _, err = cf.Get("key"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get from a recreated family: %v, want ErrNotFound", err)
+	}
+}
+
+// zapisi familije koji su u sstabeli ostaju u WAL-u dok ga drzi druga
+// familija, oporavak ih ne ponavlja. Ponovljen operand bi se sabrao dva puta.
+func TestFlushedRecordsAreNotReplayed(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, counter)
+	// zapis podrazumevane familije ostaje u memtabeli i drzi ceo WAL
+	err := e.Put("hold", []byte("x"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counters := createFamily(t, e, "counters", ColumnFamilyOptions{MaxSize: 4})
+	for i := 0; i < 30; i++ {
+		err = counters.Merge("c_total", []byte("1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = counters.Put(fmt.Sprint("key", i), []byte("x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counters.config.FlushedSequence == 0 {
+		t.Fatal("the family was never flushed")
+	}
+	expectFamilyValue(t, counters, "c_total", "30")
+
+	for i := 0; i < 2; i++ {
+		e = openEngine(t, counter)
+		counters, err = e.ColumnFamily("counters")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectFamilyValue(t, counters, "c_total", "30")
+		expectValue(t, e, "hold", "x")
+	}
+}
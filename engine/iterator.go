@@ -0,0 +1,420 @@
+package engine
+
+import (
+	"container/heap"
+	mergeoperator "main/mergeOperator"
+	"main/record"
+	"main/sstable"
+	valuelog "main/valueLog"
+	"strings"
+	"time"
+)
+
+// Iterator prolazi kroz zive zapise engine-a redom po kljucu.
+type Iterator interface {
+	Seek(key string)
+	SeekForPrev(key string)
+	SeekToFirst()
+	SeekToLast()
+	Next()
+	Prev()
+	Key() string
+	Value() []byte
+	Valid() bool
+	// Err vraca razlog ranijeg prekida, npr. *record.ErrCorruption.
+	Err() error
+	Close() error
+}
+
+// iterator kroz jednu memtabelu ili sstabelu
+type childIterator interface {
+	Seek(key string)
+	SeekForPrev(key string)
+	SeekToFirst()
+	SeekToLast()
+	Next()
+	Prev()
+	Valid() bool
+	Record() *record.Record
+	// brisanja opsega izvora, pokrivaju kljuceve starijih izvora
+	RangeTombstones() []record.Record
+	Err() error
+	Close() error
+}
+
+type heapItem struct {
+	iterator childIterator
+	priority int // manji prioritet je noviji izvor
+}
+
+// u obrnutom smeru je najveci kljuc na vrhu
+type iteratorHeap struct {
+	items   []heapItem
+	reverse bool
+}
+
+func (h *iteratorHeap) Len() int { return len(h.items) }
+
+func (h *iteratorHeap) Less(i, j int) bool {
+	keyI := h.items[i].iterator.Record().Key
+	keyJ := h.items[j].iterator.Record().Key
+	if keyI != keyJ {
+		return (keyI < keyJ) != h.reverse
+	}
+	return h.items[i].priority < h.items[j].priority
+}
+
+func (h *iteratorHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *iteratorHeap) Push(x any) { h.items = append(h.items, x.(heapItem)) }
+
+func (h *iteratorHeap) Pop() any {
+	old := h.items
+	item := old[len(old)-1]
+	h.items = old[:len(old)-1]
+	return item
+}
+
+func (h *iteratorHeap) top() childIterator {
+	return h.items[0].iterator
+}
+
+// mergingIterator spaja iteratore izvora poredjane od najnovijeg, vraca samo
+// najnoviju verziju svakog kljuca i preskace tombstone-e i kljuceve obuhvacene
+// brisanjem opsega novijeg izvora. Operandi spajanja se spajaju sa starijim
+// verzijama kljuca.
+type mergingIterator struct {
+	children  []childIterator
+	heap      iteratorHeap
+	current   *record.Record
+	values    *valuelog.ValueLog // cita vrednosti koje su u value logu
+	operators *mergeoperator.Registry
+	err       error
+	family    *ColumnFamily // cije tabele iterator drzi dok se ne zatvori
+}
+
+func newMergingIterator(children []childIterator, values *valuelog.ValueLog, operators *mergeoperator.Registry) *mergingIterator {
+	it := &mergingIterator{children: children, values: values, operators: operators}
+	it.rebuild(false)
+	return it
+}
+
+func (it *mergingIterator) rebuild(reverse bool) {
+	it.heap.items = it.heap.items[:0]
+	it.heap.reverse = reverse
+	for i, child := range it.children {
+		if child.Valid() {
+			it.heap.items = append(it.heap.items, heapItem{iterator: child, priority: i})
+		}
+	}
+	heap.Init(&it.heap)
+	it.findNext()
+}
+
+// uzima najnoviju verziju sledeceg kljuca u trenutnom smeru, starije
+// verzije se preskacu
+func (it *mergingIterator) findNext() {
+	it.current = nil
+	for it.heap.Len() > 0 {
+		newest := *it.heap.top().Record()
+		priority := it.heap.items[0].priority
+		// verzije kljuca po prioritetu, heap ih vraca od najnovije
+		versions := make(map[int]*record.Record)
+		for it.heap.Len() > 0 && it.heap.top().Record().Key == newest.Key {
+			version := *it.heap.top().Record()
+			versions[it.heap.items[0].priority] = &version
+			it.advanceTop()
+		}
+		if newest.Tombstone || newest.Expired(time.Now()) || it.coveredByNewer(priority, newest.Key) {
+			continue
+		}
+		if newest.Merge {
+			it.current, it.err = it.fold(priority, versions)
+		} else {
+			it.current, it.err = it.values.Resolve(&newest)
+		}
+		if it.err != nil {
+			it.current = nil
+		}
+		return
+	}
+}
+
+// spaja operand sa datim prioritetom sa starijim verzijama kljuca
+func (it *mergingIterator) fold(priority int, versions map[int]*record.Record) (*record.Record, error) {
+	newest := versions[priority]
+	merges := []record.Record{*newest}
+	var base *record.Record
+	for i := priority; i < len(it.children) && base == nil; i++ {
+		if version, found := versions[i]; found && i > priority {
+			if !version.Merge {
+				base = version
+				break
+			}
+			merges = append(merges, *version)
+		}
+		// brisanja opsega izvora su starija od njegovih zapisa
+		if record.Covered(it.children[i].RangeTombstones(), newest.Key) {
+			base = &record.Record{Key: newest.Key, Tombstone: true}
+		}
+	}
+	return it.operators.Fold(newest.Key, base, merges, it.values)
+}
+
+// da li je izvor noviji od onog sa datim prioritetom obrisao kljuc
+func (it *mergingIterator) coveredByNewer(priority int, key string) bool {
+	for _, child := range it.children[:priority] {
+		if record.Covered(child.RangeTombstones(), key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *mergingIterator) advanceTop() {
+	if it.heap.reverse {
+		it.heap.top().Prev()
+	} else {
+		it.heap.top().Next()
+	}
+	if it.heap.top().Valid() {
+		heap.Fix(&it.heap, 0)
+	} else {
+		heap.Pop(&it.heap)
+	}
+}
+
+func (it *mergingIterator) Seek(key string) {
+	for _, child := range it.children {
+		child.Seek(key)
+	}
+	it.rebuild(false)
+}
+
+func (it *mergingIterator) SeekForPrev(key string) {
+	for _, child := range it.children {
+		child.SeekForPrev(key)
+	}
+	it.rebuild(true)
+}
+
+func (it *mergingIterator) SeekToFirst() {
+	for _, child := range it.children {
+		child.SeekToFirst()
+	}
+	it.rebuild(false)
+}
+
+func (it *mergingIterator) SeekToLast() {
+	for _, child := range it.children {
+		child.SeekToLast()
+	}
+	it.rebuild(true)
+}
+
+func (it *mergingIterator) Next() {
+	if it.current == nil {
+		return
+	}
+	if !it.heap.reverse {
+		it.findNext()
+		return
+	}
+
+	// pri promeni smera svaki izvor se pomera iza trenutnog kljuca
+	key := it.current.Key
+	for _, child := range it.children {
+		child.Seek(key)
+		if child.Valid() && child.Record().Key == key {
+			child.Next()
+		}
+	}
+	it.rebuild(false)
+}
+
+func (it *mergingIterator) Prev() {
+	if it.current == nil {
+		return
+	}
+	if it.heap.reverse {
+		it.findNext()
+		return
+	}
+
+	key := it.current.Key
+	for _, child := range it.children {
+		child.SeekForPrev(key)
+		if child.Valid() && child.Record().Key == key {
+			child.Prev()
+		}
+	}
+	it.rebuild(true)
+}
+
+func (it *mergingIterator) Valid() bool {
+	return it.current != nil
+}
+
+func (it *mergingIterator) Key() string {
+	return it.current.Key
+}
+
+func (it *mergingIterator) Value() []byte {
+	return it.current.Value
+}
+
+func (it *mergingIterator) Record() *record.Record {
+	return it.current
+}
+
+func (it *mergingIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	for _, child := range it.children {
+		if err := child.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (it *mergingIterator) Close() error {
+	var err error
+	for _, child := range it.children {
+		if closeErr := child.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	it.children = nil
+	it.heap.items = nil
+	it.current = nil
+	if it.family != nil {
+		it.family.iterators.Add(-1)
+		it.family = nil
+	}
+	return err
+}
+
+// boundedIterator ogranicava iterator na kljuceve koje prihvata inRange.
+// lower je najmanji takav kljuc, upper (ako je postavljen) prvi kljuc posle njih.
+type boundedIterator struct {
+	*mergingIterator
+	lower   string
+	upper   string
+	inRange func(key string) bool
+}
+
+func (it *boundedIterator) Seek(key string) {
+	if key < it.lower {
+		key = it.lower
+	}
+	it.mergingIterator.Seek(key)
+}
+
+func (it *boundedIterator) SeekForPrev(key string) {
+	if it.upper != "" && key >= it.upper {
+		it.SeekToLast()
+		return
+	}
+	it.mergingIterator.SeekForPrev(key)
+}
+
+func (it *boundedIterator) SeekToFirst() {
+	it.mergingIterator.Seek(it.lower)
+}
+
+func (it *boundedIterator) SeekToLast() {
+	if it.upper == "" {
+		it.mergingIterator.SeekToLast()
+		return
+	}
+	it.mergingIterator.SeekForPrev(it.upper)
+	if it.mergingIterator.Valid() && it.mergingIterator.Key() == it.upper {
+		it.mergingIterator.Prev()
+	}
+}
+
+func (it *boundedIterator) Valid() bool {
+	return it.mergingIterator.Valid() && it.inRange(it.mergingIterator.Key())
+}
+
+// NewIterator vraca iterator postavljen na prvi zivi zapis.
+// Memtabele idu od aktivne unazad, a sstabele od najnovije.
+func (e *Engine) NewIterator() (Iterator, error) {
+	return e.defaultFamily.NewIterator()
+}
+
+func (e *Engine) NewPrefixIterator(prefix string) (Iterator, error) {
+	return e.defaultFamily.NewPrefixIterator(prefix)
+}
+
+func (e *Engine) NewRangeIterator(minKey, maxKey string) (Iterator, error) {
+	return e.defaultFamily.NewRangeIterator(minKey, maxKey)
+}
+
+func (e *Engine) newPrefixIterator(cf *ColumnFamily, prefix string) (*boundedIterator, error) {
+	return e.newBoundedIterator(cf, prefix, prefixSuccessor(prefix), func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+func (e *Engine) newRangeIterator(cf *ColumnFamily, minKey, maxKey string) (*boundedIterator, error) {
+	return e.newBoundedIterator(cf, minKey, maxKey+"\x00", func(key string) bool {
+		return key >= minKey && key <= maxKey
+	})
+}
+
+func (e *Engine) newBoundedIterator(cf *ColumnFamily, lower, upper string, inRange func(key string) bool) (*boundedIterator, error) {
+	merging, err := e.newMergingIterator(cf)
+	if err != nil {
+		return nil, err
+	}
+	it := &boundedIterator{mergingIterator: merging, lower: lower, upper: upper, inRange: inRange}
+	it.SeekToFirst()
+	return it, nil
+}
+
+// najmanji kljuc veci od svih kljuceva sa datim prefiksom, prazan ako
+// takav ne postoji
+func prefixSuccessor(prefix string) string {
+	successor := []byte(prefix)
+	for i := len(successor) - 1; i >= 0; i-- {
+		if successor[i] < 0xff {
+			successor[i]++
+			return string(successor[:i+1])
+		}
+	}
+	return ""
+}
+
+func (e *Engine) newMergingIterator(cf *ColumnFamily) (*mergingIterator, error) {
+	var children []childIterator
+
+	// memtabele se kopiraju jer se menjaju dok je iterator otvoren
+	i := cf.active
+	for j := 0; j < cf.config.NumberOfMemtables; j++ {
+		if !cf.memtables[i].IsEmpty() {
+			children = append(children, cf.memtables[i].NewSnapshotIterator())
+		}
+		i = cf.previousMemtable(i)
+	}
+
+	directory := cf.config.SSTableDirectory()
+	sstables := allSSTables(directory)
+	for j := len(sstables) - 1; j >= 0; j-- {
+		it, err := sstable.NewIterator(directory, sstables[j][0], sstables[j][1])
+		if err != nil {
+			for _, child := range children {
+				child.Close()
+			}
+			return nil, err
+		}
+		children = append(children, it)
+	}
+
+	cf.iterators.Add(1)
+	it := newMergingIterator(children, e.ValueLog, cf.operators)
+	it.family = cf
+	return it, nil
+}
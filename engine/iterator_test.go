@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// kljucevi i vrednosti koje iterator vraca redom
+func collect(t *testing.T, it Iterator) []string {
+	t.Helper()
+	var keys []string
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, it.Key()+"="+string(it.Value()))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestIteratorMergesMemtablesAndTables(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	// dovoljno upisa da deo zapisa bude u sstabelama
+	fill(t, e, "k", 20)
+	for _, key := range []string{"k3", "k15"} {
+		err := e.Put(key, []byte("new"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := e.Delete("k7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it, err := e.NewIterator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	keys := collect(t, it)
+	if len(keys) != 19 {
+		t.Fatalf("iterator returned %d records, want 19: %v", len(keys), keys)
+	}
+	for i := 1; i < len(keys); i++ {
+		previous, _, _ := strings.Cut(keys[i-1], "=")
+		key, _, _ := strings.Cut(keys[i], "=")
+		if previous >= key {
+			t.Fatalf("keys out of order: %q before %q", keys[i-1], keys[i])
+		}
+	}
+	joined := strings.Join(keys, " ")
+	for _, want := range []string{"k3=new", "k15=new", "k4=x"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("iterator is missing %s: %v", want, keys)
+		}
+	}
+	if strings.Contains(joined, "k7=") {
+		t.Fatalf("iterator returned a deleted key: %v", keys)
+	}
+}
+
+func TestPrefixAndRangeIterators(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	fill(t, e, "a", 5)
+	fill(t, e, "b", 5)
+
+	it, err := e.NewPrefixIterator("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := collect(t, it)
+	it.Close()
+	if len(keys) != 5 || keys[0] != "b0=x" || keys[4] != "b4=x" {
+		t.Fatalf("prefix iterator returned %v", keys)
+	}
+
+	it, err = e.NewRangeIterator("a3", "b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys = collect(t, it)
+	it.Close()
+	want := "a3=x a4=x b0=x b1=x"
+	if strings.Join(keys, " ") != want {
+		t.Fatalf("range iterator returned %v, want %s", keys, want)
+	}
+}
+
+// otvoren iterator vidi stanje iz trenutka pravljenja, a kompakcija ne brise
+// tabele koje cita; pokrenuti i sa -race
+func TestIteratorDuringWrites(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	fill(t, e, "old", 20)
+
+	it, err := e.NewIterator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := os.ReadDir("data/sstable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			err := e.Put(fmt.Sprintf("new%d", i), []byte("x"), false)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	keys := collect(t, it)
+	wg.Wait()
+	for _, table := range tables {
+		if _, err := os.Stat("data/sstable/" + table.Name()); err != nil {
+			t.Fatalf("a table read by an open iterator was removed: %v", err)
+		}
+	}
+	if len(keys) != 20 {
+		t.Fatalf("iterator returned %d records, want the 20 written before it: %v", len(keys), keys)
+	}
+	it.SeekToFirst()
+	again := collect(t, it)
+	it.Close()
+	if strings.Join(again, " ") != strings.Join(keys, " ") {
+		t.Fatalf("second pass returned %v, want %v", again, keys)
+	}
+
+	// posle zatvaranja iteratora kompakcija ponovo radi
+	fill(t, e, "more", 10)
+	it, err = e.NewPrefixIterator("new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	if keys = collect(t, it); len(keys) != 100 {
+		t.Fatalf("iterator returned %d new records, want 100", len(keys))
+	}
+}
+
+func TestReverseIteration(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	fill(t, e, "k", 20)
+	err := e.Delete("k19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.Put("k5", []byte("new"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it, err := e.NewIterator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	var forward []string
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		forward = append(forward, it.Key()+"="+string(it.Value()))
+	}
+	var backward []string
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		backward = append([]string{it.Key() + "=" + string(it.Value())}, backward...)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(forward) != 19 || strings.Join(backward, " ") != strings.Join(forward, " ") {
+		t.Fatalf("backward pass %v differs from forward pass %v", backward, forward)
+	}
+
+	// k19 je obrisan, pa je poslednji kljuc pre k2 k18
+	it.SeekForPrev("k2")
+	if !it.Valid() || it.Key() != "k2" {
+		t.Fatalf("SeekForPrev(k2) is at %q, want k2", it.Key())
+	}
+	it.Prev()
+	if !it.Valid() || it.Key() != "k18" {
+		t.Fatalf("Prev from k2 is at %q, want k18", it.Key())
+	}
+	// promena smera usred prolaza
+	it.Next()
+	if !it.Valid() || it.Key() != "k2" {
+		t.Fatalf("Next after Prev is at %q, want k2", it.Key())
+	}
+}
+
+func TestDescendingPrefixScan(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	fill(t, e, "a", 3)
+	fill(t, e, "b", 12)
+	fill(t, e, "c", 3)
+
+	page, _, err := e.PrefixScan("b", "", 100, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 12 || page[0].Key != "b9" || page[11].Key != "b0" {
+		t.Fatalf("descending scan returned %d records: %v", len(page), page)
+	}
+	for i := 1; i < len(page); i++ {
+		if page[i-1].Key <= page[i].Key {
+			t.Fatalf("keys out of order: %q before %q", page[i-1].Key, page[i].Key)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"errors"
+	mergeoperator "main/mergeOperator"
+	"main/record"
+	"main/sstable"
+)
+
+// MergeOperator spaja operande sa vrednoscu kljuca.
+type MergeOperator = mergeoperator.Operator
+
+// RegisterMergeOperator postavlja operator za kljuceve sa datim prefiksom, vazi
+// najduzi prefiks koji odgovara. Operatori se registruju pre Engine-a, da bi
+// se operandi procitani iz WAL-a mogli spojiti. Vaze u svim korisnickim
+// familijama, bez registrovanog operatora Merge vraca ErrNoMergeOperator.
+func (e *Engine) RegisterMergeOperator(prefix string, operator MergeOperator) {
+	e.operators().Register(prefix, operator)
+}
+
+// operatori koje je registrovao korisnik
+func (e *Engine) operators() *mergeoperator.Registry {
+	if e.mergeOperators == nil {
+		e.mergeOperators = mergeoperator.NewRegistry()
+	}
+	return e.mergeOperators
+}
+
+// operatori struktura, postoje samo u sistemskoj familiji
+func systemOperators() *mergeoperator.Registry {
+	operators := mergeoperator.NewRegistry()
+	operators.Register("bf_", mergeoperator.BloomFilter{})
+	operators.Register("cms_", mergeoperator.CountMinSketch{})
+	operators.Register("hll_", mergeoperator.HyperLogLog{})
+	return operators
+}
+
+// Merge dodaje operand kljucu. Registrovani operator spaja operande sa
+// vrednoscu pri citanju i kompakciji. Operand koji operator odbija se vraca sa
+// ErrInvalidOperand, a ako se ne slaze sa vrednoscu kljuca, greska se vraca
+// pri citanju.
+func (e *Engine) Merge(key string, operand []byte) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.merge(e.defaultFamily, key, operand)
+}
+
+// dodaje element strukturi u sistemskoj familiji
+func (e *Engine) addToStructure(name, prefix, element string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	key, err := e.findStructure(name, prefix)
+	if err != nil {
+		return err
+	}
+	return e.merge(e.systemFamily, key, []byte(element))
+}
+
+// merge ocekuje da je lock zakljucan
+func (e *Engine) merge(cf *ColumnFamily, key string, operand []byte) error {
+	// vrednost kljuca se ne cita, operand se proverava samo operatorom
+	err := cf.operators.ValidateOperand(key, operand)
+	if err != nil {
+		return err
+	}
+
+	mergeRecord := record.NewMergeRecord(key, operand)
+	walSize, err := e.log(cf, mergeRecord)
+	if err != nil {
+		return err
+	}
+	mergeRecord.Family = 0
+	// vrednost u kesu ne sadrzi operand
+	cf.cache.Remove(key)
+	return e.addRecordToMemtable(cf, *mergeRecord, walSize)
+}
+
+// dodaje operand u memtabelu, spaja se sa verzijom kljuca koja je vec u njoj,
+// pa svaka memtabela ima jedan zapis po kljucu
+func (e *Engine) mergeIntoMemtable(cf *ColumnFamily, operand record.Record) error {
+	combine := func(existing *record.Record) (*record.Record, error) {
+		if existing == nil {
+			return &operand, nil
+		}
+		if existing.Merge {
+			combined := mergeoperator.Combine([]record.Record{operand, *existing})
+			return &combined, nil
+		}
+		return cf.operators.Fold(operand.Key, existing, []record.Record{operand}, e.ValueLog)
+	}
+
+	successful, err := cf.memtables[cf.active].Merge(operand, combine)
+	if errors.Is(err, mergeoperator.ErrUnfoldable) {
+		// vrednost i operand ostaju posebne verzije, vrednost ide u sstabelu,
+		// a greska se vraca tek pri citanju
+		err = e.flushMemtable(cf, cf.active)
+		if !cf.memtables[cf.active].IsEmpty() {
+			return err
+		}
+		_, mergeErr := cf.memtables[cf.active].Merge(operand, combine)
+		if mergeErr != nil {
+			return mergeErr
+		}
+		return err
+	}
+	if err != nil || successful {
+		return err
+	}
+	err = e.rotateMemtable(cf)
+	_, mergeErr := cf.memtables[cf.active].Merge(operand, combine)
+	if mergeErr != nil {
+		return mergeErr
+	}
+	return err
+}
+
+// verzije kljuca od najnovije do prve koja nije operand, brisanje opsega se
+// vraca kao tombstone
+type versions struct {
+	merges []record.Record // od najnovije
+	base   *record.Record  // nil ako takve verzije nema
+	// staje na najnovijoj verziji i kada je ona operand
+	newestOnly bool
+}
+
+// dodaje sledecu stariju verziju, vraca da li su potrebne i starije
+func (v *versions) add(version *record.Record) bool {
+	if version.Merge {
+		v.merges = append(v.merges, *version)
+		return !v.newestOnly
+	}
+	v.base = version
+	return false
+}
+
+func (v *versions) found() bool {
+	return v.base != nil || len(v.merges) > 0
+}
+
+// skuplja verzije kljuca iz memtabela, kesa i sstabela. Kes se koristi samo
+// ako nijedna memtabela nema kljuc, cacheable kaze da li su sve verzije
+// procitane iz sstabela.
+func (e *Engine) findVersions(cf *ColumnFamily, key string, v *versions, useCache bool) (cacheable bool, err error) {
+	// prolazak kroz memtabele od aktivne unazad
+	i := cf.active
+	// ako je aktivna memtabela prazna, krece se od prethodne
+	if cf.memtables[i].IsEmpty() {
+		i = cf.previousMemtable(i)
+	}
+	for j := 0; j < cf.config.NumberOfMemtables; j++ {
+		mt := cf.memtables[i]
+		if mt.IsEmpty() {
+			break
+		}
+		if rec := mt.Search(key); rec != nil && !v.add(rec) {
+			return false, nil
+		}
+		if v.newestOnly && v.found() {
+			return false, nil
+		}
+		// brisanja opsega memtabele pokrivaju samo starije izvore
+		if tombstone := record.RangeTombstoneFor(mt.RangeTombstones(), key); tombstone != nil {
+			v.add(tombstone)
+			return false, nil
+		}
+		i = cf.previousMemtable(i)
+	}
+
+	if !v.found() && useCache {
+		cached, found := cf.cache.Get(key)
+		if found {
+			v.base = cached
+			return false, nil
+		}
+	}
+
+	cacheable = !v.found()
+	err = sstable.SearchVersions(cf.config.SSTableDirectory(), key, v.add)
+	return cacheable, err
+}
+
+// da li struktura postoji u sistemskoj familiji, operandi se upisuju samo u
+// postojece strukture pa je dovoljna najnovija verzija
+func (e *Engine) structureExists(key string) error {
+	v := versions{newestOnly: true}
+	_, err := e.findVersions(e.systemFamily, key, &v, true)
+	if err != nil {
+		return err
+	}
+	if len(v.merges) == 0 && (v.base == nil || v.base.Tombstone) {
+		return ErrNotFound
+	}
+	return nil
+}
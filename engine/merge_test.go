@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"main/config"
+	mergeoperator "main/mergeOperator"
+	"main/record"
+	"main/sstable"
+	"os"
+	"strings"
+	"testing"
+)
+
+var counter = map[string]MergeOperator{"c_": mergeoperator.Counter{}}
+
+// da li je neka tabela prepisana kompakcijom na nizi nivo
+func compacted(t *testing.T) bool {
+	t.Helper()
+	files, err := os.ReadDir("data/sstable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if strings.Contains(file.Name(), "sstable_data") && !strings.HasPrefix(file.Name(), "lvl_1_") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMergeAcrossCompaction(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, counter)
+	err := e.Put("c_total", []byte("100"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// operandi zavrsavaju u razlicitim tabelama, koje kompakcija spaja
+	for i := 0; i < 40; i++ {
+		err = e.Merge("c_total", []byte("1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = e.Merge("c_fresh", []byte("2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fill(t, e, "fill", 10)
+	}
+	if !compacted(t) {
+		t.Fatal("no table was compacted")
+	}
+	expectValue(t, e, "c_total", "140")
+	expectValue(t, e, "c_fresh", "80")
+
+	e = openEngine(t, counter)
+	expectValue(t, e, "c_total", "140")
+	expectValue(t, e, "c_fresh", "80")
+}
+
+func TestMergeRejectsInvalidOperands(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, counter)
+	err := e.Put("c_total", []byte("5"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = e.Merge("c_total", []byte("abc"))
+	if !errors.Is(err, ErrInvalidOperand) {
+		t.Fatalf("Merge of an invalid operand: %v, want ErrInvalidOperand", err)
+	}
+	// operatori struktura postoje samo u sistemskoj familiji
+	err = e.Merge("bf_x", []byte("element"))
+	if !errors.Is(err, ErrNoMergeOperator) {
+		t.Fatalf("Merge into bf_x: %v, want ErrNoMergeOperator", err)
+	}
+	err = e.Merge("key", []byte("1"))
+	if !errors.Is(err, ErrNoMergeOperator) {
+		t.Fatalf("Merge without an operator: %v, want ErrNoMergeOperator", err)
+	}
+	expectValue(t, e, "c_total", "5")
+
+	e = openEngine(t, counter)
+	expectValue(t, e, "c_total", "5")
+}
+
+// operand koji se ne slaze sa vrednoscu se prihvata, a greska se vraca pri
+// citanju. Vrednost se ne gubi ni u memtabeli ni pri kompakciji.
+func TestMergeIncompatibleWithValue(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, counter)
+	err := e.Put("c_memtable", []byte("abc"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.Merge("c_memtable", []byte("1"))
+	if err != nil {
+		t.Fatalf("Merge onto an incompatible value in the memtable: %v", err)
+	}
+	err = e.Put("c_table", []byte("abc"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fill(t, e, "before", 10)
+	err = e.Merge("c_table", []byte("2"))
+	if err != nil {
+		t.Fatalf("Merge onto an incompatible value in a table: %v", err)
+	}
+
+	// kompakcija ne moze da spoji operande i ostavlja stare tabele
+	var compactionErr error
+	for i := 0; i < 40; i++ {
+		if err := e.Put(fmt.Sprint("after", i), []byte("x"), false); err != nil {
+			compactionErr = err
+		}
+	}
+	if !errors.Is(compactionErr, ErrInvalidOperand) {
+		t.Fatalf("compaction: %v, want ErrInvalidOperand", compactionErr)
+	}
+	e = openEngine(t, counter)
+	for _, key := range []string{"c_memtable", "c_table"} {
+		_, err = e.Get(key)
+		if !errors.Is(err, ErrInvalidOperand) {
+			t.Fatalf("Get(%q): %v, want ErrInvalidOperand", key, err)
+		}
+		var kept []string
+		err = sstable.SearchVersions(config.SSTABLE_DIRECTORY, key, func(version *record.Record) bool {
+			if !version.Merge {
+				kept = append(kept, string(version.Value))
+			}
+			return true
+		})
+		if err != nil || len(kept) == 0 || kept[0] != "abc" {
+			t.Fatalf("value of %q below its operands = %v, %v, want abc", key, kept, err)
+		}
+	}
+
+	// novi upis zamenjuje operande i kompakcija se nastavlja kada je u
+	// sstabeli, do tada upisi i dalje vracaju gresku kompakcije
+	keys := []string{"c_memtable", "c_table"}
+	for i := 0; i < 40; i++ {
+		keys = append(keys, fmt.Sprint("fixed", i))
+	}
+	for _, key := range keys {
+		err = e.Put(key, []byte("7"), false)
+	}
+	if err != nil {
+		t.Fatalf("compaction after the keys were overwritten: %v", err)
+	}
+	if !compacted(t) {
+		t.Fatal("no table was compacted after the keys were overwritten")
+	}
+	expectValue(t, e, "c_memtable", "7")
+	expectValue(t, e, "c_table", "7")
+}
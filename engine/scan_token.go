@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"main/record"
+)
+
+const scanTokenVersion = 1
+
+var ErrInvalidToken = errors.New("invalid continuation token")
+
+var ErrInvalidPageSize = errors.New("page size must be positive")
+
+// scanToken je dekodiran token za nastavak koji vracaju skeniranja. bounds je
+// kontrolna suma argumenata skeniranja, pa token nastavlja samo svoje skeniranje.
+type scanToken struct {
+	descending bool
+	bounds     uint32
+	lastKey    string
+}
+
+func scanBounds(kind string, bounds ...string) uint32 {
+	data := []byte(kind)
+	for _, bound := range bounds {
+		data = binary.AppendUvarint(data, uint64(len(bound)))
+		data = append(data, bound...)
+	}
+	return crc32.ChecksumIEEE(data)
+}
+
+// verzija | smer | kontrolna suma granica | poslednji kljuc
+func (t scanToken) encode() string {
+	data := make([]byte, 6, 6+len(t.lastKey))
+	data[0] = scanTokenVersion
+	if t.descending {
+		data[1] = 1
+	}
+	binary.BigEndian.PutUint32(data[2:6], t.bounds)
+	data = append(data, t.lastKey...)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeScanToken(token string) (scanToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < 6 || data[0] != scanTokenVersion || data[1] > 1 {
+		return scanToken{}, ErrInvalidToken
+	}
+	return scanToken{
+		descending: data[1] == 1,
+		bounds:     binary.BigEndian.Uint32(data[2:6]),
+		lastKey:    string(data[6:]),
+	}, nil
+}
+
+// vraca sledecu stranu skeniranja i token za stranu posle nje, token je
+// prazan kada vise nema zapisa
+func scanPage(it *boundedIterator, bounds uint32, token string, pageSize int, descending bool) ([]record.Record, string, error) {
+	if pageSize <= 0 {
+		return nil, "", ErrInvalidPageSize
+	}
+	advance := it.Next
+	if descending {
+		advance = it.Prev
+	}
+
+	if token == "" {
+		if descending {
+			it.SeekToLast()
+		}
+	} else {
+		t, err := decodeScanToken(token)
+		if err != nil {
+			return nil, "", err
+		}
+		if t.bounds != bounds || t.descending != descending {
+			return nil, "", ErrInvalidToken
+		}
+
+		// nastavlja odmah posle poslednjeg vracenog kljuca
+		if descending {
+			it.SeekForPrev(t.lastKey)
+		} else {
+			it.Seek(t.lastKey)
+		}
+		if it.Valid() && it.Key() == t.lastKey {
+			advance()
+		}
+	}
+
+	var page []record.Record
+	for len(page) < pageSize && it.Valid() {
+		page = append(page, *it.Record())
+		advance()
+	}
+	if err := it.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if !it.Valid() || len(page) == 0 {
+		return page, "", nil
+	}
+	next := scanToken{descending: descending, bounds: bounds, lastKey: page[len(page)-1].Key}
+	return page, next.encode(), nil
+}
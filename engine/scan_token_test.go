@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"errors"
+	"main/record"
+	"testing"
+)
+
+func pageKeys(page []record.Record) string {
+	var s string
+	for _, r := range page {
+		s += r.Key + " "
+	}
+	return s
+}
+
+// token pamti poslednji vraceni kljuc, pa se nastavak ne menja kada se
+// memtabela u medjuvremenu upise u sstabelu
+func TestScanTokenResumesAcrossFlush(t *testing.T) {
+	for _, descending := range []bool{false, true} {
+		chdirTemp(t)
+		e := openEngine(t, nil)
+		fill(t, e, "p", 6)
+
+		page, token, err := e.PrefixScan("p", "", 2, descending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "p0 p1 "
+		if descending {
+			want = "p5 p4 "
+		}
+		if pageKeys(page) != want || token == "" {
+			t.Fatalf("first page = %q, token %q, want %q", pageKeys(page), token, want)
+		}
+
+		// flush i kompakcija, a vraceni kljuc se brise
+		fill(t, e, "q", 20)
+		err = e.Delete(page[1].Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var rest string
+		for token != "" {
+			page, token, err = e.PrefixScan("p", token, 2, descending)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rest += pageKeys(page)
+		}
+		want = "p2 p3 p4 p5 "
+		if descending {
+			want = "p3 p2 p1 p0 "
+		}
+		if rest != want {
+			t.Fatalf("resumed scan returned %q, want %q", rest, want)
+		}
+	}
+}
+
+func TestScanRejectsInvalidArguments(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	fill(t, e, "p", 4)
+	_, token, err := e.PrefixScan("p", "", 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		scan func() error
+		want error
+	}{
+		{"zero page size", func() error {
+			_, _, err := e.PrefixScan("p", "", 0, false)
+			return err
+		}, ErrInvalidPageSize},
+		{"malformed token", func() error {
+			_, _, err := e.PrefixScan("p", "not a token", 2, false)
+			return err
+		}, ErrInvalidToken},
+		{"token of another prefix", func() error {
+			_, _, err := e.PrefixScan("q", token, 2, false)
+			return err
+		}, ErrInvalidToken},
+		{"token of another direction", func() error {
+			_, _, err := e.PrefixScan("p", token, 2, true)
+			return err
+		}, ErrInvalidToken},
+		{"token of a range scan", func() error {
+			_, _, err := e.RangeScan("p", "q", token, 2, false)
+			return err
+		}, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		if err := tt.scan(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"main/config"
+	"main/envelope"
+	"math"
+	"testing"
+)
+
+func TestSketchAPI(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	if err := e.BloomFilterCreateNewInstance("filter", 100, 0.01); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.HLLCreateNewInstance("visitors", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.CMSCreateNewInstance("counts", 0.01, 0.01); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		element := fmt.Sprint("element", i)
+		if err := e.BloomFilterAddElement("filter", element); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.HLLAddElement("visitors", element); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.CMSAddElement("counts", "often"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.CMSAddElement("counts", "once"); err != nil {
+		t.Fatal(err)
+	}
+	first, err := e.CalculateFingerprintSimHash("first", "the quick brown fox jumps over the lazy dog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 16 {
+		t.Fatalf("fingerprint %q is not 64 bits in hex", first)
+	}
+	_, err = e.CalculateFingerprintSimHash("second", "the quick brown fox jumps over the lazy cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// strukture se citaju iz sstabela i posle ponovnog pokretanja
+	fill(t, e, "fill", 20)
+	e = openEngine(t, nil)
+
+	for i := 0; i < 50; i++ {
+		found, err := e.BloomContains("filter", fmt.Sprint("element", i))
+		if err != nil || !found {
+			t.Fatalf("BloomContains of an added element = %v, %v", found, err)
+		}
+	}
+	estimate, err := e.HLLEstimate("visitors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(estimate-50) > 5 {
+		t.Fatalf("HLLEstimate = %v, want about 50", estimate)
+	}
+	for element, want := range map[string]uint32{"often": 50, "once": 1} {
+		count, err := e.CMSCount("counts", element)
+		if err != nil || count < want {
+			t.Fatalf("CMSCount(%q) = %d, %v, want at least %d", element, count, err, want)
+		}
+	}
+	distance, err := e.SimHashDistance("first", "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if distance >= 32 {
+		t.Fatalf("SimHashDistance of similar texts = %d", distance)
+	}
+	if distance, err = e.SimHashDistance("first", "first"); err != nil || distance != 0 {
+		t.Fatalf("SimHashDistance of a fingerprint to itself = %d, %v, want 0", distance, err)
+	}
+}
+
+func TestSketchNamesAndTypes(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	if err := e.HLLCreateNewInstance("name", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// ime pripada strukturi drugog tipa
+	if _, err := e.BloomContains("name", "a"); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("BloomContains of a HyperLogLog: %v, want ErrWrongType", err)
+	}
+	if err := e.CMSAddElement("name", "a"); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("CMSAddElement to a HyperLogLog: %v, want ErrWrongType", err)
+	}
+	if err := e.BloomFilterDeleteInstance("name"); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("BloomFilterDeleteInstance of a HyperLogLog: %v, want ErrWrongType", err)
+	}
+
+	if _, err := e.CMSCount("missing", "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("CMSCount of a missing sketch: %v, want ErrNotFound", err)
+	}
+	if err := e.BloomFilterAddElement("missing", "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("BloomFilterAddElement to a missing filter: %v, want ErrNotFound", err)
+	}
+	if _, err := e.SimHashDistance("missing", "other"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("SimHashDistance of missing fingerprints: %v, want ErrNotFound", err)
+	}
+
+	if err := e.HLLDeleteInstance("name"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.HLLEstimate("name"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("HLLEstimate of a deleted HyperLogLog: %v, want ErrNotFound", err)
+	}
+	// posle brisanja ime je slobodno za drugi tip
+	if err := e.CMSCreateNewInstance("name", 0.1, 0.1); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.CMSAddElement("name", "a"); err != nil {
+		t.Fatalf("CMSAddElement after the name was freed: %v", err)
+	}
+}
+
+func TestCMSRejectsInvalidParameters(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	for _, tt := range []struct{ epsilon, delta float64 }{
+		{0, 0.1}, {1, 0.1}, {-0.1, 0.1}, {1.5, 0.1},
+		{0.1, 0}, {0.1, 1}, {0.1, -0.1}, {0.1, 2},
+		{math.NaN(), 0.1}, {0.1, math.NaN()},
+	} {
+		err := e.CMSCreateNewInstance("sketch", tt.epsilon, tt.delta)
+		if !errors.Is(err, ErrInvalidParameters) {
+			t.Fatalf("CMSCreateNewInstance(%v, %v): %v, want ErrInvalidParameters", tt.epsilon, tt.delta, err)
+		}
+	}
+	if _, err := e.CMSCount("sketch", "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("CMSCount after rejected parameters: %v, want ErrNotFound", err)
+	}
+}
+
+func TestBloomRejectsInvalidParameters(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	for _, tt := range []struct {
+		expectedElements  int
+		falsePositiveRate float64
+	}{
+		{0, 0.01}, {-1, 0.01}, {100, 0}, {100, 1}, {100, -0.5}, {100, math.NaN()},
+	} {
+		err := e.BloomFilterCreateNewInstance("filter", tt.expectedElements, tt.falsePositiveRate)
+		if !errors.Is(err, ErrInvalidParameters) {
+			t.Fatalf("BloomFilterCreateNewInstance(%d, %v): %v, want ErrInvalidParameters", tt.expectedElements, tt.falsePositiveRate, err)
+		}
+	}
+}
+
+// sacuvana struktura nepoznate verzije, ostecena ili drugog tipa se ne cita
+func TestStoredStructureIsChecked(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	if err := e.CMSCreateNewInstance("valid", 0.1, 0.1); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := e.Admin().Get("cms_valid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	damaged := bytes.Clone(stored.Value)
+	damaged[len(damaged)/2] ^= 0xFF
+
+	values := map[string][]byte{
+		"bf_version":  envelope.Wrap(envelope.Bloom, 99, []byte("payload")),
+		"hll_version": envelope.Wrap(envelope.HLL, 99, []byte("payload")),
+		"cms_version": envelope.Wrap(envelope.CMS, 99, []byte("payload")),
+		"sh_version":  envelope.Wrap(envelope.SimHash, 99, []byte("payload")),
+		"cms_damaged": damaged,
+		"bf_type":     envelope.Wrap(envelope.HLL, 1, []byte("payload")),
+	}
+	for key, value := range values {
+		if err := e.systemFamily.Put(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = e.BloomContains("version", "a"); !errors.Is(err, ErrMalformedStructure) {
+		t.Fatalf("BloomContains of an unsupported version: %v, want ErrMalformedStructure", err)
+	}
+	if _, err = e.HLLEstimate("version"); !errors.Is(err, ErrMalformedStructure) {
+		t.Fatalf("HLLEstimate of an unsupported version: %v, want ErrMalformedStructure", err)
+	}
+	if _, err = e.CMSCount("version", "a"); !errors.Is(err, ErrMalformedStructure) {
+		t.Fatalf("CMSCount of an unsupported version: %v, want ErrMalformedStructure", err)
+	}
+	if _, err = e.SimHashDistance("version", "version"); !errors.Is(err, ErrMalformedStructure) {
+		t.Fatalf("SimHashDistance of an unsupported version: %v, want ErrMalformedStructure", err)
+	}
+	if _, err = e.CMSCount("damaged", "a"); !errors.Is(err, ErrMalformedStructure) {
+		t.Fatalf("CMSCount of a damaged sketch: %v, want ErrMalformedStructure", err)
+	}
+	if _, err = e.BloomContains("type", "a"); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("BloomContains of a HyperLogLog under a filter key: %v, want ErrWrongType", err)
+	}
+	if _, err = e.CMSCount("valid", "a"); err != nil {
+		t.Fatalf("CMSCount of a valid sketch: %v", err)
+	}
+}
+
+func TestBloomUnionAndIntersect(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	for _, name := range []string{"left", "right", "both", "small"} {
+		expected := 1000
+		if name == "small" {
+			expected = 10
+		}
+		if err := e.BloomFilterCreateNewInstance(name, expected, 0.001); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		if err := e.BloomFilterAddElement("left", fmt.Sprint("left", i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.BloomFilterAddElement("right", fmt.Sprint("right", i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.BloomFilterAddElement("both", fmt.Sprint("left", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.BloomFilterAddElement("right", "left0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.BloomIntersect("both", "right"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.BloomUnion("left", "right"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		for _, element := range []string{fmt.Sprint("left", i), fmt.Sprint("right", i)} {
+			found, err := e.BloomContains("left", element)
+			if err != nil || !found {
+				t.Fatalf("union does not contain %q: %v, %v", element, found, err)
+			}
+		}
+	}
+	found, err := e.BloomContains("both", "left0")
+	if err != nil || !found {
+		t.Fatalf("intersection does not contain an element of both filters: %v, %v", found, err)
+	}
+	missing := 0
+	for i := 1; i < 20; i++ {
+		if found, _ := e.BloomContains("both", fmt.Sprint("left", i)); !found {
+			missing++
+		}
+	}
+	if missing == 0 {
+		t.Fatal("intersection contains every element of only one filter")
+	}
+	// izvorni filter se ne menja
+	if found, _ := e.BloomContains("right", "left5"); found {
+		t.Fatal("union changed the source filter")
+	}
+
+	if err := e.BloomUnion("left", "small"); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("union of filters of different sizes: %v, want ErrIncompatible", err)
+	}
+	if err := e.BloomIntersect("left", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("intersection with a missing filter: %v, want ErrNotFound", err)
+	}
+	if err := e.HLLCreateNewInstance("counter", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.BloomUnion("left", "counter"); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("union with a HyperLogLog: %v, want ErrWrongType", err)
+	}
+}
+
+func TestHLLAndCMSMerge(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	for _, name := range []string{"first", "second"} {
+		if err := e.HLLCreateNewInstance(name, 12); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.HLLCreateNewInstance("coarse", 4); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"counts", "more", "other"} {
+		epsilon := 0.01
+		if name == "other" {
+			epsilon = 0.1
+		}
+		if err := e.CMSCreateNewInstance(name, epsilon, 0.01); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// prvi i drugi imaju po 100 elemenata, od kojih je 50 zajednickih
+	for i := 0; i < 100; i++ {
+		if err := e.HLLAddElement("first", fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.HLLAddElement("second", fmt.Sprint(i+50)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if err := e.CMSAddElement("counts", "a"); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.CMSAddElement("more", "a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := e.HLLMerge("first", "second"); err != nil {
+		t.Fatal(err)
+	}
+	estimate, err := e.HLLEstimate("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(estimate-150) > 15 {
+		t.Fatalf("estimate of the union = %v, want about 150", estimate)
+	}
+	if err := e.HLLMerge("first", "coarse"); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("merge of different precisions: %v, want ErrIncompatible", err)
+	}
+
+	if err := e.CMSMerge("counts", "more"); err != nil {
+		t.Fatal(err)
+	}
+	count, err := e.CMSCount("counts", "a")
+	if err != nil || count < 20 {
+		t.Fatalf("CMSCount after merge = %d, %v, want at least 20", count, err)
+	}
+	if count, _ = e.CMSCount("more", "a"); count < 10 || count >= 20 {
+		t.Fatalf("CMSCount of the source after merge = %d, want it unchanged", count)
+	}
+	if err := e.CMSMerge("counts", "other"); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("merge of different dimensions: %v, want ErrIncompatible", err)
+	}
+}
+
+func TestHLLRejectsInvalidPrecision(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	for _, p := range []int{-1, 0, config.HLL_MIN_PRECISION - 1, config.HLL_MAX_PRECISION + 1} {
+		err := e.HLLCreateNewInstance("visitors", p)
+		if !errors.Is(err, ErrInvalidParameters) {
+			t.Fatalf("HLLCreateNewInstance(%d): %v, want ErrInvalidParameters", p, err)
+		}
+	}
+	if _, err := e.HLLEstimate("visitors"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("HLLEstimate after rejected precisions: %v, want ErrNotFound", err)
+	}
+	for _, p := range []int{config.HLL_MIN_PRECISION, config.HLL_MAX_PRECISION} {
+		if err := e.HLLCreateNewInstance(fmt.Sprint("p", p), p); err != nil {
+			t.Fatalf("HLLCreateNewInstance(%d): %v", p, err)
+		}
+	}
+}
+
+// nove strukture dobijaju seed iz config-a, pa se spajaju samo strukture
+// napravljene sa istim HashSeed
+func TestStructuresUseConfiguredSeed(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	if err := e.BloomFilterCreateNewInstance("before", 100, 0.01); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.CMSCreateNewInstance("before", 0.01, 0.01); err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfig(t, func(cfg *config.Config) {
+		cfg.HashSeed = 99
+	})
+	e = openEngine(t, nil)
+	for _, name := range []string{"after", "again"} {
+		if err := e.BloomFilterCreateNewInstance(name, 100, 0.01); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.CMSCreateNewInstance(name, 0.01, 0.01); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.BloomUnion("after", "again"); err != nil {
+		t.Fatalf("union of filters with the same seed: %v", err)
+	}
+	if err := e.CMSMerge("after", "again"); err != nil {
+		t.Fatalf("merge of sketches with the same seed: %v", err)
+	}
+	if err := e.BloomUnion("after", "before"); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("union of filters with different seeds: %v, want ErrIncompatible", err)
+	}
+	if err := e.CMSMerge("after", "before"); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("merge of sketches with different seeds: %v, want ErrIncompatible", err)
+	}
+}
@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"errors"
+	"main/record"
+)
+
+// Probabilisticke strukture i stanje token bucket-a se cuvaju u internoj
+// familiji kolona do koje korisnicki API ne dopire, a kljucevi im pocinju
+// jednim od ovih prefiksa. Admin API je cita radi pregleda.
+const systemColumnFamily = "_system"
+
+var systemPrefixes = []string{"bf_", "cms_", "hll_", "sh_", "tb_"}
+
+// upisuje se kada su kljucevi sa prefiksima iznad premesteni iz
+// podrazumevane familije, gde su ih cuvale starije verzije
+const systemLayoutKey = "meta_layout"
+
+// pravi sistemsku familiju ako je jos nema u manifestu
+func (e *Engine) loadSystemFamily() error {
+	cf, found := e.families[systemColumnFamily]
+	if !found {
+		var err error
+		cf, err = e.createColumnFamily(systemColumnFamily, ColumnFamilyOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	cf.operators = systemOperators()
+	e.systemFamily = cf
+	return nil
+}
+
+// premesta kljuceve struktura iz podrazumevane u sistemsku familiju jednom
+// grupom upisa, zajedno sa oznakom rasporeda
+func (e *Engine) moveSystemKeys() error {
+	_, err := e.systemFamily.Get(systemLayoutKey)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	t := e.Begin()
+	for _, prefix := range systemPrefixes {
+		it, err := e.newPrefixIterator(e.defaultFamily, prefix)
+		if err != nil {
+			return err
+		}
+		for ; it.Valid(); it.Next() {
+			t.PutCF(e.systemFamily, it.Key(), it.Value())
+			t.DeleteCF(e.defaultFamily, it.Key())
+		}
+		err = it.Err()
+		it.Close()
+		if err != nil {
+			return err
+		}
+	}
+	t.PutCF(e.systemFamily, systemLayoutKey, []byte("1"))
+	return t.Commit()
+}
+
+// prefiksi struktura kojima se pristupa po imenu preko sketch API-ja
+var structurePrefixes = []string{"bf_", "cms_", "hll_", "sh_"}
+
+// kljuc strukture sa datim imenom i tipom odredjenim prefiksom, ocekuje da je
+// lock zakljucan
+func (e *Engine) findStructure(name, prefix string) (string, error) {
+	err := e.structureExists(prefix + name)
+	if !errors.Is(err, ErrNotFound) {
+		return prefix + name, err
+	}
+	for _, other := range structurePrefixes {
+		if other != prefix && e.structureExists(other+name) == nil {
+			return "", ErrWrongType
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (e *Engine) getStructure(name, prefix string) (*record.Record, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	key, err := e.findStructure(name, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return e.get(e.systemFamily, key)
+}
+
+// zamenjuje dest rezultatom spajanja izvora u nju, sve se cita i upisuje
+// pod jednim lock-om
+func (e *Engine) mergeStructures(dest string, sources []string, prefix string, merge func(dest []byte, sources [][]byte) ([]byte, error)) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var values [][]byte
+	for _, name := range append([]string{dest}, sources...) {
+		key, err := e.findStructure(name, prefix)
+		if err != nil {
+			return err
+		}
+		structure, err := e.get(e.systemFamily, key)
+		if err != nil {
+			return err
+		}
+		values = append(values, structure.Value)
+	}
+	merged, err := merge(values[0], values[1:])
+	if err != nil {
+		return err
+	}
+	return e.put(e.systemFamily, prefix+dest, merged, false)
+}
+
+func (e *Engine) putStructure(key string, value []byte) error {
+	return e.systemFamily.Put(key, value)
+}
+
+func (e *Engine) deleteStructure(name, prefix string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	key, err := e.findStructure(name, prefix)
+	if err != nil {
+		return err
+	}
+	return e.delete(e.systemFamily, key)
+}
+
+// SaveTokenBucket cuva stanje ogranicivaca zahteva u sistemskoj familiji.
+func (e *Engine) SaveTokenBucket() error {
+	return e.putStructure("tb_", e.Tbucket.ToBytes())
+}
+
+// Admin daje pristup samo za citanje internoj sistemskoj familiji, u kojoj su
+// probabilisticke strukture i stanje token bucket-a.
+type Admin struct {
+	engine *Engine
+}
+
+func (e *Engine) Admin() *Admin {
+	return &Admin{engine: e}
+}
+
+// Get vraca najnoviju zivu verziju sistemskog kljuca.
+func (a *Admin) Get(key string) (*record.Record, error) {
+	return a.engine.systemFamily.Get(key)
+}
+
+// PrefixScan vraca stranu sistemskih kljuceva sa datim prefiksom, kao Engine.PrefixScan.
+func (a *Admin) PrefixScan(prefix, token string, pageSize int, descending bool) ([]record.Record, string, error) {
+	e := a.engine
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	it, err := e.newPrefixIterator(e.systemFamily, prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	defer it.Close()
+
+	return scanPage(it, scanBounds("prefix", prefix), token, pageSize, descending)
+}
+
+func (a *Admin) NewPrefixIterator(prefix string) (Iterator, error) {
+	return a.engine.systemFamily.NewPrefixIterator(prefix)
+}
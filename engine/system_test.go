@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"errors"
+	"main/config"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStructuresAreInTheSystemFamily(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	err := e.BloomFilterCreateNewInstance("seen", 100, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.BloomFilterAddElement("seen", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.SaveTokenBucket()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// korisnicki API ne vidi sistemsku familiju ni njene kljuceve
+	_, err = e.Get("bf_seen")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a structure key: %v, want ErrNotFound", err)
+	}
+	if names := e.ColumnFamilies(); !reflect.DeepEqual(names, []string{DefaultColumnFamily}) {
+		t.Fatalf("ColumnFamilies() = %v, want only the default family", names)
+	}
+	if _, err = e.ColumnFamily(systemColumnFamily); !errors.Is(err, ErrNoColumnFamily) {
+		t.Fatalf("ColumnFamily(%q): %v, want ErrNoColumnFamily", systemColumnFamily, err)
+	}
+	if _, err = e.CreateColumnFamily(systemColumnFamily, ColumnFamilyOptions{}); !errors.Is(err, ErrInvalidColumnFamily) {
+		t.Fatalf("CreateColumnFamily(%q): %v, want ErrInvalidColumnFamily", systemColumnFamily, err)
+	}
+	if err = e.DropColumnFamily(systemColumnFamily); !errors.Is(err, ErrNoColumnFamily) {
+		t.Fatalf("DropColumnFamily(%q): %v, want ErrNoColumnFamily", systemColumnFamily, err)
+	}
+
+	admin := e.Admin()
+	if _, err = admin.Get("bf_seen"); err != nil {
+		t.Fatalf("Admin Get of the filter: %v", err)
+	}
+	page, _, err := admin.PrefixScan("", "", 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, r := range page {
+		keys = append(keys, r.Key)
+	}
+	want := []string{"bf_seen", systemLayoutKey, "tb_"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Admin PrefixScan returned %v, want %v", keys, want)
+	}
+	it, err := admin.NewPrefixIterator("tb_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	if !it.Valid() || it.Key() != "tb_" {
+		t.Fatal("Admin iterator does not see the token bucket")
+	}
+}
+
+// starije verzije su cuvale strukture u podrazumevanoj familiji, pri
+// otvaranju se premestaju u sistemsku
+func TestLegacyStructuresAreMoved(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	err := e.Put("hll_legacy", []byte("value"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.Put("user", []byte("value"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.systemFamily.Delete(systemLayoutKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e = openEngine(t, nil)
+	_, err = e.Get("hll_legacy")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a moved key: %v, want ErrNotFound", err)
+	}
+	r, err := e.Admin().Get("hll_legacy")
+	if err != nil || string(r.Value) != "value" {
+		t.Fatalf("Admin Get of a moved key = %v, %v", r, err)
+	}
+	expectValue(t, e, "user", "value")
+}
+
+// redni brojevi posle ponovnog pokretanja su veci od zapisa u sstabelama i
+// kada sat kasni, inace bi oporavak preskocio nove zapise
+func TestSequenceStartsAfterFlushedRecords(t *testing.T) {
+	chdirTemp(t)
+	flushed := uint64(time.Now().Add(time.Hour).UnixNano())
+	writeConfig(t, func(cfg *config.Config) {
+		cfg.FlushedSequence = flushed
+	})
+	e := openEngine(t, nil)
+	err := e.Put("key", []byte("value"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := e.Get("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.SeqNum <= flushed {
+		t.Fatalf("sequence number %d is not after the flushed sequence %d", r.SeqNum, flushed)
+	}
+
+	e = openEngine(t, nil)
+	expectValue(t, e, "key", "value")
+}
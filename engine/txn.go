@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"main/record"
+)
+
+// Txn je optimisticka transakcija. Upisi se cuvaju do Commit-a, citanja vide
+// upise transakcije, a Commit vraca ErrTxnConflict ako se procitan kljuc
+// promenio posle citanja. Upisi u vise familija se upisuju atomicno.
+type Txn struct {
+	engine *Engine
+	reads  map[txnKey]txnRead
+	writes map[txnKey]*record.Record
+	order  []txnKey // upisani kljucevi redom prvog upisa
+	done   bool
+}
+
+type txnKey struct {
+	family *ColumnFamily
+	key    string
+}
+
+// sta je prvo citanje kljuca videlo, kljuc koji je postojao pa je obrisan se
+// razlikuje od kljuca kog nije bilo i kada je njegov tombstone kompaktovan
+type txnRead struct {
+	found   bool
+	version uint64
+}
+
+// Begin pocinje transakciju nad trenutnim stanjem engine-a.
+func (e *Engine) Begin() *Txn {
+	return &Txn{
+		engine: e,
+		reads:  make(map[txnKey]txnRead),
+		writes: make(map[txnKey]*record.Record),
+	}
+}
+
+// Get vraca vrednost upisanu u transakciji, a inace zivu verziju iz
+// engine-a. Kljuc se proverava pri Commit-u.
+func (t *Txn) Get(key string) (*record.Record, error) {
+	return t.GetCF(t.engine.defaultFamily, key)
+}
+
+// GetCF je Get za kljuc date familije.
+func (t *Txn) GetCF(cf *ColumnFamily, key string) (*record.Record, error) {
+	if t.done {
+		return nil, ErrTxnDone
+	}
+	k := txnKey{family: cf, key: key}
+	if written, found := t.writes[k]; found {
+		if written.Tombstone {
+			return nil, ErrNotFound
+		}
+		copied := *written
+		copied.Value = bytes.Clone(written.Value)
+		return &copied, nil
+	}
+	current, err := cf.Get(key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if _, found := t.reads[k]; !found {
+		t.reads[k] = observed(current)
+	}
+	return current, err
+}
+
+func observed(current *record.Record) txnRead {
+	if current == nil {
+		return txnRead{}
+	}
+	return txnRead{found: true, version: current.Version()}
+}
+
+func (t *Txn) Put(key string, value []byte) error {
+	return t.write(t.engine.defaultFamily, key, value, false)
+}
+
+func (t *Txn) Delete(key string) error {
+	return t.write(t.engine.defaultFamily, key, nil, true)
+}
+
+func (t *Txn) PutCF(cf *ColumnFamily, key string, value []byte) error {
+	return t.write(cf, key, value, false)
+}
+
+func (t *Txn) DeleteCF(cf *ColumnFamily, key string) error {
+	return t.write(cf, key, nil, true)
+}
+
+func (t *Txn) write(cf *ColumnFamily, key string, value []byte, deleted bool) error {
+	if t.done {
+		return ErrTxnDone
+	}
+	k := txnKey{family: cf, key: key}
+	if _, found := t.writes[k]; !found {
+		t.order = append(t.order, k)
+	}
+	t.writes[k] = record.NewRecord(key, value, deleted)
+	return nil
+}
+
+// Commit proverava da se nijedan procitan kljuc nije promenio od Begin-a i
+// upisuje sve upise u WAL kao jednu grupu. Transakcija se posle ne moze
+// koristiti, ni kada Commit nije uspeo.
+func (t *Txn) Commit() error {
+	if t.done {
+		return ErrTxnDone
+	}
+	t.done = true
+
+	e := t.engine
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for k, read := range t.reads {
+		if k.family.dropped {
+			return ErrNoColumnFamily
+		}
+		current, err := e.live(k.family, k.key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if observed(current) != read {
+			return ErrTxnConflict
+		}
+	}
+	if len(t.order) == 0 {
+		return nil
+	}
+
+	batch := make([]*record.Record, len(t.order))
+	for i, k := range t.order {
+		if k.family.dropped {
+			return ErrNoColumnFamily
+		}
+		written := t.writes[k]
+		batchRecord, err := e.newRecord(k.family, k.key, written.Value, written.Tombstone)
+		if err != nil {
+			return err
+		}
+		batchRecord.Family = k.family.id
+		e.assignSequence(batchRecord)
+		batchRecord.Batch = i < len(t.order)-1
+		batch[i] = batchRecord
+	}
+	err := e.Wal.AppendBatch(batch)
+	if err != nil {
+		return err
+	}
+
+	for i, batchRecord := range batch {
+		walSize := len(batchRecord.ToBytes())
+		batchRecord.Batch = false
+		err = e.apply(t.order[i].family, *batchRecord, walSize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback odbacuje upise transakcije.
+func (t *Txn) Rollback() {
+	t.done = true
+	t.writes = nil
+	t.order = nil
+}
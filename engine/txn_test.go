@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"errors"
+	"main/config"
+	"main/record"
+	"main/sstable"
+	"testing"
+)
+
+func TestTxnConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		conflict bool
+		// upis drugog klijenta posle citanja u transakciji
+		interfere func(e *Engine) error
+	}{
+		{"read key overwritten", true, func(e *Engine) error {
+			return e.Put("read", []byte("other"), false)
+		}},
+		{"read key deleted", true, func(e *Engine) error {
+			return e.Delete("read")
+		}},
+		{"missing read key written", true, func(e *Engine) error {
+			return e.Put("missing", []byte("other"), false)
+		}},
+		{"read key range deleted", true, func(e *Engine) error {
+			return e.DeleteRange("re", "rf")
+		}},
+		{"unrelated key written", false, func(e *Engine) error {
+			return e.Put("unrelated", []byte("other"), false)
+		}},
+		{"written key overwritten", false, func(e *Engine) error {
+			return e.Put("written", []byte("other"), false)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdirTemp(t)
+			e := openEngine(t, nil)
+			err := e.Put("read", []byte("initial"), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			txn := e.Begin()
+			_, err = txn.Get("read")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = txn.Get("missing")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get of a missing key: %v, want ErrNotFound", err)
+			}
+			err = txn.Put("written", []byte("txn"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tt.interfere(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = txn.Commit()
+			if !tt.conflict {
+				if err != nil {
+					t.Fatalf("Commit: %v, want no conflict", err)
+				}
+				expectValue(t, e, "written", "txn")
+				return
+			}
+			if !errors.Is(err, ErrTxnConflict) {
+				t.Fatalf("Commit: %v, want ErrTxnConflict", err)
+			}
+			// transakcija sa konfliktom ne upisuje nista
+			_, err = e.Get("written")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get of a key written by the failed transaction: %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestTxnReadsOwnWrites(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	txn := e.Begin()
+	err := txn.Put("key", []byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := txn.Get("key")
+	if err != nil || string(r.Value) != "value" {
+		t.Fatalf("Get in the transaction = %v, %v, want its own write", r, err)
+	}
+	_, err = e.Get("key")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get before Commit: %v, want ErrNotFound", err)
+	}
+
+	err = txn.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectValue(t, e, "key", "value")
+	if err = txn.Commit(); !errors.Is(err, ErrTxnDone) {
+		t.Fatalf("second Commit: %v, want ErrTxnDone", err)
+	}
+	if err = txn.Put("key", nil); !errors.Is(err, ErrTxnDone) {
+		t.Fatalf("Put after Commit: %v, want ErrTxnDone", err)
+	}
+}
+
+// kljuc koji je postojao pri citanju je konflikt i kada kompakcija izbaci
+// njegov tombstone, iako ga tada nema ni u jednoj tabeli
+func TestTxnConflictAfterTombstoneIsCompacted(t *testing.T) {
+	chdirTemp(t)
+	// bez kesa tombstone ne ostaje ni u kesu
+	writeConfig(t, func(cfg *config.Config) {
+		cfg.CacheMaxSize = 0
+	})
+	e := openEngine(t, nil)
+	err := e.Put("read", []byte("initial"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fill(t, e, "before", 10)
+
+	txn := e.Begin()
+	_, err = txn.Get("read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txn.Put("written", []byte("txn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.Delete("read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fill(t, e, "fill", 200)
+	found := false
+	err = sstable.SearchVersions(config.SSTABLE_DIRECTORY, "read", func(*record.Record) bool {
+		found = true
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("compaction kept a version of the deleted key")
+	}
+
+	err = txn.Commit()
+	if !errors.Is(err, ErrTxnConflict) {
+		t.Fatalf("Commit: %v, want ErrTxnConflict", err)
+	}
+}
+
+func TestTxnGetReturnsCopy(t *testing.T) {
+	chdirTemp(t)
+	e := openEngine(t, nil)
+	txn := e.Begin()
+	err := txn.Put("key", []byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := txn.Get("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Value[0] = 'X'
+	r.Tombstone = true
+
+	err = txn.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectValue(t, e, "key", "value")
+}
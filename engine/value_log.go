@@ -0,0 +1,68 @@
+package engine
+
+import (
+	valuelog "main/valueLog"
+	"time"
+)
+
+// ValueLogGC prepisuje zive vrednosti najstarijeg fajla value loga na kraj
+// loga i brise fajl. Vrednosti ciji je kljuc u medjuvremenu prepisan ili
+// obrisan se odbacuju. Vraca false kada nema fajla za ciscenje ili dok su
+// otvoreni iteratori podrazumevane familije.
+func (e *Engine) ValueLogGC() (bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// otvoreni iteratori mogu citati vrednosti iz fajla
+	if e.defaultFamily.iterators.Load() > 0 {
+		return false, nil
+	}
+	fileNumber, found := e.ValueLog.OldestFile()
+	if !found {
+		return false, nil
+	}
+
+	entries, err := e.ValueLog.ReadFile(fileNumber)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		live, err := e.pointsTo(entry.Key, entry.Pointer)
+		if err != nil {
+			return false, err
+		}
+		if !live {
+			continue
+		}
+		// nova verzija pokazuje na kraj loga, operandi upisani posle
+		// vrednosti se spajaju sa njom
+		current, err := e.get(e.defaultFamily, entry.Key)
+		if err != nil {
+			return false, err
+		}
+		err = e.put(e.defaultFamily, entry.Key, current.Value, false)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, e.ValueLog.RemoveFile(fileNumber)
+}
+
+// da li je najnovija vrednost kljuca, ispod njegovih operanada, vrednost na
+// datom pokazivacu. Kes se preskace jer cuva spojene vrednosti.
+func (e *Engine) pointsTo(key string, pointer valuelog.Pointer) (bool, error) {
+	var v versions
+	_, err := e.findVersions(e.defaultFamily, key, &v, false)
+	if err != nil {
+		return false, err
+	}
+	record := v.base
+	if record == nil || record.Tombstone || record.Expired(time.Now()) || !record.ValuePointer {
+		return false, nil
+	}
+	current, err := valuelog.PointerFromBytes(record.Value)
+	if err != nil {
+		return false, err
+	}
+	return current == pointer, nil
+}
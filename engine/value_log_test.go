@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"main/config"
+	"os"
+	"strings"
+	"testing"
+)
+
+func vlogFiles(t *testing.T) int {
+	t.Helper()
+	files, err := os.ReadDir("data/vlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
+
+func TestValueLogGC(t *testing.T) {
+	chdirTemp(t)
+	writeConfig(t, func(cfg *config.Config) {
+		cfg.ValueThreshold = 8
+		cfg.ValueLogFileSize = 300
+	})
+	e := openEngine(t, nil)
+	value := func(version, i int) string {
+		return strings.Repeat(fmt.Sprint(version), 40) + fmt.Sprint(i)
+	}
+	for i := 0; i < 10; i++ {
+		err := e.Put(fmt.Sprint("k", i), []byte(value(1, i)), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		err := e.Put(fmt.Sprint("k", i), []byte(value(2, i)), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := e.Delete("k5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := vlogFiles(t)
+
+	for i := 0; i < 3; i++ {
+		collected, err := e.ValueLogGC()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !collected {
+			t.Fatal("no value log file was collected")
+		}
+	}
+	if vlogFiles(t) >= before {
+		t.Fatalf("%d value log files after collection, %d before", vlogFiles(t), before)
+	}
+
+	check := func(e *Engine) {
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprint("k", i)
+			switch {
+			case i < 5:
+				expectValue(t, e, key, value(2, i))
+			case i == 5:
+				_, err := e.Get(key)
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("Get(%q): %v, want ErrNotFound", key, err)
+				}
+			default:
+				expectValue(t, e, key, value(1, i))
+			}
+		}
+	}
+	check(e)
+	check(openEngine(t, nil))
+}
@@ -0,0 +1,72 @@
+package envelope
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// Strukture (bloom filter, cms, hll, simhash) se cuvaju kao obicni bajtovi,
+// pa se upisuju sa zaglavljem i kontrolnom sumom:
+// magic (1B) | tip (1B) | verzija (1B) | sadrzaj | crc32 (4B)
+// Tako se struktura ne moze zameniti sa strukturom drugog tipa ili sa
+// proizvoljnim bajtovima.
+
+const (
+	magic        = 0xE7
+	headerSize   = 3
+	checksumSize = 4
+)
+
+// oznake tipova
+const (
+	Bloom   byte = 'B'
+	CMS     byte = 'C'
+	HLL     byte = 'H'
+	SimHash byte = 'S'
+)
+
+var (
+	ErrMalformed = errors.New("malformed structure")
+	ErrWrongType = errors.New("structure of a different type")
+	// strukture se spajaju samo ako imaju iste parametre i hash funkcije
+	ErrIncompatible = errors.New("structures are not compatible")
+)
+
+func Wrap(tag, version byte, payload []byte) []byte {
+	data := make([]byte, headerSize, headerSize+len(payload)+checksumSize)
+	data[0], data[1], data[2] = magic, tag, version
+	data = append(data, payload...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+}
+
+// da li podaci imaju zaglavlje, starije verzije su upisivale samo sadrzaj
+func IsWrapped(data []byte) bool {
+	return len(data) >= headerSize+checksumSize && data[0] == magic
+}
+
+// proverava zaglavlje i kontrolnu sumu, vraca verziju i sadrzaj
+func Unwrap(tag byte, data []byte) (byte, []byte, error) {
+	if !IsWrapped(data) {
+		return 0, nil, ErrMalformed
+	}
+	body := data[:len(data)-checksumSize]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(data[len(body):]) {
+		return 0, nil, fmt.Errorf("%w: checksum mismatch", ErrMalformed)
+	}
+	if body[1] != tag {
+		return 0, nil, ErrWrongType
+	}
+	return body[2], body[headerSize:], nil
+}
+
+// greska za verziju koju paket ne zna da procita
+func UnsupportedVersion(version byte) error {
+	return fmt.Errorf("%w: unsupported version %d", ErrMalformed, version)
+}
+
+// greska za hash funkciju koja nije registrovana u paketu hashing
+func UnknownHash(id byte) error {
+	return fmt.Errorf("%w: unknown hash function %d", ErrMalformed, id)
+}
@@ -0,0 +1,66 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWrapRoundTrip(t *testing.T) {
+	payload := []byte("payload")
+	data := Wrap(Bloom, 2, payload)
+	if !IsWrapped(data) {
+		t.Fatal("wrapped data is not recognized")
+	}
+	version, unwrapped, err := Unwrap(Bloom, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 2 || !bytes.Equal(unwrapped, payload) {
+		t.Fatalf("Unwrap = %d, %q, want 2, %q", version, unwrapped, payload)
+	}
+
+	version, unwrapped, err = Unwrap(SimHash, Wrap(SimHash, 1, nil))
+	if err != nil || version != 1 || len(unwrapped) != 0 {
+		t.Fatalf("Unwrap of an empty payload = %d, %q, %v", version, unwrapped, err)
+	}
+}
+
+func TestUnwrapRejectsDamagedData(t *testing.T) {
+	data := Wrap(CMS, 1, []byte("payload"))
+	flipped := func(i int) []byte {
+		damaged := bytes.Clone(data)
+		damaged[i] ^= 0xFF
+		return damaged
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrMalformed},
+		{"shorter than the header", data[:headerSize+checksumSize-1], ErrMalformed},
+		{"legacy bytes without a header", []byte("payload and more"), ErrMalformed},
+		{"wrong magic", flipped(0), ErrMalformed},
+		{"damaged tag", flipped(1), ErrMalformed},
+		{"damaged version", flipped(2), ErrMalformed},
+		{"damaged payload", flipped(headerSize), ErrMalformed},
+		{"damaged checksum", flipped(len(data) - 1), ErrMalformed},
+		{"truncated", data[:len(data)-1], ErrMalformed},
+		{"another type", Wrap(HLL, 1, []byte("payload")), ErrWrongType},
+	}
+	for _, tt := range tests {
+		_, _, err := Unwrap(CMS, tt.data)
+		if !errors.Is(err, tt.want) {
+			t.Fatalf("%s: Unwrap returned %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestVersionAndHashErrorsAreMalformed(t *testing.T) {
+	for _, err := range []error{UnsupportedVersion(9), UnknownHash(9)} {
+		if !errors.Is(err, ErrMalformed) {
+			t.Fatalf("%v is not ErrMalformed", err)
+		}
+	}
+}
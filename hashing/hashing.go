@@ -0,0 +1,112 @@
+package hashing
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Hash128 vraca 128-bitni hash podataka, seed menja hash funkciju
+type Hash128 func(data []byte, seed uint64) (uint64, uint64)
+
+// Identifikatori hash funkcija, upisuju se uz strukture da bi se procitane
+// strukture hesirale istom funkcijom. 0 je md5 koji su koristile starije verzije.
+const (
+	MD5     byte = 0
+	Murmur3 byte = 1
+)
+
+// funkcija koju koriste nove strukture
+const Default = Murmur3
+
+var functions = map[byte]Hash128{
+	Murmur3: Murmur3x64,
+}
+
+// dodaje hash funkciju pod identifikatorom, postojeca se zamenjuje
+func Register(id byte, function Hash128) {
+	functions[id] = function
+}
+
+func Get(id byte) (Hash128, bool) {
+	function, found := functions[id]
+	return function, found
+}
+
+// Kirsch-Mitzenmacher: i-ti od k indeksa se dobija iz jednog 128-bitnog hasha
+// kao h1 + i*h2, pa se podaci hesiraju samo jednom
+func Index(h1, h2 uint64, i uint32, m uint64) uint64 {
+	return (h1 + uint64(i)*h2) % m
+}
+
+const (
+	c1 = 0x87c37b91114253d5
+	c2 = 0x4cf5ad432745937f
+)
+
+// MurmurHash3 x64 128
+func Murmur3x64(data []byte, seed uint64) (uint64, uint64) {
+	h1, h2 := seed, seed
+	length := len(data)
+
+	for len(data) >= 16 {
+		k1 := binary.LittleEndian.Uint64(data)
+		k2 := binary.LittleEndian.Uint64(data[8:])
+		data = data[16:]
+
+		h1 ^= mixK1(k1)
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		h2 ^= mixK2(k2)
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	// ostatak kraci od 16 bajtova
+	var k1, k2 uint64
+	for i := len(data) - 1; i >= 8; i-- {
+		k2 = k2<<8 | uint64(data[i])
+	}
+	for i := min(len(data), 8) - 1; i >= 0; i-- {
+		k1 = k1<<8 | uint64(data[i])
+	}
+	if len(data) > 8 {
+		h2 ^= mixK2(k2)
+	}
+	if len(data) > 0 {
+		h1 ^= mixK1(k1)
+	}
+
+	h1 ^= uint64(length)
+	h2 ^= uint64(length)
+	h1 += h2
+	h2 += h1
+	h1 = fmix64(h1)
+	h2 = fmix64(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
+
+func mixK1(k uint64) uint64 {
+	k *= c1
+	k = bits.RotateLeft64(k, 31)
+	return k * c2
+}
+
+func mixK2(k uint64) uint64 {
+	k *= c2
+	k = bits.RotateLeft64(k, 33)
+	return k * c1
+}
+
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
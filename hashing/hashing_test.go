@@ -0,0 +1,98 @@
+package hashing
+
+import "testing"
+
+// vrednosti referentne implementacije MurmurHash3_x64_128
+func TestMurmur3KnownValues(t *testing.T) {
+	tests := []struct {
+		data   string
+		seed   uint64
+		h1, h2 uint64
+	}{
+		{"", 0, 0, 0},
+		{"", 1, 0x4610abe56eff5cb5, 0x51622daa78f83583},
+		{"hello", 0, 0xcbd8a7b341bd9b02, 0x5b1e906a48ae1d19},
+		{"hello", 42, 0xc4b8b3c960af6f08, 0x2334b875b0efbc7a},
+		{"The quick brown fox jumps over the lazy dog", 0, 0xe34bbc7bbc071b6c, 0x7a433ca9c49a9347},
+		// ceo blok od 16 bajtova, pa blok i jedan bajt ostatka
+		{"0123456789abcdef", 0, 0x4be06d94cf4ad1a7, 0x87c35b5c63a708da},
+		{"0123456789abcdefg", 7, 0x792d7b2ed7b034ea, 0x139764e0c8b00f0c},
+	}
+	for _, tt := range tests {
+		h1, h2 := Murmur3x64([]byte(tt.data), tt.seed)
+		if h1 != tt.h1 || h2 != tt.h2 {
+			t.Fatalf("Murmur3x64(%q, %d) = %#x, %#x, want %#x, %#x", tt.data, tt.seed, h1, h2, tt.h1, tt.h2)
+		}
+	}
+}
+
+// svaka duzina ostatka ide drugom granom, promena bilo kog bajta menja hash
+func TestMurmur3TailLengths(t *testing.T) {
+	data := []byte("abcdefghijklmnopqrstuvwxyz0123456789")
+	seen := make(map[[2]uint64]int)
+	for n := 0; n <= len(data); n++ {
+		h1, h2 := Murmur3x64(data[:n], 0)
+		if previous, found := seen[[2]uint64{h1, h2}]; found {
+			t.Fatalf("prefixes of length %d and %d have the same hash", previous, n)
+		}
+		seen[[2]uint64{h1, h2}] = n
+
+		for i := 0; i < n; i++ {
+			changed := append([]byte(nil), data[:n]...)
+			changed[i] ^= 1
+			c1, c2 := Murmur3x64(changed, 0)
+			if c1 == h1 && c2 == h2 {
+				t.Fatalf("changing byte %d of %d does not change the hash", i, n)
+			}
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	function, found := Get(Default)
+	if !found {
+		t.Fatal("the default hash function is not registered")
+	}
+	h1, h2 := function([]byte("hello"), 0)
+	w1, w2 := Murmur3x64([]byte("hello"), 0)
+	if h1 != w1 || h2 != w2 {
+		t.Fatal("the default hash function is not Murmur3x64")
+	}
+	// md5 hesiraju same strukture starijih verzija
+	if _, found = Get(MD5); found {
+		t.Fatal("md5 is registered as a 128 bit hash")
+	}
+
+	const custom byte = 200
+	defer delete(functions, custom)
+	Register(custom, func(data []byte, seed uint64) (uint64, uint64) {
+		return uint64(len(data)), seed
+	})
+	function, found = Get(custom)
+	if !found {
+		t.Fatal("a registered hash function is not found")
+	}
+	if h1, h2 = function([]byte("abc"), 9); h1 != 3 || h2 != 9 {
+		t.Fatalf("registered function returned %d, %d", h1, h2)
+	}
+}
+
+// indeksi Kirsch-Mitzenmacher su u opsegu i za razlicite i se razlikuju
+func TestIndex(t *testing.T) {
+	const m = 1009
+	h1, h2 := Murmur3x64([]byte("element"), 0)
+	seen := make(map[uint64]bool)
+	for i := uint32(0); i < 10; i++ {
+		index := Index(h1, h2, i, m)
+		if index >= m {
+			t.Fatalf("index %d is out of range %d", index, m)
+		}
+		seen[index] = true
+	}
+	if len(seen) < 9 {
+		t.Fatalf("10 indexes hit only %d positions", len(seen))
+	}
+	if Index(h1, h2, 0, m) != h1%m {
+		t.Fatal("the first index is not h1 mod m")
+	}
+}
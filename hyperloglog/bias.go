@@ -0,0 +1,250 @@
+// Code generated by go run bias_gen.go; DO NOT EDIT.
+
+package hll
+
+// prosecna sirova procena i njeno odstupanje, po preciznosti od 4
+var rawEstimateData = [][]float64{
+	{
+		11.2368, 11.7223, 12.7389, 13.2712, 13.8194, 14.9608, 15.5555, 16.1662, 17.4342, 18.0898,
+		18.7616, 20.151, 20.8668, 21.594, 23.097, 23.8661, 24.6534, 26.2725, 27.0963, 27.9259,
+		29.6257, 30.4912, 31.3684, 33.1354, 34.04, 34.9606, 36.7978, 37.727, 38.6571, 40.5369,
+		41.4858, 42.4423, 44.3587, 45.3287, 46.2968, 48.2439, 49.2294, 50.21, 52.1685, 53.1494,
+		54.1274, 56.1102, 57.1141, 58.105, 60.1113, 61.0942, 62.092, 64.0891, 65.0779, 66.0662,
+		68.0595, 69.0527, 70.051, 72.0475, 73.0388, 74.0269, 76.0163, 77.0037, 78.0018, 80.0305,
+	},
+	{
+		23.2607, 24.7537, 26.3157, 27.3904, 29.0662, 30.8111, 32.0037, 33.8571, 35.7755, 37.0884,
+		39.1094, 41.1971, 42.6186, 44.8118, 47.0633, 48.5916, 50.9319, 53.3367, 54.9672, 57.4296,
+		59.9618, 61.6627, 64.2607, 66.8772, 68.6543, 71.3303, 74.0167, 75.8444, 78.6193, 81.4102,
+		83.2862, 86.1114, 88.9734, 90.8903, 93.7804, 96.6661, 98.5939, 101.507, 104.438, 106.376,
+		109.312, 112.243, 114.215, 117.152, 120.092, 122.079, 125.033, 128.004, 129.992, 132.978,
+		135.974, 137.96, 140.956, 143.902, 145.911, 148.937, 151.892, 153.848, 156.823, 159.857,
+	},
+	{
+		47.8066, 50.3263, 53.4721, 56.1983, 59.011, 62.5072, 65.519, 68.6333, 72.4829, 75.7937,
+		79.1726, 83.3332, 86.8937, 90.5439, 95.0285, 98.8259, 102.727, 107.487, 111.532, 115.622,
+		120.653, 124.88, 129.195, 134.437, 138.862, 143.339, 148.777, 153.302, 157.901, 163.421,
+		168.055, 172.77, 178.443, 183.259, 188.071, 193.805, 198.68, 203.527, 209.341, 214.232,
+		219.112, 224.992, 229.84, 234.76, 240.735, 245.744, 250.708, 256.612, 261.537, 266.526,
+		272.497, 277.473, 282.431, 288.414, 293.413, 298.493, 304.454, 309.436, 314.404, 320.414,
+	},
+	{
+		96.4322, 102.006, 107.798, 113.258, 119.47, 125.893, 131.916, 138.777, 145.842, 152.443,
+		159.914, 167.605, 174.742, 182.737, 190.936, 198.533, 207.086, 215.783, 223.866, 232.875,
+		242.073, 250.498, 259.966, 269.439, 278.191, 288.001, 297.888, 306.968, 317.114, 327.169,
+		336.553, 346.894, 357.334, 366.807, 377.323, 387.822, 397.343, 407.972, 418.568, 428.326,
+		439.123, 449.919, 459.684, 470.579, 481.4, 491.349, 502.156, 512.999, 522.895, 533.781,
+		544.617, 554.572, 565.545, 576.558, 586.579, 597.587, 608.524, 618.558, 629.527, 640.388,
+	},
+	{
+		194.15, 204.839, 216.46, 227.953, 239.82, 252.715, 265.387, 278.454, 292.576, 306.46,
+		320.696, 336.057, 351.03, 366.335, 382.827, 398.802, 415.116, 432.651, 449.633, 466.788,
+		485.097, 502.768, 520.71, 539.803, 558.348, 577.052, 596.808, 615.849, 635.031, 655.278,
+		674.823, 694.472, 715.204, 735.165, 755.249, 776.604, 796.821, 816.928, 838.181, 858.457,
+		879.065, 900.48, 920.91, 941.834, 963.631, 984.143, 1004.83, 1026.73, 1047.52, 1068.19,
+		1090.15, 1111.09, 1131.95, 1153.57, 1174.34, 1195.19, 1217.12, 1238.07, 1259.41, 1281.29,
+	},
+	{
+		389.115, 410.992, 433.701, 456.697, 480.99, 506.13, 531.489, 558.303, 585.991, 613.707,
+		642.692, 672.639, 702.477, 733.761, 765.922, 797.785, 831.379, 865.461, 899.33, 934.525,
+		970.291, 1005.97, 1042.67, 1079.98, 1116.88, 1155.34, 1193.97, 1231.98, 1271.37, 1310.88,
+		1349.82, 1389.94, 1430.49, 1470.05, 1510.91, 1551.58, 1592.07, 1633.48, 1675.13, 1715.93,
+		1758.2, 1800.11, 1840.85, 1883.01, 1925.34, 1966.97, 2009.55, 2052.07, 2094.41, 2137.27,
+		2179.73, 2221.62, 2264.41, 2307.28, 2349.35, 2392.22, 2434.82, 2476.49, 2519.46, 2562.66,
+	},
+	{
+		779.503, 822.735, 868.299, 914.871, 962.978, 1013.37, 1064.61, 1117.74, 1172.99, 1229.13,
+		1286.74, 1346.45, 1407, 1469.04, 1533.24, 1597.83, 1663.59, 1731.39, 1799.8, 1869.06,
+		1940.69, 2012.37, 2084.71, 2159.63, 2233.91, 2309.34, 2385.54, 2462.32, 2540.64, 2620.26,
+		2699.28, 2778.69, 2859.35, 2939.69, 3020.53, 3102.88, 3184.43, 3267.01, 3350.19, 3432.87,
+		3515.71, 3599.59, 3682.58, 3766.19, 3850.44, 3934.98, 4019.33, 4102.87, 4186.4, 4271.18,
+		4356.87, 4441.79, 4526.33, 4610.97, 4694.82, 4780.7, 4866.34, 4951.56, 5036.14, 5121.36,
+	},
+	{
+		1559.85, 1647, 1737.34, 1830.64, 1927.76, 2027.77, 2130.7, 2237.52, 2347.56, 2459.98,
+		2576.15, 2694.92, 2815.76, 2940.04, 3066.84, 3196.83, 3329.86, 3464.98, 3602.13, 3742.22,
+		3885.12, 4028.27, 4174.29, 4322.05, 4471.14, 4622.35, 4776.98, 4930.18, 5085.78, 5242.6,
+		5399.35, 5559.18, 5719.61, 5881.55, 6043.43, 6207.65, 6368.43, 6532.49, 6696.2, 6861.52,
+		7026.97, 7192.82, 7359.48, 7526.79, 7696.62, 7863.62, 8031.42, 8201.23, 8370.75, 8539.77,
+		8709.8, 8877.53, 9047.85, 9217.47, 9386.67, 9557.09, 9727.6, 9898.16, 10069.7, 10240.9,
+	},
+	{
+		3120.69, 3294.38, 3475.43, 3662.01, 3855.19, 4056.03, 4262.83, 4475.24, 4694.59, 4918.98,
+		5149.52, 5387.54, 5630.65, 5878.4, 6132.23, 6392.38, 6656.5, 6926.89, 7201.26, 7479.98,
+		7766.28, 8053.57, 8345.19, 8642.14, 8940.72, 9242.01, 9549.91, 9858.77, 10169.8, 10482.5,
+		10801, 11119.4, 11443.5, 11768.8, 12092.3, 12418.7, 12746.1, 13074.7, 13406.7, 13736.2,
+		14071.5, 14404.5, 14738.5, 15072.6, 15407.9, 15744.1, 16082.4, 16420.7, 16756.2, 17089.7,
+		17428.3, 17767.2, 18110.2, 18448.1, 18789, 19130.7, 19468.2, 19804.2, 20143.3, 20482.1,
+	},
+	{
+		6242.56, 6590.5, 6951.31, 7325.48, 7712.88, 8113.41, 8526.78, 8952.74, 9391.5, 9844.08,
+		10308.7, 10784.2, 11271.4, 11770.3, 12283.4, 12802.7, 13331.8, 13872.5, 14419.4, 14981.4,
+		15550.3, 16127, 16711.4, 17303.5, 17903.4, 18507.1, 19116.1, 19732.3, 20357.9, 20984.5,
+		21619.1, 22256.8, 22899, 23542.2, 24182.3, 24830.9, 25481.9, 26138.1, 26795.8, 27457.4,
+		28122.1, 28790.5, 29454.9, 30122.9, 30789.6, 31462.1, 32138.5, 32811.2, 33488.2, 34161.2,
+		34837.7, 35514.4, 36186.6, 36861.6, 37535.4, 38226.3, 38904.4, 39582.3, 40266.3, 40950.2,
+	},
+	{
+		12486, 13181.2, 13902.2, 14650.5, 15423.6, 16223.5, 17049, 17900.3, 18778.2, 19681,
+		20605.7, 21556.5, 22533.6, 23524.6, 24542.2, 25580.8, 26646.5, 27725.2, 28817.8, 29938.1,
+		31068.8, 32213.3, 33378.7, 34556.4, 35757.7, 36965.7, 38186, 39425.2, 40662.7, 41924.2,
+		43189.1, 44473.6, 45767.3, 47060.4, 48355.7, 49636.9, 50954.7, 52268.5, 53583, 54920.3,
+		56247.9, 57590.4, 58937, 60269, 61607.4, 62948.6, 64284.8, 65647.4, 66969.5, 68311.2,
+		69654.4, 71009.1, 72363.8, 73712.5, 75080.8, 76429.8, 77764.1, 79136.5, 80511.4, 81864.5,
+	},
+	{
+		24972.7, 26363.9, 27810, 29305, 30856.9, 32455.4, 34105.9, 35812.4, 37564, 39360.9,
+		41215.7, 43122.7, 45065.4, 47057.1, 49098.2, 51174.6, 53293.9, 55440.2, 57638.6, 59881.4,
+		62162.8, 64461.2, 66805.3, 69191.6, 71577.8, 73982.2, 76409.9, 78880.9, 81362.8, 83890,
+		86418.3, 88960.7, 91516, 94123.5, 96714.5, 99353.1, 101972, 104598, 107291, 109916,
+		112577, 115265, 117946, 120607, 123258, 125944, 128625, 131366, 134072, 136784,
+		139477, 142179, 144901, 147613, 150338, 153096, 155799, 158532, 161238, 163940,
+	},
+	{
+		49950.2, 52732.7, 55620.9, 58619.6, 61720, 64926.4, 68240, 71643.5, 75161.9, 78771.6,
+		82474.3, 86254.3, 90147, 94130.7, 98209.2, 102349, 106586, 110893, 115275, 119762,
+		124297, 128919, 133588, 138324, 143124, 147979, 152880, 157804, 162826, 167855,
+		172881, 177961, 183100, 188267, 193495, 198723, 203906, 209132, 214399, 219671,
+		225023, 230374, 235736, 241107, 246450, 251896, 257243, 262569, 267969, 273417,
+		278834, 284268, 289655, 295101, 300516, 305957, 311402, 316769, 322207, 327720,
+	},
+	{
+		99888.3, 105454, 111231, 117226, 123422, 129819, 136428, 143265, 150278, 157517,
+		164938, 172553, 180319, 188245, 196380, 204744, 213234, 221908, 230715, 239649,
+		248689, 257888, 267284, 276701, 286353, 295998, 305775, 315734, 325713, 335770,
+		345860, 356022, 366343, 376661, 387113, 397578, 408030, 418602, 429244, 439854,
+		450408, 461034, 471704, 482392, 493148, 503915, 514720, 525501, 536288, 547101,
+		557948, 568804, 579720, 590508, 601283, 612145, 623042, 633972, 644872, 655855,
+	},
+	{
+		199799, 210935, 222482, 234459, 246847, 259633, 272898, 286476, 300544, 315021,
+		329839, 344955, 360524, 376456, 392633, 409291, 426226, 443504, 461082, 478872,
+		497025, 515385, 534109, 552990, 572166, 591531, 611087, 630836, 650668, 670832,
+		690931, 711480, 731950, 752655, 773425, 794269, 815170, 836348, 857529, 878500,
+		899702, 920978, 942420, 963823, 985393, 1.00687e+06, 1.02844e+06, 1.05017e+06, 1.07181e+06, 1.0934e+06,
+		1.11502e+06, 1.1368e+06, 1.15867e+06, 1.18035e+06, 1.20217e+06, 1.22404e+06, 1.2457e+06, 1.26754e+06, 1.28941e+06, 1.31119e+06,
+	},
+}
+
+var biasData = [][]float64{
+	{
+		10.2368, 9.72229, 8.73887, 8.27123, 7.81944, 6.96075, 6.55548, 6.16616, 5.43418, 5.08979,
+		4.7616, 4.151, 3.86683, 3.594, 3.09705, 2.86605, 2.65337, 2.27253, 2.09632, 1.92588,
+		1.62572, 1.49117, 1.36836, 1.13539, 1.04001, 0.960582, 0.797843, 0.726979, 0.657067, 0.536867,
+		0.485824, 0.442255, 0.358668, 0.328722, 0.296774, 0.243906, 0.229371, 0.210033, 0.168484, 0.149396,
+		0.127407, 0.110239, 0.114062, 0.105019, 0.111337, 0.0941687, 0.0920253, 0.0891095, 0.0778825, 0.066213,
+		0.0594538, 0.0527334, 0.0510461, 0.0475235, 0.0387513, 0.0269487, 0.0163147, 0.00372396, 0.00175851, 0.0305135,
+	},
+	{
+		21.2607, 19.7537, 18.3157, 17.3904, 16.0662, 14.8111, 14.0037, 12.8571, 11.7755, 11.0884,
+		10.1094, 9.19713, 8.61865, 7.81179, 7.06331, 6.59162, 5.93186, 5.33667, 4.96715, 4.42958,
+		3.96183, 3.66272, 3.26069, 2.87723, 2.65429, 2.33031, 2.01668, 1.84445, 1.61929, 1.41019,
+		1.28621, 1.1114, 0.973433, 0.890332, 0.780373, 0.666116, 0.593882, 0.506976, 0.437523, 0.376394,
+		0.312435, 0.243214, 0.214717, 0.152177, 0.0915925, 0.0790738, 0.0332939, 0.00448987, -0.00787215, -0.0222732,
+		-0.0263643, -0.0398947, -0.0438583, -0.0976289, -0.0888684, -0.063327, -0.107822, -0.151691, -0.177418, -0.142863,
+	},
+	{
+		42.8066, 40.3263, 37.4721, 35.1983, 33.011, 30.5072, 28.519, 26.6333, 24.4829, 22.7937,
+		21.1726, 19.3332, 17.8937, 16.5439, 15.0285, 13.8259, 12.727, 11.487, 10.5323, 9.62219,
+		8.65271, 7.87958, 7.19506, 6.43687, 5.8625, 5.33925, 4.77713, 4.30162, 3.90121, 3.4208,
+		3.05534, 2.7695, 2.44296, 2.25913, 2.07146, 1.80482, 1.68034, 1.52731, 1.34092, 1.2321,
+		1.11173, 0.99219, 0.839808, 0.75995, 0.734827, 0.743694, 0.708341, 0.612418, 0.537344, 0.52564,
+		0.496742, 0.473139, 0.431106, 0.41432, 0.413189, 0.493271, 0.453817, 0.435804, 0.404007, 0.413594,
+	},
+	{
+		86.4322, 81.0061, 75.7985, 71.2576, 66.47, 61.8926, 57.9161, 53.777, 49.8415, 46.4426,
+		42.9137, 39.6048, 36.7421, 33.7375, 30.9364, 28.5331, 26.0856, 23.7828, 21.8656, 19.8752,
+		18.0726, 16.498, 14.9661, 13.439, 12.1907, 11.0009, 9.88809, 8.96751, 8.11419, 7.16868,
+		6.55291, 5.89372, 5.33422, 4.80698, 4.32346, 3.82229, 3.34302, 2.97173, 2.56768, 2.32557,
+		2.12278, 1.91934, 1.68391, 1.57922, 1.40032, 1.34892, 1.15621, 0.99884, 0.894629, 0.780559,
+		0.61722, 0.571614, 0.545306, 0.558466, 0.578949, 0.58683, 0.524351, 0.558419, 0.526537, 0.388081,
+	},
+	{
+		173.15, 162.839, 152.46, 142.953, 133.82, 124.715, 116.387, 108.454, 100.576, 93.4597,
+		86.6958, 80.057, 74.0303, 68.3349, 62.8273, 57.8015, 53.1158, 48.6511, 44.6332, 40.7883,
+		37.097, 33.7682, 30.7099, 27.8031, 25.3476, 23.0516, 20.8083, 18.849, 17.0306, 15.2783,
+		13.8227, 12.4724, 11.2039, 10.1652, 9.24917, 8.60418, 7.82148, 6.92843, 6.18065, 5.45692,
+		5.0645, 4.47954, 3.90974, 3.83379, 3.63089, 3.14349, 2.82622, 2.72856, 2.51694, 2.18903,
+		2.15497, 2.08851, 1.9535, 1.57168, 1.33992, 1.19062, 1.11555, 1.06918, 1.41278, 1.29364,
+	},
+	{
+		347.115, 325.992, 305.701, 286.697, 267.99, 250.13, 233.489, 217.303, 201.991, 187.707,
+		173.692, 160.639, 148.477, 136.761, 125.922, 115.785, 106.379, 97.4613, 89.3303, 81.5247,
+		74.2913, 67.9713, 61.6696, 55.983, 50.8759, 46.3368, 41.9711, 37.9834, 34.3666, 30.8834,
+		27.8175, 24.9449, 22.4896, 20.0502, 17.9065, 15.5751, 14.0737, 12.4752, 11.1341, 9.92918,
+		9.19917, 8.10566, 6.8526, 6.00985, 5.3404, 4.97065, 4.54575, 4.07319, 4.41087, 4.26507,
+		3.72768, 3.61933, 3.41139, 3.28337, 3.34662, 3.22173, 2.81846, 2.48968, 2.45858, 2.66458,
+	},
+	{
+		694.503, 652.735, 612.299, 573.871, 536.978, 501.368, 467.607, 435.74, 404.987, 376.132,
+		348.745, 322.445, 298.002, 275.043, 253.237, 232.825, 213.592, 195.387, 178.796, 163.061,
+		148.69, 135.375, 122.711, 111.632, 100.908, 91.336, 81.5352, 73.3249, 66.6351, 60.2578,
+		54.277, 48.6861, 43.3521, 38.6871, 34.5266, 30.8781, 27.4309, 25.0137, 22.191, 19.8669,
+		17.7096, 15.5857, 13.5757, 12.1946, 10.4379, 9.98106, 9.32576, 6.87305, 5.3971, 5.17954,
+		4.86964, 4.79296, 4.33086, 2.96546, 1.82339, 2.69921, 2.34133, 2.56432, 2.1427, 1.3649,
+	},
+	{
+		1389.85, 1306, 1225.34, 1148.64, 1074.76, 1003.77, 936.7, 872.518, 811.564, 753.98,
+		699.149, 646.918, 597.757, 551.043, 506.843, 466.825, 428.858, 392.978, 360.127, 329.223,
+		301.117, 274.268, 249.292, 226.047, 205.144, 185.352, 168.984, 152.179, 136.776, 122.604,
+		109.353, 98.18, 87.6124, 79.5525, 70.4327, 63.6532, 54.4255, 47.4916, 40.2023, 35.5151,
+		29.967, 24.8175, 21.4774, 17.7877, 16.6249, 13.6208, 10.4168, 9.22883, 8.75367, 6.768,
+		5.79662, 3.53302, 2.85302, 1.46999, 0.66889, 0.0876143, -0.396877, 0.163323, 0.725047, 0.898037,
+	},
+	{
+		2779.69, 2612.38, 2451.43, 2297.01, 2149.19, 2008.03, 1873.83, 1745.24, 1622.59, 1505.98,
+		1395.52, 1291.54, 1193.65, 1100.4, 1012.23, 931.382, 854.504, 782.886, 716.263, 653.979,
+		598.278, 544.575, 495.185, 450.143, 407.719, 368.006, 333.912, 301.769, 271.846, 242.469,
+		220.05, 197.396, 179.463, 163.788, 146.348, 130.712, 117.127, 104.664, 94.7215, 83.1512,
+		77.5172, 68.5103, 61.5206, 54.6028, 47.938, 43.0578, 40.3689, 36.7088, 31.241, 23.6718,
+		20.282, 18.1787, 20.2115, 16.1004, 16.0292, 16.7281, 12.2027, 7.16743, 5.34077, 2.08674,
+	},
+	{
+		5560.56, 5225.5, 4903.31, 4595.48, 4299.88, 4017.41, 3748.78, 3491.74, 3247.5, 3018.08,
+		2799.72, 2592.16, 2397.43, 2213.33, 2043.45, 1880.75, 1726.84, 1584.47, 1449.41, 1328.37,
+		1214.35, 1109.03, 1010.35, 919.455, 837.4, 758.078, 684.121, 618.259, 560.949, 504.496,
+		457.072, 411.832, 371, 332.166, 289.269, 254.861, 223.885, 197.102, 171.751, 151.368,
+		133.117, 118.474, 100.9, 85.936, 69.6096, 60.1078, 53.4933, 43.1679, 38.2025, 28.1743,
+		21.6674, 16.4364, 5.5551, -2.38735, -10.5623, -2.67287, -7.63496, -11.6701, -10.6686, -9.79466,
+	},
+	{
+		11121, 10451.2, 9806.21, 9189.54, 8597.59, 8031.5, 7492, 6978.31, 6490.16, 6028.01,
+		5587.75, 5172.54, 4784.62, 4410.62, 4062.17, 3735.81, 3436.54, 3149.18, 2876.76, 2632.12,
+		2396.75, 2176.26, 1976.68, 1788.36, 1624.74, 1467.66, 1322, 1196.24, 1068.73, 964.228,
+		864.057, 783.614, 711.321, 639.369, 569.738, 484.903, 437.677, 386.511, 335.003, 307.293,
+		269.88, 246.443, 227.974, 194.972, 167.394, 143.649, 114.757, 111.412, 68.4594, 45.2146,
+		22.409, 12.134, 1.83767, -15.5412, -12.1797, -28.2468, -59.8646, -52.5068, -42.6292, -55.5079,
+	},
+	{
+		22242.7, 20902.9, 19618, 18383, 17203.9, 16071.4, 14991.9, 13967.4, 12988, 12054.9,
+		11178.7, 10354.7, 9567.4, 8828.11, 8138.24, 7484.61, 6872.95, 6288.25, 5756.56, 5268.41,
+		4818.76, 4387.19, 4000.32, 3655.6, 3311.81, 2985.17, 2681.9, 2422.93, 2173.82, 1969.96,
+		1768.31, 1579.69, 1404, 1281.46, 1141.51, 1049.12, 938.357, 832.524, 794.572, 690.008,
+		620.223, 576.588, 527.67, 458.367, 377.743, 333.946, 284.192, 294.436, 269.914, 250.939,
+		212.823, 184.677, 176.183, 157.075, 151.994, 178.827, 151.182, 153.557, 128.844, 100.385,
+	},
+	{
+		44489.2, 41810.7, 39236.9, 36774.6, 34414, 32158.4, 30011, 27953.5, 26009.9, 24158.6,
+		22400.3, 20718.3, 19150, 17672.7, 16289.2, 14967.8, 13744.3, 12588.9, 11510.1, 10536.2,
+		9609.11, 8770.33, 7977.62, 7252.2, 6591.17, 5984.84, 5424.18, 4887.36, 4447.57, 4014.67,
+		3579.8, 3198.86, 2876.29, 2581.88, 2349.35, 2115.07, 1837.48, 1602.32, 1407.27, 1218.32,
+		1109.25, 998.349, 898.92, 808.796, 689.598, 675.247, 560.973, 425.481, 364.335, 350.608,
+		305.816, 279.024, 205.214, 189.463, 143.374, 122.92, 106.218, 11.9413, -10.5563, 40.3886,
+	},
+	{
+		88966.3, 83609, 78462.9, 73536.2, 68808.8, 64283.4, 59969.7, 55883.5, 51973.5, 48290.6,
+		44789.5, 41481.4, 38325.2, 35327.9, 32539.8, 29982, 27548.6, 25300.5, 23185, 21195.6,
+		19312.6, 17589.8, 16063.3, 14557.3, 13286.9, 12008.6, 10863, 9900.45, 8955.53, 8089.55,
+		7257.63, 6496.59, 5894.65, 5290.99, 4820.34, 4361.87, 3891.62, 3540.9, 3260.44, 2947.6,
+		2578.62, 2281.86, 2030.43, 1794.64, 1627.77, 1472.52, 1354.58, 1212.86, 1077.69, 968.009,
+		891.65, 825.893, 819.241, 684.326, 536.753, 476.317, 449.561, 458.414, 434.632, 494.634,
+	},
+	{
+		177954, 167245, 156946, 147078, 137621, 128561, 119981, 111714, 103936, 96568.1,
+		89541, 82810.5, 76535.1, 70621.6, 64952.6, 59765.7, 54855.7, 50288.3, 46021.1, 41965.7,
+		38272.6, 34787.6, 31667.3, 28702.1, 26033.1, 23553.5, 21262.5, 19166.6, 17153.7, 15472.4,
+		13726.1, 12430, 11054.2, 9914.3, 8838.63, 7836.71, 6892.65, 6226.03, 5560.51, 4687.39,
+		4043.77, 3474.36, 3071.22, 2629.28, 2352.54, 1987.1, 1709.07, 1592.59, 1384.31, 1138.36,
+		904.921, 845.085, 871.609, 698.206, 677.231, 697.942, 517.916, 515.896, 534.437, 466.587,
+	},
+}
@@ -0,0 +1,103 @@
+//go:build ignore
+
+// Pravi bias.go: za svaku preciznost simulira hll sa slucajnim hash
+// vrednostima i belezi prosecnu sirovu procenu i njeno odstupanje od stvarnog
+// broja elemenata, do 5m elemenata gde se korekcija koristi.
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	minPrecision = 4
+	maxPrecision = 18
+	points       = 60
+)
+
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/m)
+}
+
+func simulate(p uint8, runs int, r *rand.Rand) ([]float64, []float64) {
+	m := 1 << p
+	raw := make([]float64, points)
+	bias := make([]float64, points)
+	for run := 0; run < runs; run++ {
+		reg := make([]uint8, m)
+		sum := float64(m)
+		n := 0
+		for i := 0; i < points; i++ {
+			target := (i + 1) * 5 * m / points
+			for ; n < target; n++ {
+				h := r.Uint64()
+				bucket := h >> (64 - p)
+				rho := uint8(bits.LeadingZeros64(h<<p|1<<(p-1)) + 1)
+				if reg[bucket] < rho {
+					sum += math.Ldexp(1, -int(rho)) - math.Ldexp(1, -int(reg[bucket]))
+					reg[bucket] = rho
+				}
+			}
+			estimate := alpha(float64(m)) * float64(m) * float64(m) / sum
+			raw[i] += estimate
+			bias[i] += estimate - float64(target)
+		}
+	}
+	for i := range raw {
+		raw[i] /= float64(runs)
+		bias[i] /= float64(runs)
+	}
+	return raw, bias
+}
+
+func table(values [][]float64) string {
+	var b strings.Builder
+	for _, row := range values {
+		b.WriteString("\t{")
+		for i, v := range row {
+			if i%10 == 0 {
+				b.WriteString("\n\t\t")
+			} else {
+				b.WriteString(" ")
+			}
+			b.WriteString(strconv.FormatFloat(v, 'g', 6, 64) + ",")
+		}
+		b.WriteString("\n\t},\n")
+	}
+	return b.String()
+}
+
+func main() {
+	r := rand.New(rand.NewSource(1))
+	var raw, bias [][]float64
+	for p := uint8(minPrecision); p <= maxPrecision; p++ {
+		runs := max(10, 2_000_000>>p)
+		rawRow, biasRow := simulate(p, runs, r)
+		raw = append(raw, rawRow)
+		bias = append(bias, biasRow)
+	}
+
+	out := "// Code generated by go run bias_gen.go; DO NOT EDIT.\n\npackage hll\n\n" +
+		"// prosecna sirova procena i njeno odstupanje, po preciznosti od 4\n" +
+		"var rawEstimateData = [][]float64{\n" + table(raw) + "}\n\n" +
+		"var biasData = [][]float64{\n" + table(bias) + "}\n"
+	err := os.WriteFile("bias.go", []byte(out), 0644)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -4,11 +4,28 @@ import (
 	"crypto/md5"
 	"encoding/binary"
 	"main/config"
+	"main/envelope"
+	"main/hashing"
 	"math"
 	"math/bits"
 	"os"
+	"sort"
 )
 
+//go:generate go run bias_gen.go
+
+// HyperLogLog++: dok je elemenata malo pamte se samo zauzeti registri sa
+// preciznoscu sparsePrecision (sparse mod), a kada ih ima previse prelazi se
+// na niz od m registara (dense mod). Sirova procena se koriguje empirijskim
+// odstupanjem iz bias.go.
+const sparsePrecision = 25
+
+// ispod ovih procena linear counting je precizniji od korigovane procene,
+// po preciznosti od 4 (Heule, Nunkesser, Hall 2013)
+var thresholds = []float64{
+	10, 20, 40, 80, 220, 400, 900, 1800, 3100, 6500, 11500, 20000, 50000, 120000, 350000,
+}
+
 func firstKbits(value, k uint64) uint64 {
 	return value >> (64 - k)
 }
@@ -18,30 +35,139 @@ func trailingZeroBits(value uint64) int {
 }
 
 type HLL struct {
-	m   uint64 //duzina niza
-	p   uint8  //preciznost
-	reg []uint8
+	m      uint64 //duzina niza
+	p      uint8  //preciznost
+	reg    []uint8
+	sparse map[uint32]uint8 // registar preciznosti sparsePrecision -> vrednost, nil u dense modu
+	legacy bool             // hll iz verzije 1, registri se racunaju na stari nacin
+	hash   byte             // hash funkcija iz paketa hashing, md5 za verzije 1 i 2
+	hashFn hashing.Hash128
 }
 
 func NewHyperLogLog(p uint8) *HLL {
 	hll := new(HLL)
 	hll.p = p
-	hll.m = uint64(math.Pow(float64(2), float64(hll.p)))
-	hll.reg = make([]uint8, hll.m)
+	hll.m = 1 << p
+	hll.sparse = make(map[uint32]uint8)
+	hll.hash = hashing.Default
+	hll.hashFn, _ = hashing.Get(hll.hash)
 	return hll
 }
 
 func (hll *HLL) AddElement(key string) {
-	keyConverted := []byte(key)
-	keyHash := Hash(keyConverted)
-	bucket := firstKbits(uint64(keyHash), uint64(hll.p))
-	value := uint8(trailingZeroBits(keyHash) + 1)
+	keyHash := hll.hashKey([]byte(key))
+	if hll.legacy {
+		bucket := firstKbits(uint64(keyHash), uint64(hll.p))
+		value := uint8(trailingZeroBits(keyHash) + 1)
+		if hll.reg[bucket] < value {
+			hll.reg[bucket] = value
+		}
+		return
+	}
+
+	value := rho(keyHash, hll.p)
+	if hll.sparse != nil {
+		index := uint32(firstKbits(keyHash, sparsePrecision))
+		if hll.sparse[index] < value {
+			hll.sparse[index] = value
+		}
+		// sparse zapis je tada veci od dense zapisa
+		if len(hll.sparse) > int(hll.m/4) {
+			hll.toDense()
+		}
+		return
+	}
+	bucket := firstKbits(keyHash, uint64(hll.p))
 	if hll.reg[bucket] < value {
 		hll.reg[bucket] = value
 	}
 }
 
+func (hll *HLL) hashKey(key []byte) uint64 {
+	if hll.hash == hashing.MD5 {
+		return Hash(key)
+	}
+	keyHash, _ := hll.hashFn(key, 0)
+	return keyHash
+}
+
+// broj vodecih nula posle prvih p bita, plus jedan
+func rho(hash uint64, p uint8) uint8 {
+	return uint8(bits.LeadingZeros64(hash<<p|1<<(p-1)) + 1)
+}
+
+func (hll *HLL) toDense() {
+	hll.reg = make([]uint8, hll.m)
+	for index, value := range hll.sparse {
+		bucket := index >> (sparsePrecision - hll.p)
+		if hll.reg[bucket] < value {
+			hll.reg[bucket] = value
+		}
+	}
+	hll.sparse = nil
+}
+
 func (hll *HLL) Estimate() float64 {
+	if hll.legacy {
+		return hll.classicEstimate()
+	}
+	if hll.sparse != nil {
+		return linearCounting(1<<sparsePrecision, 1<<sparsePrecision-len(hll.sparse))
+	}
+
+	sum := 0.0
+	for _, val := range hll.reg {
+		sum += math.Ldexp(1, -int(val))
+	}
+	m := float64(hll.m)
+	estimation := alpha(m) * m * m / sum
+	if estimation <= 5*m {
+		estimation -= hll.bias(estimation)
+	}
+
+	emptyRegs := hll.emptyCount()
+	if emptyRegs > 0 {
+		counted := linearCounting(hll.m, emptyRegs)
+		if counted <= thresholds[hll.p-config.HLL_MIN_PRECISION] {
+			return counted
+		}
+	}
+	return estimation
+}
+
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/m)
+}
+
+func linearCounting(m uint64, emptyRegs int) float64 {
+	return float64(m) * math.Log(float64(m)/float64(emptyRegs))
+}
+
+// odstupanje sirove procene, linearno izmedju najblizih tacaka iz bias.go
+func (hll *HLL) bias(estimation float64) float64 {
+	raw := rawEstimateData[hll.p-config.HLL_MIN_PRECISION]
+	bias := biasData[hll.p-config.HLL_MIN_PRECISION]
+	i := sort.SearchFloat64s(raw, estimation)
+	if i == 0 {
+		return bias[0]
+	}
+	if i == len(raw) {
+		return bias[len(bias)-1]
+	}
+	t := (estimation - raw[i-1]) / (raw[i] - raw[i-1])
+	return bias[i-1] + t*(bias[i]-bias[i-1])
+}
+
+// procena iz verzije 1
+func (hll *HLL) classicEstimate() float64 {
 	sum := 0.0
 	for _, val := range hll.reg {
 		sum += math.Pow(math.Pow(2.0, float64(val)), -1)
@@ -60,6 +186,40 @@ func (hll *HLL) Estimate() float64 {
 	return estimation
 }
 
+// spaja other u hll, registar je veci od dva registra. Preciznosti moraju biti iste.
+func (hll *HLL) Merge(other *HLL) error {
+	if hll.p != other.p || hll.legacy != other.legacy || hll.hash != other.hash {
+		return envelope.ErrIncompatible
+	}
+	if hll.sparse != nil && other.sparse != nil {
+		for index, value := range other.sparse {
+			if hll.sparse[index] < value {
+				hll.sparse[index] = value
+			}
+		}
+		if len(hll.sparse) > int(hll.m/4) {
+			hll.toDense()
+		}
+		return nil
+	}
+
+	if hll.sparse != nil {
+		hll.toDense()
+	}
+	otherReg := other.reg
+	if other.sparse != nil {
+		otherDense := *other
+		otherDense.toDense()
+		otherReg = otherDense.reg
+	}
+	for i, val := range otherReg {
+		if hll.reg[i] < val {
+			hll.reg[i] = val
+		}
+	}
+	return nil
+}
+
 func (hll *HLL) emptyCount() int {
 	sum := 0
 	for _, val := range hll.reg {
@@ -70,7 +230,44 @@ func (hll *HLL) emptyCount() int {
 	return sum
 }
 
+// verzija formata koji ToBytes upisuje, verzija 1 je hll bez sparse moda, a
+// verzija 2 nema oznaku hash funkcije i koristi md5
+const (
+	legacyVersion = 1
+	md5Version    = 2
+	formatVersion = 3
+)
+
+// p (1B) | hash funkcija (1B) | sparse (1B) | registri (m bajtova), ili u sparse
+// modu broj zauzetih registara pa za svaki razlika indeksa od prethodnog i vrednost
 func (hll *HLL) ToBytes() []byte {
+	if hll.legacy {
+		return envelope.Wrap(envelope.HLL, legacyVersion, hll.legacyBytes())
+	}
+
+	buffer := []byte{hll.p, hll.hash, 0}
+	if hll.sparse == nil {
+		buffer = append(buffer, hll.reg...)
+		return envelope.Wrap(envelope.HLL, formatVersion, buffer)
+	}
+
+	buffer[2] = 1
+	indexes := make([]uint32, 0, len(hll.sparse))
+	for index := range hll.sparse {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	buffer = binary.AppendUvarint(buffer, uint64(len(indexes)))
+	previous := uint32(0)
+	for _, index := range indexes {
+		buffer = binary.AppendUvarint(buffer, uint64(index-previous))
+		buffer = append(buffer, hll.sparse[index])
+		previous = index
+	}
+	return envelope.Wrap(envelope.HLL, formatVersion, buffer)
+}
+
+func (hll *HLL) legacyBytes() []byte {
 	bufferSize := 9 + len(hll.reg)
 	buffer := make([]byte, bufferSize)
 	binary.BigEndian.PutUint64(buffer[0:8], hll.m)
@@ -97,58 +294,116 @@ func (hll *HLL) WriteToBinFile() error {
 }
 
 func (hll *HLL) LoadHLL() error {
-	f, err := os.OpenFile(config.HLL_FILE_PATH, os.O_RDONLY, 0644)
+	data, err := os.ReadFile(config.HLL_FILE_PATH)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	stat, err := f.Stat()
+	loaded, err := LoadHLL(data)
 	if err != nil {
 		return err
 	}
+	*hll = *loaded
+	return nil
+}
 
-	data := make([]byte, stat.Size())
-	_, err = f.Read(data)
+// cita hll upisan sa ToBytes, ili bez zaglavlja iz starijih verzija
+func LoadHLL(data []byte) (*HLL, error) {
+	if !envelope.IsWrapped(data) {
+		return loadLegacy(data)
+	}
+	version, payload, err := envelope.Unwrap(envelope.HLL, data)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	hll.m = binary.BigEndian.Uint64(data[0:8])
-
-	for _, b := range data[8:9] {
-		hll.p = (hll.p << 1) | uint8(b) // posto ne postoji binary.BigEndian.Uint8, shift-ovali smo vrednost za 1 bajt u levo
+	hashID := hashing.MD5
+	switch version {
+	case legacyVersion:
+		return loadLegacy(payload)
+	case md5Version:
+	case formatVersion:
+		// oznaka hash funkcije je posle preciznosti
+		if len(payload) < 3 {
+			return nil, envelope.ErrMalformed
+		}
+		hashID = payload[1]
+		payload = append([]byte{payload[0]}, payload[2:]...)
+	default:
+		return nil, envelope.UnsupportedVersion(version)
 	}
 
-	offSet := 9
-	hll.reg = make([]uint8, hll.m)
-	for i := 0; i < int(hll.m); i++ {
-		for _, b := range data[offSet : offSet+1] {
-			hll.reg[i] = (hll.reg[i] << 1) | uint8(b) // posto ne postoji binary.BigEndian.Uint8, shift-ovali smo vrednost za 1 bajt u levo i dodelili vrednost niza
+	if len(payload) < 2 {
+		return nil, envelope.ErrMalformed
+	}
+	p := payload[0]
+	if p < config.HLL_MIN_PRECISION || p > config.HLL_MAX_PRECISION {
+		return nil, envelope.ErrMalformed
+	}
+	hll := &HLL{p: p, m: 1 << p, hash: hashID}
+	if hashID != hashing.MD5 {
+		var found bool
+		hll.hashFn, found = hashing.Get(hashID)
+		if !found {
+			return nil, envelope.UnknownHash(hashID)
 		}
-		offSet += 1
+	}
+	maxValue := uint8(64 - p + 1)
+	if payload[1] == 0 {
+		if uint64(len(payload)) != 2+hll.m {
+			return nil, envelope.ErrMalformed
+		}
+		hll.reg = append([]uint8(nil), payload[2:]...)
+		for _, val := range hll.reg {
+			if val > maxValue {
+				return nil, envelope.ErrMalformed
+			}
+		}
+		return hll, nil
 	}
 
-	return nil
+	data = payload[2:]
+	count, n := binary.Uvarint(data)
+	// svaki zauzet registar zauzima bar dva bajta
+	if n <= 0 || count > uint64(len(data))/2 {
+		return nil, envelope.ErrMalformed
+	}
+	data = data[n:]
+	hll.sparse = make(map[uint32]uint8, count)
+	index := uint64(0)
+	for i := uint64(0); i < count; i++ {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 || n >= len(data) {
+			return nil, envelope.ErrMalformed
+		}
+		index += delta
+		value := data[n]
+		if index >= 1<<sparsePrecision || value == 0 || value > maxValue {
+			return nil, envelope.ErrMalformed
+		}
+		hll.sparse[uint32(index)] = value
+		data = data[n+1:]
+	}
+	if len(data) != 0 {
+		return nil, envelope.ErrMalformed
+	}
+	return hll, nil
 }
 
-func LoadHLL(data []byte) *HLL {
+// hll iz verzije 1: m (8B) | p (1B) | registri
+func loadLegacy(data []byte) (*HLL, error) {
+	if len(data) < 9 {
+		return nil, envelope.ErrMalformed
+	}
 	hll := new(HLL)
+	hll.legacy = true
 	hll.m = binary.BigEndian.Uint64(data[0:8])
-
-	for _, b := range data[8:9] {
-		hll.p = (hll.p << 1) | uint8(b) // posto ne postoji binary.BigEndian.Uint8, shift-ovali smo vrednost za 1 bajt u levo
+	hll.p = data[8]
+	if hll.p > 32 || hll.m != 1<<hll.p || uint64(len(data)) != 9+hll.m {
+		return nil, envelope.ErrMalformed
 	}
 
-	offSet := 9
 	hll.reg = make([]uint8, hll.m)
-	for i := 0; i < int(hll.m); i++ {
-		for _, b := range data[offSet : offSet+1] {
-			hll.reg[i] = (hll.reg[i] << 1) | uint8(b) // posto ne postoji binary.BigEndian.Uint8, shift-ovali smo vrednost za 1 bajt u levo i dodelili vrednost niza
-		}
-		offSet += 1
-	}
-	return hll
+	copy(hll.reg, data[9:])
+	return hll, nil
 }
 
 func Hash(data []byte) uint64 {
@@ -0,0 +1,139 @@
+package hll
+
+import (
+	"errors"
+	"fmt"
+	"main/config"
+	"main/envelope"
+	"math"
+	"testing"
+)
+
+func newFilled(p uint8, from, to int) *HLL {
+	hll := NewHyperLogLog(p)
+	for i := from; i < to; i++ {
+		hll.AddElement(fmt.Sprint("element", i))
+	}
+	return hll
+}
+
+// relativna greska do tri standardne greske 1.04/sqrt(m)
+func expectEstimate(t *testing.T, hll *HLL, want int) {
+	t.Helper()
+	estimate := hll.Estimate()
+	allowed := 3 * 1.04 / math.Sqrt(float64(hll.m)) * float64(want)
+	if math.Abs(estimate-float64(want)) > math.Max(allowed, 1) {
+		t.Fatalf("p=%d: estimate of %d elements = %v", hll.p, want, estimate)
+	}
+}
+
+func TestEstimateAcrossCardinalities(t *testing.T) {
+	for _, p := range []uint8{config.HLL_MIN_PRECISION, 10, 14} {
+		for _, n := range []int{0, 5, 100, 1000, 20000, 200000} {
+			expectEstimate(t, newFilled(p, 0, n), n)
+		}
+	}
+}
+
+// dok je elemenata malo procena iz sparse moda je skoro tacna, i kada je
+// preciznost mala
+func TestSparseModeIsExactForSmallSets(t *testing.T) {
+	hll := newFilled(config.HLL_MIN_PRECISION, 0, 3)
+	if hll.sparse == nil {
+		t.Fatal("three elements switched to dense mode")
+	}
+	if estimate := hll.Estimate(); math.Round(estimate) != 3 {
+		t.Fatalf("estimate of 3 elements = %v", estimate)
+	}
+	hll = newFilled(config.HLL_MIN_PRECISION, 0, 100)
+	if hll.sparse != nil {
+		t.Fatal("100 elements in 16 registers stayed in sparse mode")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 10, 50000} {
+		hll := newFilled(14, 0, n)
+		loaded, err := LoadHLL(hll.ToBytes())
+		if err != nil {
+			t.Fatalf("%d elements: %v", n, err)
+		}
+		if (loaded.sparse == nil) != (hll.sparse == nil) {
+			t.Fatalf("%d elements: sparse mode was not kept", n)
+		}
+		if loaded.Estimate() != hll.Estimate() {
+			t.Fatalf("%d elements: estimate %v after loading, want %v", n, loaded.Estimate(), hll.Estimate())
+		}
+		// ucitan hll se dalje puni kao i originalni
+		loaded.AddElement("new")
+		hll.AddElement("new")
+		if loaded.Estimate() != hll.Estimate() {
+			t.Fatalf("%d elements: loaded hll diverged after an add", n)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	sparse := newFilled(14, 0, 100)
+	dense := newFilled(14, 50, 30000)
+	err := sparse.Merge(dense)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectEstimate(t, sparse, 30000)
+
+	left, right := newFilled(12, 0, 60), newFilled(12, 30, 90)
+	err = left.Merge(right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectEstimate(t, left, 90)
+
+	err = left.Merge(NewHyperLogLog(10))
+	if !errors.Is(err, envelope.ErrIncompatible) {
+		t.Fatalf("merge of different precisions: %v, want ErrIncompatible", err)
+	}
+}
+
+// hll iz verzije 1 se i dalje cita i procenjuje na stari nacin
+func TestLegacyVersion(t *testing.T) {
+	legacy := &HLL{p: 10, m: 1 << 10, legacy: true, reg: make([]uint8, 1<<10)}
+	for i := 0; i < 1000; i++ {
+		legacy.AddElement(fmt.Sprint("element", i))
+	}
+	loaded, err := LoadHLL(legacy.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.legacy || loaded.Estimate() != legacy.Estimate() {
+		t.Fatalf("legacy hll loaded as %+v", loaded)
+	}
+	loaded, err = LoadHLL(legacy.legacyBytes())
+	if err != nil || !loaded.legacy {
+		t.Fatalf("legacy bytes without a header: %v", err)
+	}
+	if err = loaded.Merge(NewHyperLogLog(10)); !errors.Is(err, envelope.ErrIncompatible) {
+		t.Fatalf("merge of a legacy and a new hll: %v, want ErrIncompatible", err)
+	}
+}
+
+func TestLoadRejectsMalformed(t *testing.T) {
+	valid := newFilled(10, 0, 10000).ToBytes()
+	_, payload, err := envelope.Unwrap(envelope.HLL, valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badPrecision := append([]byte{config.HLL_MAX_PRECISION + 1}, payload[1:]...)
+	badHash := append([]byte{payload[0], 200}, payload[2:]...)
+	tests := map[string][]byte{
+		"precision":     envelope.Wrap(envelope.HLL, formatVersion, badPrecision),
+		"truncated":     envelope.Wrap(envelope.HLL, formatVersion, payload[:len(payload)-1]),
+		"version":       envelope.Wrap(envelope.HLL, 99, payload),
+		"hash function": envelope.Wrap(envelope.HLL, formatVersion, badHash),
+	}
+	for name, data := range tests {
+		if _, err := LoadHLL(data); !errors.Is(err, envelope.ErrMalformed) {
+			t.Fatalf("%s: LoadHLL returned %v, want ErrMalformed", name, err)
+		}
+	}
+}
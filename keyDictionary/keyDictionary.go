@@ -0,0 +1,159 @@
+package keydictionary
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// Recnik kljuceva za kompresiju, svaki kljuc dobija redni broj (id)
+// koji se u zapisima cuva kao varint umesto celog kljuca.
+// Na disku je append-only log ulaza: id (uvarint) | duzina kljuca (uvarint) | kljuc
+type KeyDictionary struct {
+	keys []string          // id -> kljuc
+	ids  map[string]uint64 // kljuc -> id
+	file *os.File
+}
+
+// pravi prazan recnik koji se upisuje u novi fajl, postojeci fajl se brise
+func NewKeyDictionary(path string) (*KeyDictionary, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyDictionary{ids: make(map[string]uint64), file: file}, nil
+}
+
+// ucitava recnik samo za citanje
+func ReadKeyDictionary(path string) (*KeyDictionary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	kd := &KeyDictionary{ids: make(map[string]uint64)}
+
+	// stari globalni recnik je bio json mapa
+	if len(data) > 0 && data[0] == '{' {
+		return kd, kd.parseJSON(data)
+	}
+
+	kd.parse(data)
+	return kd, nil
+}
+
+// ucitava ulaze, nepotpun ulaz na kraju fajla se odbacuje
+func (kd *KeyDictionary) parse(data []byte) {
+	offset := 0
+	for offset < len(data) {
+		id, n := binary.Uvarint(data[offset:])
+		if n <= 0 {
+			break
+		}
+		keySize, m := binary.Uvarint(data[offset+n:])
+		if m <= 0 || uint64(len(data)-offset-n-m) < keySize {
+			break
+		}
+		start := offset + n + m
+		key := string(data[start : start+int(keySize)])
+		if id != uint64(len(kd.keys)) {
+			break
+		}
+
+		kd.keys = append(kd.keys, key)
+		kd.ids[key] = id
+		offset = start + int(keySize)
+	}
+}
+
+func (kd *KeyDictionary) parseJSON(data []byte) error {
+	var old map[int]string
+	err := json.Unmarshal(data, &old)
+	if err != nil {
+		return err
+	}
+
+	kd.keys = make([]string, len(old))
+	for id, key := range old {
+		if id < 0 || id >= len(old) {
+			return errors.New("invalid key dictionary")
+		}
+		kd.keys[id] = key
+		kd.ids[key] = uint64(id)
+	}
+	return nil
+}
+
+func appendEntry(data []byte, id uint64, key string) []byte {
+	data = binary.AppendUvarint(data, id)
+	data = binary.AppendUvarint(data, uint64(len(key)))
+	return append(data, key...)
+}
+
+// vraca id kljuca, novi kljuc se dopisuje u fajl recnika
+func (kd *KeyDictionary) Add(key string) (uint64, error) {
+	if id, found := kd.ids[key]; found {
+		return id, nil
+	}
+	if kd.file == nil {
+		return 0, errors.New("key dictionary is read only")
+	}
+
+	id := uint64(len(kd.keys))
+	_, err := kd.file.Write(appendEntry(nil, id, key))
+	if err != nil {
+		return 0, err
+	}
+
+	kd.keys = append(kd.keys, key)
+	kd.ids[key] = id
+	return id, nil
+}
+
+func (kd *KeyDictionary) ID(key string) (uint64, bool) {
+	id, found := kd.ids[key]
+	return id, found
+}
+
+func (kd *KeyDictionary) Key(id uint64) (string, bool) {
+	if id >= uint64(len(kd.keys)) {
+		return "", false
+	}
+	return kd.keys[id], true
+}
+
+// id kljuca kodiran kao varint, kljuc koji ne postoji se dodaje
+func (kd *KeyDictionary) EncodeKey(key string) ([]byte, error) {
+	id, err := kd.Add(key)
+	if err != nil {
+		return nil, err
+	}
+	return binary.AppendUvarint(nil, id), nil
+}
+
+func (kd *KeyDictionary) DecodeKey(idBytes []byte) string {
+	id, n := binary.Uvarint(idBytes)
+	if n <= 0 {
+		return ""
+	}
+	key, _ := kd.Key(id)
+	return key
+}
+
+func (kd *KeyDictionary) Len() int {
+	return len(kd.keys)
+}
+
+// recnik mora biti na disku pre nego sto se tabela koja ga koristi procita
+func (kd *KeyDictionary) Close() error {
+	if kd.file == nil {
+		return nil
+	}
+	err := kd.file.Sync()
+	if err != nil {
+		kd.file.Close()
+		return err
+	}
+	return kd.file.Close()
+}
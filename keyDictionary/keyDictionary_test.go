@@ -0,0 +1,87 @@
+package keydictionary
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDictionarySurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictionary.db")
+	kd, err := NewKeyDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"alpha", "beta", "", "gamma"}
+	for i, key := range keys {
+		encoded, err := kd.EncodeKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kd.DecodeKey(encoded) != key {
+			t.Fatalf("DecodeKey(EncodeKey(%q)) = %q", key, kd.DecodeKey(encoded))
+		}
+		// kljuc koji vec postoji zadrzava svoj id
+		id, err := kd.Add(key)
+		if err != nil || id != uint64(i) {
+			t.Fatalf("Add(%q) = %d, %v, want %d", key, id, err, i)
+		}
+	}
+	err = kd.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadKeyDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Len() != len(keys) {
+		t.Fatalf("reopened dictionary has %d keys, want %d", read.Len(), len(keys))
+	}
+	for i, key := range keys {
+		if got, found := read.Key(uint64(i)); !found || got != key {
+			t.Fatalf("Key(%d) = %q, %v, want %q", i, got, found, key)
+		}
+	}
+	if _, err = read.Add("delta"); err == nil {
+		t.Fatal("Add to a read only dictionary succeeded")
+	}
+}
+
+func TestTornEntryIsDropped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictionary.db")
+	data := appendEntry(nil, 0, "alpha")
+	data = appendEntry(data, 1, "beta")
+	torn := appendEntry(nil, 2, "gamma")
+	err := os.WriteFile(path, append(data, torn[:len(torn)-2]...), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kd, err := ReadKeyDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kd.Len() != 2 {
+		t.Fatalf("dictionary has %d keys, want the 2 complete entries", kd.Len())
+	}
+	if _, found := kd.ID("gamma"); found {
+		t.Fatal("key of a torn entry was loaded")
+	}
+}
+
+func TestReadJSONDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictionary.json")
+	err := os.WriteFile(path, []byte(`{"0":"alpha","1":"beta"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kd, err := ReadKeyDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, found := kd.ID("beta"); !found || id != 1 {
+		t.Fatalf("ID(beta) = %d, %v, want 1", id, found)
+	}
+}
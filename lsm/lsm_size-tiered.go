@@ -3,99 +3,142 @@ package lsm
 import (
 	"fmt"
 	"main/config"
+	mergeoperator "main/mergeOperator"
 	"main/record"
 	"main/sstable"
+	valuelog "main/valueLog"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
-func Compact(cfg *config.Config, keyDictionary *map[int]string) bool {
-	SSTablesLvl1 := findSSTable("1")
-	if len(SSTablesLvl1) < 2 {
-		return false
+// operatori spajaju operande sa starijim verzijama kljuca, a vrednosti iz
+// value loga se citaju preko values. Vraca da li je pokrenuta kompakcija.
+func Compact(cfg *config.Config, operators *mergeoperator.Registry, values *valuelog.ValueLog) (bool, error) {
+	directory := cfg.SSTableDirectory()
+	SSTablesLvl1, err := findSSTable(directory, "1")
+	if err != nil || len(SSTablesLvl1) < 2 {
+		return false, err
 	}
 	if cfg.CompactBy == "byte" {
-		byteSizeOfCurrentLevelSSTables, _ := calculateSizeOfSSTables(SSTablesLvl1)
+		byteSizeOfCurrentLevelSSTables, err := calculateSizeOfSSTables(directory, SSTablesLvl1)
+		if err != nil {
+			return false, err
+		}
 		if byteSizeOfCurrentLevelSSTables >= cfg.MaxBytesSSTables {
 			if cfg.CompactType == "size_tiered" {
-				SizeTiered(cfg, keyDictionary)
-				return true
+				return true, SizeTiered(cfg, operators, values)
 			} else if cfg.CompactType == "level" {
-				Level(cfg)
-				return true
+				return true, Level(cfg)
 			}
 		}
 	} else if cfg.CompactBy == "amount" {
 		if len(SSTablesLvl1) >= cfg.MaxTabels {
 			if cfg.CompactType == "size_tiered" {
-				SizeTiered(cfg, keyDictionary)
-				return true
+				return true, SizeTiered(cfg, operators, values)
 			} else if cfg.CompactType == "level" {
-				Level(cfg)
-				return true
+				return true, Level(cfg)
 			}
 		}
 	}
-	return false
+	return false, nil
 }
 
-func Level(cfg *config.Config) {
-	var currentLevelSSTables []string
+func Level(cfg *config.Config) error {
+	directory := cfg.SSTableDirectory()
 	for level := 1; level < cfg.NumberOfLevels; level++ {
-		currentLevelSSTables = findSSTable(strconv.Itoa(level))
+		currentLevelSSTables, err := findSSTable(directory, strconv.Itoa(level))
+		if err != nil {
+			return err
+		}
 		if len(currentLevelSSTables) < 2 { // ne radimo kompakciju za manje od 2 sstabele
-			return
+			return nil
 		}
-		path := config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(level+1) + "_sstable_data_" + strconv.Itoa(cfg.NumberOfSSTables-len(currentLevelSSTables)+1) + ".db"
+		path := directory + "lvl_" + strconv.Itoa(level+1) + "_sstable_data_" + strconv.Itoa(cfg.NumberOfSSTables-len(currentLevelSSTables)+1) + ".db"
 		if cfg.CompactBy == "byte" {
-			byteSizeOfCurrentLevelSSTables, _ := calculateSizeOfSSTables(currentLevelSSTables)
+			byteSizeOfCurrentLevelSSTables, err := calculateSizeOfSSTables(directory, currentLevelSSTables)
+			if err != nil {
+				return err
+			}
 			if byteSizeOfCurrentLevelSSTables >= cfg.MaxBytesSSTables {
 				LeveledMergeSSTables(currentLevelSSTables, path)
 			} else {
-				return // nema uslova za kompakciju
+				return nil // nema uslova za kompakciju
 			}
 		} else if cfg.CompactBy == "amount" {
 			if len(currentLevelSSTables) >= cfg.MaxTabels {
 				LeveledMergeSSTables(currentLevelSSTables, path)
 			} else {
-				return // nema uslova za kompakciju
+				return nil // nema uslova za kompakciju
 			}
 		}
 	}
+	return nil
 }
 
-func SizeTiered(cfg *config.Config, keyDictionary *map[int]string) {
-	var currentLevelSSTables []string
+func SizeTiered(cfg *config.Config, operators *mergeoperator.Registry, values *valuelog.ValueLog) error {
+	directory := cfg.SSTableDirectory()
 	// prolazak kroz nivoe sstabela
 	for level := 1; level < cfg.NumberOfLevels; level++ {
-		currentLevelSSTables = findSSTable(strconv.Itoa(level))
+		currentLevelSSTables, err := findSSTable(directory, strconv.Itoa(level))
+		if err != nil {
+			return err
+		}
 		if len(currentLevelSSTables) < 2 {
-			return
+			return nil
 		}
-		var recordCounter int
-		path := config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(level+1) + "_sstable_data_" + strconv.Itoa(cfg.NumberOfSSTables-len(currentLevelSSTables)+1) + ".db"
+		// tombstone smemo da izbacimo samo ako ispod nema starijih verzija zapisa
+		deeper, err := deeperLevelsExist(directory, level+1, cfg.NumberOfLevels)
+		if err != nil {
+			return err
+		}
+		dropTombstones := !deeper
+		tableNumber := cfg.NumberOfSSTables - len(currentLevelSSTables) + 1
 		if cfg.CompactBy == "byte" {
-			byteSizeOfCurrentLevelSSTables, _ := calculateSizeOfSSTables(currentLevelSSTables)
-			if byteSizeOfCurrentLevelSSTables >= cfg.MaxBytesSSTables*level {
-				_, recordCounter = SizeTieredMergeSSTables(currentLevelSSTables, path, keyDictionary)
-			} else {
-				return // nema uslova za kompakciju
+			byteSizeOfCurrentLevelSSTables, err := calculateSizeOfSSTables(directory, currentLevelSSTables)
+			if err != nil {
+				return err
+			}
+			if byteSizeOfCurrentLevelSSTables < cfg.MaxBytesSSTables*level {
+				return nil // nema uslova za kompakciju
 			}
 		} else if cfg.CompactBy == "amount" {
-			if len(currentLevelSSTables) >= cfg.MaxTabels*level {
-				_, recordCounter = SizeTieredMergeSSTables(currentLevelSSTables, path, keyDictionary)
-			} else {
-				return // nema uslova za kompakciju
+			if len(currentLevelSSTables) < cfg.MaxTabels*level {
+				return nil // nema uslova za kompakciju
 			}
 		}
 
-		deleteOldTables(currentLevelSSTables, level)
-		cfg.NumberOfSSTables -= len(currentLevelSSTables) - 1
-		cfg.WriteConfig()
-		sstable.WriteDataIndexSummaryLSM(path, level+1, *cfg, keyDictionary, recordCounter)
+		writer, err := sstable.NewWriter(cfg, level+1, tableNumber)
+		if err != nil {
+			return err
+		}
+		err = SizeTieredMergeSSTables(directory, currentLevelSSTables, writer, dropTombstones, operators, values)
+		if err != nil {
+			// stare tabele ostaju, da se ne bi izgubili zapisi
+			writer.Abort()
+			return err
+		}
+		empty := writer.Empty()
+		err = writer.Close()
+		if err != nil {
+			return err
+		}
+
+		deleteOldTables(directory, currentLevelSSTables, level)
+		// ako su svi zapisi bili obrisani nova tabela nije napravljena
+		if !empty {
+			cfg.NumberOfSSTables -= len(currentLevelSSTables) - 1
+		} else {
+			cfg.NumberOfSSTables -= len(currentLevelSSTables)
+		}
+		err = cfg.WriteConfig()
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func LeveledMergeSSTables(SSTables []string, filepath string) bool {
@@ -103,10 +146,10 @@ func LeveledMergeSSTables(SSTables []string, filepath string) bool {
 }
 
 // vraca velicinu svih sstabeli na nekom nivou
-func calculateSizeOfSSTables(SSTables []string) (int, error) {
+func calculateSizeOfSSTables(directory string, SSTables []string) (int, error) {
 	totalSize := int64(0)
 	for i := 0; i < len(SSTables); i++ {
-		fileInfo, err := os.Stat(config.SSTABLE_DIRECTORY + SSTables[i])
+		fileInfo, err := os.Stat(directory + SSTables[i])
 		if err != nil {
 			return 0, err
 		}
@@ -116,25 +159,41 @@ func calculateSizeOfSSTables(SSTables []string) (int, error) {
 	return int(totalSize), nil
 }
 
-func deleteOldTables(oldSSTables []string, level int) {
+func deleteOldTables(directory string, oldSSTables []string, level int) {
 	for i := 0; i < len(oldSSTables); i++ {
 		sstableIndex := strings.Split(strings.Split(oldSSTables[i], "_")[4], ".")[0]
-		prefix := config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(level)
+		prefix := directory + "lvl_" + strconv.Itoa(level)
 		os.Remove(prefix + "_sstable_data_" + sstableIndex + ".db")
 		os.Remove(prefix + "_sstable_filter_" + sstableIndex + ".bin")
 		os.Remove(prefix + "_sstable_index_" + sstableIndex + ".db")
 		os.Remove(prefix + "_sstable_summary_" + sstableIndex + ".db")
 		os.Remove(prefix + "_sstable_metadata_" + sstableIndex + ".bin")
+		os.Remove(prefix + "_sstable_dictionary_" + sstableIndex + ".db")
+		os.Remove(prefix + "_sstable_rangedel_" + sstableIndex + ".db")
+		sstable.InvalidateBlockCache(prefix + "_sstable_data_" + sstableIndex + ".db")
+		sstable.InvalidateBlockCache(prefix + "_sstable_index_" + sstableIndex + ".db")
+		sstable.InvalidateBlockCache(prefix + "_sstable_summary_" + sstableIndex + ".db")
+		sstable.InvalidateBlockCache(prefix + "_sstable_filter_" + sstableIndex + ".bin")
+		sstable.InvalidateBlockCache(prefix + "_sstable_rangedel_" + sstableIndex + ".db")
 	}
 }
 
-func findSSTable(level string) []string {
+func deeperLevelsExist(directory string, fromLevel, numberOfLevels int) (bool, error) {
+	for level := fromLevel; level <= numberOfLevels; level++ {
+		tables, err := findSSTable(directory, strconv.Itoa(level))
+		if err != nil || len(tables) > 0 {
+			return len(tables) > 0, err
+		}
+	}
+	return false, nil
+}
+
+func findSSTable(directory, level string) ([]string, error) {
 	var currentLevelSSTables []string
 
-	files, err := os.ReadDir(config.SSTABLE_DIRECTORY)
+	files, err := os.ReadDir(directory)
 	if err != nil {
-		fmt.Println("Error reading directory:", err)
-		return nil
+		return nil, err
 	}
 
 	for _, file := range files {
@@ -146,90 +205,191 @@ func findSSTable(level string) []string {
 		}
 	}
 
-	return currentLevelSSTables
+	return currentLevelSSTables, nil
 }
 
-func SizeTieredMergeSSTables(SSTables []string, filepath string, keyDictionary *map[int]string) (bool, int) {
-	SSTableFiles := []*os.File{}
-	recordCounter := 0
-	dataFile, err := os.OpenFile(filepath, os.O_CREATE|os.O_APPEND, 0644)
-	if err != nil {
-		return false, -1
-	}
-	defer dataFile.Close()
+// spaja tabele u writer, na gresku stare tabele moraju ostati
+func SizeTieredMergeSSTables(directory string, SSTables []string, writer *sstable.Writer, dropTombstones bool, operators *mergeoperator.Registry, values *valuelog.ValueLog) error {
+	// tabele sortiramo od najnovije, da bi pri istoj verziji prednost imao noviji zapis
+	sort.Slice(SSTables, func(i, j int) bool {
+		return sstableNumber(SSTables[i]) > sstableNumber(SSTables[j])
+	})
 
-	// ako dodje do situacije da se jedna sstablea skroz isprazni, onda njen indeks samo brisem is SSTables
-	// ucitavam sve pokazivace na fajlove trenutnih sstabela
+	// ako dodje do situacije da se jedna sstablea skroz isprazni, onda je samo izbacujem iz inputs
+	var inputs []*sstable.Iterator
+	// rang tabele je njen indeks u sortiranom nizu, manji rang je novija tabela
+	rank := make(map[*sstable.Iterator]int)
+	rangeTombstones := make([][]record.Record, len(SSTables))
 	for i := 0; i < len(SSTables); i++ {
-		file, _ := os.Open(config.SSTABLE_DIRECTORY + SSTables[i])
-		SSTableFiles = append(SSTableFiles, file)
+		level, _ := strconv.Atoi(strings.Split(SSTables[i], "_")[1])
+		it, err := sstable.NewIterator(directory, level, sstableNumber(SSTables[i]))
+		if err != nil {
+			closeInputs(inputs)
+			return err
+		}
+		inputs = append(inputs, it)
+		rank[it] = i
+		rangeTombstones[i] = it.RangeTombstones()
+	}
+	inputs, err := removeFinished(inputs)
+	if err != nil {
+		closeInputs(inputs)
+		return err
 	}
 
-	allRecords := record.LoadAllRecordsFromFiles(SSTableFiles, keyDictionary)
-
+	now := time.Now()
 	// loop dok postoje podaci
-	for len(SSTableFiles) > 0 {
-
-		// idemo kroz rekorde i nabavljamo one koji nisu obrisani
-		for i := 0; i < len(SSTableFiles); i++ {
-			for {
-				if allRecords[i].Tombstone {
-					rekord, err := record.LoadRecordFromFile(*SSTableFiles[i], keyDictionary)
-					if err != nil {
-						// edgecase kada prodjemo kroz sve rekorde iz jedne sstabele
-						allRecords, SSTableFiles = deleteFromArrays(allRecords, SSTableFiles, i)
-					} else {
-						allRecords[i] = rekord
-					}
-				} else {
-					break
-				}
+	for len(inputs) > 0 {
+		newest := inputs[findSuitableRecord(inputs)]
+		rec := *newest.Record()
+		covered := coveredByNewer(rangeTombstones[:rank[newest]], rec.Key)
+
+		// stariji zapisi sa istim kljucem iz ostalih tabela se preskacu,
+		// a pamte se zbog spajanja sa operandima
+		versions := make([]*record.Record, len(SSTables))
+		for _, input := range inputs {
+			if input.Record().Key == rec.Key {
+				version := *input.Record()
+				versions[rank[input]] = &version
+				input.Next()
 			}
 		}
+		inputs, err = removeFinished(inputs)
+		if err != nil {
+			closeInputs(inputs)
+			return err
+		}
 
-		rec := findSuitableRecord(allRecords)
-		index := findRecordIndex(allRecords, rec)
+		// istekao zapis ostaje kao tombstone, da se ne bi videla starija
+		// verzija sa dubljeg nivoa
+		if rec.Expired(now) {
+			rec.Tombstone = true
+			rec.Value = nil
+			rec.ValueSize = 0
+			rec.ValuePointer = false
+			rec.Expiry = 0
+		}
 
-		recordBytes := rec.ToBytesSSTable(keyDictionary)
+		// obrisani zapisi se ne prepisuju u novu tabelu, a zapis obuhvacen
+		// brisanjem opsega iz novije tabele je obrisan
+		if (rec.Tombstone && dropTombstones) || covered {
+			continue
+		}
 
-		_, err := dataFile.Write(recordBytes)
-		if err != nil {
-			fmt.Println("Error writing record to", filepath)
-			return false, -1
+		if rec.Merge {
+			merged, err := mergeVersions(rec, rank[newest], versions, rangeTombstones, dropTombstones, operators, values)
+			if err != nil {
+				closeInputs(inputs)
+				return err
+			}
+			rec = *merged
 		}
-		recordCounter++
 
-		rekord, err := record.LoadRecordFromFile(*SSTableFiles[index], keyDictionary)
+		err = writer.Add(rec)
 		if err != nil {
-			allRecords, SSTableFiles = deleteFromArrays(allRecords, SSTableFiles, index)
-		} else {
-			allRecords[index] = rekord
+			closeInputs(inputs)
+			return err
 		}
 	}
 
-	return true, recordCounter
+	// brisanja opsega i dalje pokrivaju zapise na dubljim nivoima
+	if !dropTombstones {
+		for _, tableRangeTombstones := range rangeTombstones {
+			for _, rangeTombstone := range tableRangeTombstones {
+				writer.AddRangeTombstone(rangeTombstone)
+			}
+		}
+	}
+	return nil
 }
 
-func findRecordIndex(allRecords []record.Record, target record.Record) int {
-	for i := 0; i < len(allRecords); i++ {
-		if record.IsSimilar(allRecords[i], target) {
-			return i
+// spaja operande najnovijeg zapisa (iz tabele ranga newestRank) sa starijim
+// verzijama kljuca. Ako starija vrednost nije u ulaznim tabelama, a ispod
+// postoje dublji nivoi, operandi se samo spajaju u jedan zapis spajanja.
+// Tabela ima jedan zapis po kljucu, pa kada operandi ne mogu da se spoje sa
+// vrednoscu kompakcija se prekida i stare tabele ostaju. Nastavlja se kada se
+// kljuc prepise ili se registruje njegov operator.
+func mergeVersions(newest record.Record, newestRank int, versions []*record.Record, rangeTombstones [][]record.Record, dropTombstones bool, operators *mergeoperator.Registry, values *valuelog.ValueLog) (*record.Record, error) {
+	merges := []record.Record{newest}
+	var base *record.Record
+	for rank := newestRank; rank < len(versions) && base == nil; rank++ {
+		if rank > newestRank && versions[rank] != nil {
+			if !versions[rank].Merge {
+				base = versions[rank]
+				break
+			}
+			merges = append(merges, *versions[rank])
+		}
+		// brisanje opsega tabele je starije od zapisa iz nje
+		if record.Covered(rangeTombstones[rank], newest.Key) {
+			base = &record.Record{Key: newest.Key, Tombstone: true}
 		}
 	}
-	return -1
+
+	if base == nil && !dropTombstones {
+		combined := mergeoperator.Combine(merges)
+		return &combined, nil
+	}
+	folded, err := operators.Fold(newest.Key, base, merges, values)
+	if err != nil {
+		return nil, fmt.Errorf("compacting %q: %w", newest.Key, err)
+	}
+	return folded, nil
 }
 
-func findSuitableRecord(allRecords []record.Record) record.Record {
-	sort.Slice(allRecords, func(i, j int) bool {
-		// sortiramo leksikografski
-		if allRecords[i].Key != allRecords[j].Key {
-			return allRecords[i].Key < allRecords[j].Key
+// da li kljuc brise neko od brisanja opsega novijih tabela
+func coveredByNewer(rangeTombstones [][]record.Record, key string) bool {
+	for _, tableRangeTombstones := range rangeTombstones {
+		if record.Covered(tableRangeTombstones, key) {
+			return true
 		}
-		// sortira po tajmstempu
-		return allRecords[i].Timestamp > allRecords[j].Timestamp
-	})
+	}
+	return false
+}
+
+// izbacuje tabele koje su procitane do kraja, tabela koja nije mogla
+// biti procitana do kraja prekida spajanje
+func removeFinished(inputs []*sstable.Iterator) ([]*sstable.Iterator, error) {
+	remaining := inputs[:0]
+	var err error
+	for _, input := range inputs {
+		if input.Valid() {
+			remaining = append(remaining, input)
+			continue
+		}
+		if input.Err() != nil && err == nil {
+			err = input.Err()
+		}
+		input.Close()
+	}
+	return remaining, err
+}
+
+func closeInputs(inputs []*sstable.Iterator) {
+	for _, input := range inputs {
+		input.Close()
+	}
+}
 
-	return allRecords[0]
+func sstableNumber(fileName string) int {
+	number, _ := strconv.Atoi(strings.Split(strings.Split(fileName, "_")[4], ".")[0])
+	return number
+}
+
+// vraca indeks zapisa sa najmanjim kljucem, a medju istim kljucevima najnovijeg
+func findSuitableRecord(inputs []*sstable.Iterator) int {
+	index := 0
+	for i := 1; i < len(inputs); i++ {
+		current := inputs[i].Record()
+		best := inputs[index].Record()
+		// poredimo leksikografski, pa po verziji, jer tajmstemp ne odredjuje
+		// redosled upisa u istoj sekundi
+		if current.Key < best.Key || (current.Key == best.Key && current.Version() > best.Version()) {
+			index = i
+		}
+	}
+
+	return index
 }
 
 func findSmallestRecordIndex(allRecords []record.Record) int {
@@ -258,13 +418,6 @@ func allRecordsHaveSameKey(records []record.Record) bool {
 	return true
 }
 
-func deleteFromArrays(allRecords []record.Record, allFiles []*os.File, index int) ([]record.Record, []*os.File) {
-	allFiles[index].Close()
-	allRecordsResult := append(allRecords[:index], allRecords[index+1:]...)
-	allFilesResult := append(allFiles[:index], allFiles[index+1:]...)
-	return allRecordsResult, allFilesResult
-}
-
 // func mergeTables(records1, records2 string, level int) []record.Record {
 // 	var result_records []record.Record
 
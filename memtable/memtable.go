@@ -14,6 +14,9 @@ type Memtable struct {
 	CurrentSize        int
 	SizeOfRecordsInWal int
 	config             config.Config
+	// brisanja opsega, pokrivaju samo zapise starijih izvora jer su
+	// zapisi ove memtabele iz opsega obrisani pri dodavanju
+	rangeTombstones []record.Record
 }
 
 func MemtableConstructor(config config.Config) *Memtable {
@@ -55,16 +58,22 @@ func (mt *Memtable) Search(key string) *record.Record {
 }
 
 func (mt *Memtable) Insert(record record.Record) bool {
+	return mt.insert(record, len(record.ToBytes()))
+}
+
+// walSize je broj bajtova koje je zapis zauzeo u WAL-u
+func (mt *Memtable) insert(record record.Record, walSize int) bool {
 	if mt.CurrentSize < mt.config.MaxSize {
 		if mt.config.MemtableStructure == "skiplist" {
-			_, found := mt.skiplist.Search(record.Key)
+			node, found := mt.skiplist.Search(record.Key)
 			if found {
-				mt.Update(record.Key, record.Value)
-				mt.SizeOfRecordsInWal += len(record.ToBytes())
+				// menjamo ceo zapis da bi se upisao i tombstone
+				*node.Record = record
+				mt.SizeOfRecordsInWal += walSize
 			} else {
 				mt.skiplist.Insert(record)
 				mt.CurrentSize += 1
-				mt.SizeOfRecordsInWal += len(record.ToBytes())
+				mt.SizeOfRecordsInWal += walSize
 			}
 		} else if mt.config.MemtableStructure == "btree" {
 			//it updated the value if the key already existed
@@ -73,7 +82,7 @@ func (mt *Memtable) Insert(record record.Record) bool {
 				mt.bTree.Insert(record.Key, record)
 				mt.CurrentSize += 1
 			}
-			mt.SizeOfRecordsInWal += len(record.ToBytes())
+			mt.SizeOfRecordsInWal += walSize
 		}
 	} else {
 		return false
@@ -81,6 +90,24 @@ func (mt *Memtable) Insert(record record.Record) bool {
 	return true
 }
 
+// dodaje operand spajanja, combine od zapisa kljuca u memtabeli (nil ako ga
+// nema) i operanda pravi zapis koji se cuva. U WAL-u je upisan samo operand.
+func (mt *Memtable) Merge(operand record.Record, combine func(existing *record.Record) (*record.Record, error)) (bool, error) {
+	if mt.CurrentSize >= mt.config.MaxSize {
+		return false, nil
+	}
+	existing := mt.Search(operand.Key)
+	if existing == nil {
+		// starije verzije je mozda obrisalo brisanje opsega iz ove memtabele
+		existing = record.RangeTombstoneFor(mt.rangeTombstones, operand.Key)
+	}
+	merged, err := combine(existing)
+	if err != nil {
+		return false, err
+	}
+	return mt.insert(*merged, len(operand.ToBytes())), nil
+}
+
 func (mt *Memtable) Update(key string, value []byte) {
 	if mt.config.MemtableStructure == "skiplist" {
 		node, found := mt.skiplist.Search(key)
@@ -108,15 +135,64 @@ func (mt *Memtable) Delete(record record.Record) {
 	mt.SizeOfRecordsInWal += len(record.ToBytes())
 }
 
-func (mt *Memtable) Flush() []record.Record {
+// dodaje brisanje opsega, zapisi iz opsega koji su vec u memtabeli postaju tombstone-ovi
+func (mt *Memtable) DeleteRange(rangeTombstone record.Record) {
+	var keys []string
+	it := mt.NewIterator()
+	for it.Seek(rangeTombstone.Key); it.Valid() && rangeTombstone.Covers(it.Record().Key); it.Next() {
+		keys = append(keys, it.Record().Key)
+	}
+	it.Close()
+
+	for _, key := range keys {
+		deleted := *mt.Search(key)
+		deleted.Tombstone = true
+		deleted.Value = nil
+		deleted.ValueSize = 0
+		deleted.ValuePointer = false
+		deleted.Merge = false
+		deleted.Timestamp = rangeTombstone.Timestamp
+		deleted.SeqNum = rangeTombstone.SeqNum
+		if mt.config.MemtableStructure == "skiplist" {
+			node, _ := mt.skiplist.Search(key)
+			*node.Record = deleted
+		} else if mt.config.MemtableStructure == "btree" {
+			mt.bTree.SearchForInsertion(key, deleted)
+		}
+	}
+
+	mt.rangeTombstones = append(mt.rangeTombstones, rangeTombstone)
+	mt.SizeOfRecordsInWal += len(rangeTombstone.ToBytes())
+}
+
+func (mt *Memtable) RangeTombstones() []record.Record {
+	return mt.rangeTombstones
+}
+
+// memtabela bez zapisa i bez brisanja opsega
+func (mt *Memtable) IsEmpty() bool {
+	return mt.CurrentSize == 0 && len(mt.rangeTombstones) == 0
+}
+
+// zapisi memtabele sortirani po kljucu, memtabela se ne menja
+func (mt *Memtable) Records() []record.Record {
 	var elements []record.Record
+	if mt.config.MemtableStructure == "skiplist" {
+		elements = mt.skiplist.GetRecords()
+	} else if mt.config.MemtableStructure == "btree" {
+		elements = mt.bTree.ValuesInOrderTraversal()
+	}
+	return elements
+}
+
+func (mt *Memtable) Flush() []record.Record {
+	elements := mt.Records()
 	mt.CurrentSize = 0
 	mt.SizeOfRecordsInWal = 0
+	mt.rangeTombstones = nil
 	if mt.config.MemtableStructure == "skiplist" {
-		elements = mt.skiplist.GetRecords()
 		mt.skiplist = skiplist.NewSkipList()
 	} else if mt.config.MemtableStructure == "btree" {
-		elements = mt.bTree.ValuesInOrderTraversal()
 		mt.bTree = btree.NewBTree()
 	}
 	return elements
@@ -0,0 +1,133 @@
+package memtable
+
+import (
+	"main/record"
+	"main/skiplist"
+	"sort"
+)
+
+// iterator kroz zapise jedne memtabele sortirane po kljucu
+type Iterator struct {
+	memtable        *Memtable
+	node            *skiplist.Node // trenutni cvor kada se skiplista cita direktno
+	snapshot        bool
+	records         []record.Record // snimak zapisa u trenutku pravljenja iteratora
+	rangeTombstones []record.Record
+	position        int
+}
+
+func (mt *Memtable) NewIterator() *Iterator {
+	if mt.config.MemtableStructure == "btree" {
+		return mt.NewSnapshotIterator()
+	}
+	it := &Iterator{memtable: mt}
+	it.SeekToFirst()
+	return it
+}
+
+// iterator nad snimkom zapisa, memtabela se posle pravljenja moze menjati
+func (mt *Memtable) NewSnapshotIterator() *Iterator {
+	it := &Iterator{
+		memtable:        mt,
+		snapshot:        true,
+		records:         mt.Records(),
+		rangeTombstones: append([]record.Record(nil), mt.rangeTombstones...),
+	}
+	it.SeekToFirst()
+	return it
+}
+
+func (it *Iterator) SeekToFirst() {
+	if !it.snapshot {
+		it.node = it.memtable.skiplist.First()
+	} else {
+		it.position = 0
+	}
+}
+
+func (it *Iterator) SeekToLast() {
+	if !it.snapshot {
+		it.node = it.memtable.skiplist.Last()
+	} else {
+		it.position = len(it.records) - 1
+	}
+}
+
+// pozicionira iterator na prvi zapis ciji je kljuc veci ili jednak zadatom
+func (it *Iterator) Seek(key string) {
+	if !it.snapshot {
+		it.node, _ = it.memtable.skiplist.Search(key)
+	} else {
+		it.position = sort.Search(len(it.records), func(i int) bool {
+			return it.records[i].Key >= key
+		})
+	}
+}
+
+// pozicionira iterator na poslednji zapis ciji je kljuc manji ili jednak zadatom
+func (it *Iterator) SeekForPrev(key string) {
+	if !it.snapshot {
+		it.node = it.memtable.skiplist.SearchForPrev(key)
+	} else {
+		it.position = sort.Search(len(it.records), func(i int) bool {
+			return it.records[i].Key > key
+		}) - 1
+	}
+}
+
+func (it *Iterator) Next() {
+	if !it.Valid() {
+		return
+	}
+	if !it.snapshot {
+		it.node = it.node.Next()
+	} else {
+		it.position++
+	}
+}
+
+func (it *Iterator) Prev() {
+	if !it.Valid() {
+		return
+	}
+	if !it.snapshot {
+		it.node = it.node.Prev()
+	} else {
+		it.position--
+	}
+}
+
+func (it *Iterator) Valid() bool {
+	if !it.snapshot {
+		return it.node != nil
+	}
+	return it.position >= 0 && it.position < len(it.records)
+}
+
+func (it *Iterator) Record() *record.Record {
+	if !it.Valid() {
+		return nil
+	}
+	if !it.snapshot {
+		return it.node.Record
+	}
+	return &it.records[it.position]
+}
+
+func (it *Iterator) RangeTombstones() []record.Record {
+	if it.snapshot {
+		return it.rangeTombstones
+	}
+	return it.memtable.rangeTombstones
+}
+
+// memtable se ne cita sa diska, pa iterator nikad nema gresku
+func (it *Iterator) Err() error {
+	return nil
+}
+
+func (it *Iterator) Close() error {
+	it.node = nil
+	it.records = nil
+	return nil
+}
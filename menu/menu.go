@@ -2,9 +2,10 @@ package menu
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
-	"main/config"
 	"main/engine"
+	"main/record"
 	"os"
 	"strconv"
 	"strings"
@@ -29,13 +30,22 @@ func (m *Menu) print() {
 	fmt.Println("[9] 	Range Scan")
 	fmt.Println("[10]	Prefix Iterator")
 	fmt.Println("[11]	Range Iterator")
+	fmt.Println("[12]	Cache Statistics")
+	fmt.Println("[13]	Value Log GC")
+	fmt.Println("[14]	Delete Range")
+	fmt.Println("[15]	Compare And Swap")
+	fmt.Println("[16]	Put If Absent")
+	fmt.Println("[17]	System Keys (admin)")
 	fmt.Println("[X]	EXIT")
 	fmt.Println("======================")
 	fmt.Print(">> ")
 }
 
 func (m *Menu) Start() {
-	m.engine.Engine()
+	err := m.engine.Engine()
+	if err != nil {
+		fmt.Println("Error recovering from wal:", err)
+	}
 	m.reader = bufio.NewReader(os.Stdin)
 
 	for {
@@ -44,25 +54,23 @@ func (m *Menu) Start() {
 		optionScanner.Scan()
 		option := optionScanner.Text()
 
-		if m.engine.Tbucket.Take() {
+		if m.engine.TakeToken() == nil {
 			switch option {
 			case "1":
 				key, value := m.InputKeyValue(true)
-				m.engine.Put(key, value, false)
+				m.printError(m.engine.Put(key, value, false))
 			case "2":
 				key, _ := m.InputKeyValue(false)
-				record := m.engine.Get(key, false) // u slucaju rada sa strukturama staviti na true
-				if record == nil {
-					fmt.Println("Record not found.")
+				record, err := m.engine.Get(key)
+				if err != nil {
+					m.printError(err)
 				} else {
 					fmt.Println("Value: " + string(record.Value))
+					fmt.Println("Version:", record.Version())
 				}
 			case "3":
 				key, _ := m.InputKeyValue(false)
-				err := m.engine.Delete(key)
-				if err != nil {
-					fmt.Println(err)
-				}
+				m.printError(m.engine.Delete(key))
 			case "4":
 				m.CMSOptions()
 			case "5":
@@ -79,19 +87,20 @@ func (m *Menu) Start() {
 				m.PrefixIterator()
 			case "11":
 				m.RangeIterator()
-			case "X":
-				record := m.engine.Tbucket.ToBytes()
-				m.engine.Put("tb_", record, false)
-				serializedKeyDictionary, _ := m.engine.SerializeMap(m.engine.KeyDictionary)
-				f, _ := os.OpenFile(config.KEY_DICTIONARY_FILE_PATH, os.O_CREATE|os.O_WRONLY, 0644)
-				f.Write(serializedKeyDictionary)
-				os.Exit(0)
-			case "x":
-				record := m.engine.Tbucket.ToBytes()
-				m.engine.Put("tb_", record, false)
-				serializedKeyDictionary, _ := m.engine.SerializeMap(m.engine.KeyDictionary)
-				f, _ := os.OpenFile(config.KEY_DICTIONARY_FILE_PATH, os.O_CREATE|os.O_WRONLY, 0644)
-				f.Write(serializedKeyDictionary)
+			case "12":
+				m.CacheStatistics()
+			case "13":
+				m.ValueLogGC()
+			case "14":
+				m.DeleteRange()
+			case "15":
+				m.CompareAndSwap()
+			case "16":
+				m.PutIfAbsent()
+			case "17":
+				m.SystemKeys()
+			case "X", "x":
+				m.printError(m.engine.SaveTokenBucket())
 				os.Exit(0)
 			default:
 				fmt.Println("Invalid option!")
@@ -111,33 +120,42 @@ func (m *Menu) HLLOptions() {
 	fmt.Println("[2]	Delete Instance")
 	fmt.Println("[3]	Add Key")
 	fmt.Println("[4]	Check Cardinality For Key")
+	fmt.Println("[5]	Merge Instances")
 	fmt.Println("========================")
 	fmt.Print(">>")
 
 	option := m.InputString()
 
-	if m.engine.Tbucket.Take() {
+	if m.engine.TakeToken() == nil {
 		switch option {
 		case "1":
-			fmt.Print("Choose key for new HyperLogLog: ")
+			fmt.Print("Choose name for new HyperLogLog: ")
 			key := m.InputString()
 			fmt.Print("Input p for your HyperLogLog: ")
 			p := m.InputInt()
-			m.engine.HLLCreateNewInstance(key, p)
+			m.printError(m.engine.HLLCreateNewInstance(key, p))
 		case "2":
-			fmt.Print("Input key of HyperLogLog you want to delete: ")
+			fmt.Print("Input name of HyperLogLog you want to delete: ")
 			key := m.InputString()
-			m.engine.HLLDeleteInstance(key)
+			m.printError(m.engine.HLLDeleteInstance(key))
 		case "3":
-			fmt.Print("Input key of HyperLogLog you want to add element to: ")
+			fmt.Print("Input name of HyperLogLog you want to add element to: ")
 			keyhll := m.InputString()
 			fmt.Print("Input key you want to add: ")
 			key := m.InputString()
-			m.engine.HLLAddElement(keyhll, key)
+			m.printError(m.engine.HLLAddElement(keyhll, key))
 		case "4":
-			fmt.Print("Input key of HyperLogLog you want to see the estimation for: ")
+			fmt.Print("Input name of HyperLogLog you want to see the estimation for: ")
 			key := m.InputString()
-			m.engine.HLLCardinality(key)
+			estimation, err := m.engine.HLLEstimate(key)
+			if err != nil {
+				m.printError(err)
+			} else {
+				fmt.Println("The estimation of unique element is: ", estimation)
+			}
+		case "5":
+			dest, sources := m.InputMergeNames("HyperLogLog")
+			m.printError(m.engine.HLLMerge(dest, sources...))
 		default:
 			fmt.Println("Invalid option!")
 		}
@@ -156,36 +174,40 @@ func (m *Menu) SimHashOptions() {
 
 	option := m.InputString()
 
-	if m.engine.Tbucket.Take() {
+	if m.engine.TakeToken() == nil {
 		switch option {
 		case "1":
-			fmt.Print("Choose key for new SimHash: ")
+			fmt.Print("Choose name for new SimHash: ")
 			key := m.InputString()
 			fmt.Print("Input text for which you want to calculate fingerprint: ")
 			text := m.InputString()
 
-			err := m.engine.CalculateFingerprintSimHash(key, text)
+			fingerprint, err := m.engine.CalculateFingerprintSimHash(key, text)
 			if err != nil {
-				fmt.Println(err)
+				m.printError(err)
+			} else {
+				fmt.Println("fingerprint = " + fingerprint)
 			}
 		case "2":
-			fmt.Print("Input key for first SimHash: ")
+			fmt.Print("Input name of first SimHash: ")
 			key1 := m.InputString()
 			if key1 == "" {
-				fmt.Println("invalid key")
+				fmt.Println("invalid name")
 				return
 			}
 
-			fmt.Print("Input key for second SimHash: ")
+			fmt.Print("Input name of second SimHash: ")
 			key2 := m.InputString()
 			if key2 == "" {
-				fmt.Println("invalid key")
+				fmt.Println("invalid name")
 				return
 			}
 
-			err := m.engine.CalculateHammingDistanceSimHash(key1, key2)
+			hamming, err := m.engine.SimHashDistance(key1, key2)
 			if err != nil {
-				fmt.Println(err)
+				m.printError(err)
+			} else {
+				fmt.Println("Hamming distance = " + fmt.Sprint(hamming))
 			}
 		default:
 			fmt.Println("Invalid option!")
@@ -202,41 +224,50 @@ func (m *Menu) BloomFilterOptions() {
 	fmt.Println("[2]	Delete Instance")
 	fmt.Println("[3]	Add Key")
 	fmt.Println("[4]	Check Presence Of Key")
+	fmt.Println("[5]	Union Of Instances")
+	fmt.Println("[6]	Intersection Of Instances")
 	fmt.Println("============================")
 	fmt.Print(">> ")
 
 	option := m.InputString()
 
-	if m.engine.Tbucket.Take() {
+	if m.engine.TakeToken() == nil {
 		switch option {
 		case "1":
-			fmt.Print("Choose key for new BloomFilter: ")
+			fmt.Print("Choose name for new BloomFilter: ")
 			key := m.InputString()
 			fmt.Print("Input expectedElements for your BloomFilter: ")
 			expectedElements := m.InputInt()
-			fmt.Print("Input falsePositiveRate for your BloomFilter: ")
-			falsePositiveRate := float64(m.InputInt())
-			m.engine.BloomFilterCreateNewInstance(key, expectedElements, falsePositiveRate)
+			fmt.Print("Input falsePositiveRate for your BloomFilter (between 0 and 1): ")
+			falsePositiveRate := m.InputFloat()
+			m.printError(m.engine.BloomFilterCreateNewInstance(key, expectedElements, falsePositiveRate))
 		case "2":
-			fmt.Print("Input key of BloomFilter you want to delete: ")
+			fmt.Print("Input name of BloomFilter you want to delete: ")
 			key := m.InputString()
-			if strings.HasPrefix(key, "bf_") {
-				m.engine.Delete(key)
-			} else {
-				fmt.Println("Such BloomFilter doesn't exist")
-			}
+			m.printError(m.engine.BloomFilterDeleteInstance(key))
 		case "3":
-			fmt.Print("Input key of BloomFilter you want to add element to: ")
+			fmt.Print("Input name of BloomFilter you want to add element to: ")
 			key_bf := m.InputString()
 			fmt.Print("Input key you want to add: ")
 			key := m.InputString()
-			m.engine.BloomFilterAddElement(key_bf, key)
+			m.printError(m.engine.BloomFilterAddElement(key_bf, key))
 		case "4":
-			fmt.Print("Input key of BloomFilter you want to check presence of key: ")
+			fmt.Print("Input name of BloomFilter you want to check presence of key: ")
 			key_bf := m.InputString()
 			fmt.Print("Input key you want to check: ")
 			key := m.InputString()
-			m.engine.BloomFilterCheckElement(key_bf, key)
+			present, err := m.engine.BloomContains(key_bf, key)
+			if err != nil {
+				m.printError(err)
+			} else {
+				fmt.Println(present)
+			}
+		case "5":
+			dest, sources := m.InputMergeNames("BloomFilter")
+			m.printError(m.engine.BloomUnion(dest, sources...))
+		case "6":
+			dest, sources := m.InputMergeNames("BloomFilter")
+			m.printError(m.engine.BloomIntersect(dest, sources...))
 		default:
 			fmt.Println("Invalid option!")
 		}
@@ -252,37 +283,46 @@ func (m *Menu) CMSOptions() {
 	fmt.Println("[2]	Delete Instance")
 	fmt.Println("[3]	Add Key")
 	fmt.Println("[4]	Check Repetitions Of Key")
+	fmt.Println("[5]	Merge Instances")
 	fmt.Println("========================")
 	fmt.Print(">>")
 
 	option := m.InputString()
 
-	if m.engine.Tbucket.Take() {
+	if m.engine.TakeToken() == nil {
 		switch option {
 		case "1":
-			fmt.Print("Choose key for your CMS: ")
+			fmt.Print("Choose name for your CMS: ")
 			key := m.InputString()
 			fmt.Print("Input epsilon for your CMS: ")
 			epsilon := float64(m.InputInt())
 			fmt.Print("Input delta for your CMS: ")
 			delta := float64(m.InputInt())
-			m.engine.CMSCreateNewInstance(key, epsilon, delta)
+			m.printError(m.engine.CMSCreateNewInstance(key, epsilon, delta))
 		case "2":
-			fmt.Print("Input key of CMS you want to delete: ")
+			fmt.Print("Input name of CMS you want to delete: ")
 			key := m.InputString()
-			m.engine.Delete(key)
+			m.printError(m.engine.CMSDeleteInstance(key))
 		case "3":
-			fmt.Println("Input key of CMS you want to add element to: ")
+			fmt.Println("Input name of CMS you want to add element to: ")
 			key_cms := m.InputString()
 			fmt.Print("Input key you want to add: ")
 			key := m.InputString()
-			m.engine.CMSAddElement(key_cms, key)
+			m.printError(m.engine.CMSAddElement(key_cms, key))
 		case "4":
-			fmt.Println("Input key of CMS you want to check key repetition: ")
+			fmt.Println("Input name of CMS you want to check key repetition: ")
 			key_cms := m.InputString()
 			fmt.Print("Input key you want to check: ")
 			key := m.InputString()
-			m.engine.CMSCheckFrequency(key_cms, key)
+			repetitions, err := m.engine.CMSCount(key_cms, key)
+			if err != nil {
+				m.printError(err)
+			} else {
+				fmt.Println(repetitions)
+			}
+		case "5":
+			dest, sources := m.InputMergeNames("CMS")
+			m.printError(m.engine.CMSMerge(dest, sources...))
 		default:
 			fmt.Println("Invalid option!")
 		}
@@ -292,25 +332,39 @@ func (m *Menu) CMSOptions() {
 	}
 }
 
+// ispisuje gresku razumljivo korisniku, nil se ne ispisuje
+func (m *Menu) printError(err error) {
+	var corruption *engine.ErrCorruption
+	switch {
+	case err == nil:
+	case errors.Is(err, engine.ErrNotFound):
+		fmt.Println("Record not found.")
+	case errors.Is(err, engine.ErrWrongType):
+		fmt.Println("The name belongs to a structure of another type.")
+	case errors.Is(err, engine.ErrMalformedStructure):
+		fmt.Println("The structure is damaged.")
+	case errors.Is(err, engine.ErrInvalidParameters):
+		fmt.Println("Invalid parameters for the structure.")
+	case errors.Is(err, engine.ErrIncompatible):
+		fmt.Println("The structures were created with different parameters and can't be merged.")
+	case errors.As(err, &corruption):
+		fmt.Printf("Data is corrupted in %s at offset %d.\n", corruption.File, corruption.Offset)
+	default:
+		fmt.Println(err)
+	}
+}
+
 func (m *Menu) PrefixScan() {
 	fmt.Print("Enter prefix: ")
 	prefix := m.InputString()
-	fmt.Print("Enter page number: ")
-	pageNumber := m.InputInt()
 	fmt.Print("Enter page size: ")
 	pageSize := m.InputInt()
+	fmt.Print("Descending order (y/n): ")
+	descending := strings.ToLower(m.InputString()) == "y"
 
-	page := m.engine.PrefixScan(strings.ToLower(prefix), pageNumber, pageSize)
-
-	if len(page) == 0 || page == nil {
-		fmt.Println("There are not records with given prefix.")
-	} else {
-		fmt.Printf("Page %d:\n", pageNumber)
-
-		for i := 0; i < len(page); i++ {
-			fmt.Printf("Record %d: %s\t%s\n", (i + 1), page[i].Key, page[i].Value)
-		}
-	}
+	m.printPages("There are not records with given prefix.", func(token string) ([]record.Record, string, error) {
+		return m.engine.PrefixScan(prefix, token, pageSize, descending)
+	})
 }
 
 func (m *Menu) RangeScan() {
@@ -318,21 +372,46 @@ func (m *Menu) RangeScan() {
 	minKey := m.InputString()
 	fmt.Print("Enter max key: ")
 	maxKey := m.InputString()
-	fmt.Print("Enter page number: ")
-	pageNumber := m.InputInt()
 	fmt.Print("Enter page size: ")
 	pageSize := m.InputInt()
+	fmt.Print("Descending order (y/n): ")
+	descending := strings.ToLower(m.InputString()) == "y"
 
-	page := m.engine.RangeScan(minKey, maxKey, pageNumber, pageSize)
+	m.printPages("There are not records in given range.", func(token string) ([]record.Record, string, error) {
+		return m.engine.RangeScan(minKey, maxKey, token, pageSize, descending)
+	})
+}
 
-	if page == nil || len(page) == 0 {
-		fmt.Println("There are not records in given range.")
-	} else {
-		fmt.Printf("Page %d:\n", pageNumber)
+// ispisuje stranicu po stranicu, sledeca se trazi preko tokena prethodne
+func (m *Menu) printPages(emptyMessage string, scan func(token string) ([]record.Record, string, error)) {
+	token := ""
+	for pageNumber := 1; ; pageNumber++ {
+		page, nextToken, err := scan(token)
+		if err != nil {
+			m.printError(err)
+			return
+		}
+		if len(page) == 0 {
+			if pageNumber == 1 {
+				fmt.Println(emptyMessage)
+			}
+			return
+		}
 
+		fmt.Printf("Page %d:\n", pageNumber)
 		for i := 0; i < len(page); i++ {
 			fmt.Printf("Record %d: %s\t%s\n", (i + 1), page[i].Key, page[i].Value)
 		}
+
+		if nextToken == "" {
+			fmt.Println("No more records.")
+			return
+		}
+		fmt.Print("Next page (y/n): ")
+		if strings.ToLower(m.InputString()) != "y" {
+			return
+		}
+		token = nextToken
 	}
 }
 
@@ -340,30 +419,23 @@ func (m *Menu) PrefixIterator() {
 	fmt.Print("Enter prefix: ")
 	prefix := m.InputString()
 
-	input := ""
-	pageNumber := 1
-	for strings.ToLower(input) != "stop" {
-		page := m.engine.PrefixScan(prefix, pageNumber, 1)
-
-		if page == nil && pageNumber == 1 {
-			fmt.Println("There are not records with given prefix.")
-			break
-		} else if page == nil {
-			fmt.Println("No more records.")
-			break
-		} else {
-			fmt.Printf("Record: %s\t%s\n", page[0].Key, page[0].Value)
-		}
+	it, err := m.engine.NewPrefixIterator(prefix)
+	if err != nil {
+		m.printError(err)
+		return
+	}
+	defer it.Close()
 
-		fmt.Print(">> ")
-		input = m.InputString()
-		for strings.ToLower(input) != "next" && strings.ToLower(input) != "stop" {
-			fmt.Print(">> ")
-			input = m.InputString()
-		}
+	fmt.Print("Start from the end (y/n): ")
+	if strings.ToLower(m.InputString()) == "y" {
+		it.SeekToLast()
+	}
 
-		pageNumber++
+	if !it.Valid() {
+		fmt.Println("There are not records with given prefix.")
+		return
 	}
+	m.iterate(it)
 }
 
 func (m *Menu) RangeIterator() {
@@ -372,143 +444,151 @@ func (m *Menu) RangeIterator() {
 	fmt.Print("Enter max key: ")
 	maxKey := m.InputString()
 
-	input := ""
-	pageNumber := 1
-	for strings.ToLower(input) != "stop" {
-		page := m.engine.RangeScan(minKey, maxKey, pageNumber, 1)
+	it, err := m.engine.NewRangeIterator(minKey, maxKey)
+	if err != nil {
+		m.printError(err)
+		return
+	}
+	defer it.Close()
 
-		if page == nil && pageNumber == 1 {
-			fmt.Println("There are not records in this range.")
-			break
-		} else if page == nil {
-			fmt.Println("No more records.")
-			break
-		} else {
-			fmt.Printf("Record: %s\t%s\n", page[0].Key, page[0].Value)
-		}
+	fmt.Print("Start from the end (y/n): ")
+	if strings.ToLower(m.InputString()) == "y" {
+		it.SeekToLast()
+	}
+
+	if !it.Valid() {
+		fmt.Println("There are not records in this range.")
+		return
+	}
+	m.iterate(it)
+}
+
+// ispisuje zapis po zapis, u oba smera, dok korisnik ne unese stop ili se ne dodje do kraja
+func (m *Menu) iterate(it engine.Iterator) {
+	for it.Valid() {
+		fmt.Printf("Record: %s\t%s\n", it.Key(), it.Value())
 
 		fmt.Print(">> ")
-		input = m.InputString()
-		for strings.ToLower(input) != "next" && strings.ToLower(input) != "stop" {
+		input := strings.ToLower(m.InputString())
+		for input != "next" && input != "prev" && input != "stop" {
 			fmt.Print(">> ")
-			input = m.InputString()
+			input = strings.ToLower(m.InputString())
 		}
 
-		pageNumber++
+		if input == "stop" {
+			return
+		} else if input == "prev" {
+			it.Prev()
+		} else {
+			it.Next()
+		}
+	}
+	if err := it.Err(); err != nil {
+		m.printError(err)
+		return
 	}
+	fmt.Println("No more records.")
 }
 
-func (m *Menu) PrefixIterator2() {
-	fmt.Print("Enter prefix: ")
-	prefix := m.InputString()
+func (m *Menu) CacheStatistics() {
+	cacheStats := m.engine.Cache.Stats()
+	fmt.Println("\n===== CACHE STATISTICS =====")
+	fmt.Printf("Record cache (%s): %d records, %d hits, %d misses, hit ratio %.2f%%\n",
+		cacheStats.Policy, cacheStats.Size, cacheStats.Hits, cacheStats.Misses, cacheStats.HitRatio()*100)
 
-	input := ""
-	pageNumber := 1
-	for {
-		page := m.engine.PrefixScan(prefix, pageNumber, 10)
+	blockStats := m.engine.BlockCache.Stats()
+	fmt.Printf("Block cache: %d blocks (%d bytes), %d hits, %d misses, %d evictions, hit ratio %.2f%%\n",
+		blockStats.Blocks, blockStats.Bytes, blockStats.Hits, blockStats.Misses, blockStats.Evictions, blockStats.HitRatio()*100)
+	fmt.Println("============================")
+}
 
-		if page == nil && pageNumber == 1 {
-			fmt.Println("There are not records with given prefix.")
-			break
-		} else if page == nil {
-			fmt.Println("No more records.")
-			break
-		} else {
-			fmt.Printf("Record: %s\t%s\n", page[0].Key, page[0].Value)
+func (m *Menu) ValueLogGC() {
+	collected, err := m.engine.ValueLogGC()
+	if err != nil {
+		m.printError(err)
+	} else if collected {
+		fmt.Println("Oldest value log file collected.")
+	} else {
+		fmt.Println("There are no value log files to collect.")
+	}
+}
 
-			fmt.Print(">> ")
-			input = m.InputString()
-			for strings.ToLower(input) != "next" || strings.ToLower(input) != "stop" {
-				fmt.Print(">> ")
-				input = m.InputString()
-			}
+// brise sve kljuceve od pocetnog do krajnjeg, krajnji kljuc se ne brise
+func (m *Menu) DeleteRange() {
+	fmt.Print("Enter start key: ")
+	start := m.InputString()
+	fmt.Print("Enter end key (not deleted): ")
+	end := m.InputString()
 
-			if strings.ToLower(input) == "stop" {
-				break
-			} else {
-				i := 1
-				for strings.ToLower(input) == "next" {
-					if i == 10 {
-						break
-					}
-
-					fmt.Printf("Record: %s\t%s\n", page[i].Key, page[i].Value)
-					fmt.Print(">> ")
-					input = m.InputString()
-					for strings.ToLower(input) != "next" || strings.ToLower(input) != "stop" {
-						fmt.Print(">> ")
-						input = m.InputString()
-					}
-					i++
-				}
-				if strings.ToLower(input) == "stop" {
-					break
-				}
-			}
-		}
-		pageNumber++
+	err := m.engine.DeleteRange(start, end)
+	if err != nil {
+		m.printError(err)
+	} else {
+		fmt.Println("Range deleted.")
 	}
 }
 
-func (m *Menu) RangeIterator2() {
-	fmt.Print("Enter min key: ")
-	minKey := m.InputString()
-	fmt.Print("Enter max key: ")
-	maxKey := m.InputString()
+// upisuje novu vrednost samo ako se verzija kljuca nije promenila od citanja
+func (m *Menu) CompareAndSwap() {
+	key, value := m.InputKeyValue(true)
+	fmt.Print("Enter expected version: ")
+	expected, err := strconv.ParseUint(m.InputString(), 10, 64)
+	if err != nil {
+		fmt.Println("Invalid version!")
+		return
+	}
 
-	input := ""
-	pageNumber := 1
-	for {
-		page := m.engine.RangeScan(minKey, maxKey, pageNumber, 10)
+	swapped, err := m.engine.CompareAndSwap(key, expected, value)
+	if err != nil {
+		m.printError(err)
+	} else if swapped {
+		fmt.Println("Value written.")
+	} else {
+		fmt.Println("Version has changed, value not written.")
+	}
+}
 
-		if page == nil && pageNumber == 1 {
-			fmt.Println("There are not records with given prefix.")
-			break
-		} else if page == nil {
-			fmt.Println("No more records.")
-			break
-		} else {
-			fmt.Printf("Record: %s\t%s\n", page[0].Key, page[0].Value)
+func (m *Menu) PutIfAbsent() {
+	key, value := m.InputKeyValue(true)
+	written, err := m.engine.PutIfAbsent(key, value)
+	if err != nil {
+		m.printError(err)
+	} else if written {
+		fmt.Println("Value written.")
+	} else {
+		fmt.Println("Key already exists.")
+	}
+}
 
-			fmt.Print(">> ")
-			input = m.InputString()
-			for strings.ToLower(input) != "next" || strings.ToLower(input) != "stop" {
-				fmt.Print(">> ")
-				input = m.InputString()
-			}
+// ispisuje kljuceve internog prostora sa strukturama i velicinu njihovih vrednosti
+func (m *Menu) SystemKeys() {
+	fmt.Print("Enter prefix (bf_, cms_, hll_, sh_, tb_ or empty for all): ")
+	prefix := m.InputString()
 
-			if strings.ToLower(input) == "stop" {
-				break
-			} else {
-				i := 1
-				for strings.ToLower(input) == "next" {
-					if i == 10 {
-						break
-					}
-
-					fmt.Printf("Record: %s\t%s\n", page[i].Key, page[i].Value)
-					fmt.Print(">> ")
-					input = m.InputString()
-					for strings.ToLower(input) != "next" || strings.ToLower(input) != "stop" {
-						fmt.Print(">> ")
-						input = m.InputString()
-					}
-					i++
-				}
-				if strings.ToLower(input) == "stop" {
-					break
-				}
-			}
-		}
-		pageNumber++
+	it, err := m.engine.Admin().NewPrefixIterator(prefix)
+	if err != nil {
+		m.printError(err)
+		return
+	}
+	defer it.Close()
+
+	count := 0
+	for ; it.Valid(); it.Next() {
+		fmt.Printf("%s\t%d bytes\n", it.Key(), len(it.Value()))
+		count++
 	}
+	if err := it.Err(); err != nil {
+		m.printError(err)
+		return
+	}
+	fmt.Println("System keys:", count)
 }
 
 func (m *Menu) InputKeyValue(inputValueAlso bool) (string, []byte) {
 	fmt.Print("Input key: ")
 	key, _ := m.reader.ReadString('\n')
 	key = strings.TrimSpace(key)
-	if key == "" || key == "tb_" {
+	if key == "" {
 		fmt.Println("invalid key")
 		return "", nil
 	}
@@ -522,6 +602,14 @@ func (m *Menu) InputKeyValue(inputValueAlso bool) (string, []byte) {
 	}
 }
 
+// ime strukture u koju se spaja i imena struktura koje se spajaju u nju
+func (m *Menu) InputMergeNames(structure string) (string, []string) {
+	fmt.Printf("Input name of %s to merge into: ", structure)
+	dest := m.InputString()
+	fmt.Printf("Input names of %s instances to merge, separated by spaces: ", structure)
+	return dest, strings.Fields(m.InputString())
+}
+
 func (m *Menu) InputString() string {
 	input, _ := m.reader.ReadString('\n')
 	return strings.TrimSpace(input)
@@ -532,3 +620,9 @@ func (m *Menu) InputInt() int {
 	number, _ := strconv.Atoi(input)
 	return number
 }
+
+func (m *Menu) InputFloat() float64 {
+	input := m.InputString()
+	number, _ := strconv.ParseFloat(input, 64)
+	return number
+}
@@ -0,0 +1,130 @@
+package mergeoperator
+
+import (
+	"errors"
+	"fmt"
+	"main/record"
+	valuelog "main/valueLog"
+	"strings"
+	"time"
+)
+
+// Operator spaja operande sa postojecom vrednoscu kljuca, pa se kljuc moze
+// menjati bez citanja i ponovnog upisa cele vrednosti. Operandi se cuvaju kao
+// zapisi spajanja, a spajaju se pri citanju i kompakciji.
+type Operator interface {
+	Name() string
+	// existing je nil ako kljuc nema vrednost, operandi su od najstarijeg
+	FullMerge(key string, existing []byte, operands [][]byte) ([]byte, error)
+}
+
+// Operator koji moze da proveri operand pre upisa, bez vrednosti kljuca.
+// Da li se operand slaze sa vrednoscu se vidi tek pri spajanju.
+type OperandValidator interface {
+	ValidateOperand(operand []byte) error
+}
+
+var ErrNoOperator = errors.New("no merge operator for key")
+
+// operandi koji ne mogu da se spoje ni sa kojom vrednoscu, ili vrednost sa
+// kojom operator ne moze da spoji operande
+var ErrUnfoldable = errors.New("merge operands can not be folded")
+
+// Operatori po prefiksu kljuca, za kljuc se bira operator sa najduzim prefiksom
+type Registry struct {
+	operators map[string]Operator
+}
+
+func NewRegistry() *Registry {
+	return &Registry{operators: make(map[string]Operator)}
+}
+
+func (r *Registry) Register(prefix string, operator Operator) {
+	r.operators[prefix] = operator
+}
+
+// dodaje operatore iz other, oni zamenjuju postojece sa istim prefiksom
+func (r *Registry) RegisterAll(other *Registry) {
+	for prefix, operator := range other.operators {
+		r.operators[prefix] = operator
+	}
+}
+
+func (r *Registry) Find(key string) (Operator, bool) {
+	var found Operator
+	longest := -1
+	for prefix, operator := range r.operators {
+		if strings.HasPrefix(key, prefix) && len(prefix) > longest {
+			found = operator
+			longest = len(prefix)
+		}
+	}
+	return found, found != nil
+}
+
+// proverava operand operatorom kljuca, ErrNoOperator ako ga nema
+func (r *Registry) ValidateOperand(key string, operand []byte) error {
+	operator, found := r.Find(key)
+	if !found {
+		return ErrNoOperator
+	}
+	if validator, ok := operator.(OperandValidator); ok {
+		if err := validator.ValidateOperand(operand); err != nil {
+			return fmt.Errorf("%w: %w", ErrUnfoldable, err)
+		}
+	}
+	return nil
+}
+
+// spaja zapise spajanja (od najnovijeg) sa osnovnom verzijom kljuca u zapis sa
+// punom vrednoscu. base je nil ili tombstone ako kljuc nema vrednost, a vrednost
+// u value logu se cita preko values. Tajmstemp i seq broj su od najnovijeg operanda.
+func (r *Registry) Fold(key string, base *record.Record, merges []record.Record, values *valuelog.ValueLog) (*record.Record, error) {
+	operator, found := r.Find(key)
+	if !found {
+		return nil, ErrNoOperator
+	}
+
+	// operandi posle isteka vrednosti pocinju od prazne vrednosti
+	var existing []byte
+	if base != nil && !base.Tombstone && !base.Expired(time.Now()) {
+		resolved, err := values.Resolve(base)
+		if err != nil {
+			return nil, err
+		}
+		existing = resolved.Value
+		if existing == nil {
+			existing = []byte{}
+		}
+	}
+
+	var operands [][]byte
+	for i := len(merges) - 1; i >= 0; i-- {
+		recordOperands, err := record.Operands(merges[i].Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnfoldable, err)
+		}
+		operands = append(operands, recordOperands...)
+	}
+
+	value, err := operator.FullMerge(key, existing, operands)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnfoldable, err)
+	}
+	folded := record.NewRecord(key, value, false)
+	folded.Timestamp = merges[0].Timestamp
+	folded.SeqNum = merges[0].SeqNum
+	return folded, nil
+}
+
+// spaja zapise spajanja (od najnovijeg) u jedan, kada starija vrednost nije poznata
+func Combine(merges []record.Record) record.Record {
+	combined := merges[0]
+	var value []byte
+	for i := len(merges) - 1; i >= 0; i-- {
+		value = append(value, merges[i].Value...)
+	}
+	combined.Value = value
+	combined.ValueSize = int64(len(value))
+	return combined
+}
@@ -0,0 +1,106 @@
+package mergeoperator
+
+import (
+	"errors"
+	"main/bloom-filter"
+	"main/cms"
+	hll "main/hyperloglog"
+	"strconv"
+)
+
+var errMissingStructure = errors.New("merge into a structure that does not exist")
+
+// Brojac cija su vrednost i operandi celi brojevi zapisani kao tekst,
+// operand se dodaje na vrednost
+type Counter struct{}
+
+func (Counter) Name() string {
+	return "counter"
+}
+
+func (Counter) ValidateOperand(operand []byte) error {
+	_, err := strconv.ParseInt(string(operand), 10, 64)
+	return err
+}
+
+func (Counter) FullMerge(key string, existing []byte, operands [][]byte) ([]byte, error) {
+	var sum int64
+	var err error
+	if len(existing) > 0 {
+		sum, err = strconv.ParseInt(string(existing), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, operand := range operands {
+		delta, err := strconv.ParseInt(string(operand), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		sum += delta
+	}
+	return []byte(strconv.FormatInt(sum, 10)), nil
+}
+
+// Operandi su elementi koji se dodaju u bloom filter
+type BloomFilter struct{}
+
+func (BloomFilter) Name() string {
+	return "bloom_filter"
+}
+
+func (BloomFilter) FullMerge(key string, existing []byte, operands [][]byte) ([]byte, error) {
+	if existing == nil {
+		return nil, errMissingStructure
+	}
+	bloomFilter, err := bloom.FromBytes(existing)
+	if err != nil {
+		return nil, err
+	}
+	for _, operand := range operands {
+		bloomFilter.AddElement(string(operand))
+	}
+	return bloomFilter.ToBytes(), nil
+}
+
+// Operandi su elementi koji se dodaju u count-min sketch
+type CountMinSketch struct{}
+
+func (CountMinSketch) Name() string {
+	return "count_min_sketch"
+}
+
+func (CountMinSketch) FullMerge(key string, existing []byte, operands [][]byte) ([]byte, error) {
+	if existing == nil {
+		return nil, errMissingStructure
+	}
+	sketch, err := cms.LoadCMS(existing)
+	if err != nil {
+		return nil, err
+	}
+	for _, operand := range operands {
+		sketch.AddElement(string(operand))
+	}
+	return sketch.ToBytes(), nil
+}
+
+// Operandi su elementi koji se dodaju u hyperloglog
+type HyperLogLog struct{}
+
+func (HyperLogLog) Name() string {
+	return "hyperloglog"
+}
+
+func (HyperLogLog) FullMerge(key string, existing []byte, operands [][]byte) ([]byte, error) {
+	if existing == nil {
+		return nil, errMissingStructure
+	}
+	hloglog, err := hll.LoadHLL(existing)
+	if err != nil {
+		return nil, err
+	}
+	for _, operand := range operands {
+		hloglog.AddElement(string(operand))
+	}
+	return hloglog.ToBytes(), nil
+}
@@ -33,6 +33,10 @@ func NewMerkleTree(data [][]byte) *MerkleTree {
 		node := &Node{data: hash(d)}
 		nodes = append(nodes, node)
 	}
+	// tabela moze imati samo brisanja opsega
+	if len(nodes) == 0 {
+		return &MerkleTree{}
+	}
 	for len(nodes) > 1 {
 		var newLevel []*Node
 		for i := 0; i < len(nodes); i += 2 {
@@ -70,6 +74,9 @@ func SerializeMerkleTree(node *Node) string {
 func DeserializeMerkleTree(serialized string) *Node {
 	re := regexp.MustCompile(`\|+`)
 	trimmed := strings.TrimRight(re.ReplaceAllString(serialized, "|"), "|")
+	if trimmed == "" {
+		return nil
+	}
 	return RecursivelyDeserializeMerkleTree(trimmed)
 }
 
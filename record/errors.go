@@ -0,0 +1,28 @@
+package record
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// zapis ne postoji ili je obrisan
+	ErrNotFound         = errors.New("record not found")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// ErrCorruption opisuje ostecen zapis na disku, Err je uzrok
+// (ErrChecksumMismatch ili io.ErrUnexpectedEOF)
+type ErrCorruption struct {
+	File   string
+	Offset int64
+	Err    error
+}
+
+func (e *ErrCorruption) Error() string {
+	return fmt.Sprintf("corrupted record in %s at offset %d: %v", e.File, e.Offset, e.Err)
+}
+
+func (e *ErrCorruption) Unwrap() error {
+	return e.Err
+}
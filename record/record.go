@@ -2,12 +2,64 @@ package record
 
 import (
 	"encoding/binary"
+	"errors"
 	"hash/crc32"
-	"main/config"
-	"os"
+	"io"
 	"time"
 )
 
+// Format zapisa v1:
+// crc (4) | timestamp (8) | tombstone (1) | velicina kljuca (8) | velicina vrednosti (8) | kljuc | vrednost
+//
+// Format zapisa v2:
+// crc (4) | verzija (1) | flegovi (uvarint) | timestamp (uvarint) | seq broj (uvarint, ako postoji) |
+// istek (uvarint, ako postoji) | familija (uvarint, ako postoji) | velicina kljuca (uvarint) |
+// velicina vrednosti (uvarint) | kljuc | vrednost
+// crc pokriva sve bajtove posle njega. Zapisi sa flegom FlagCRC32C koriste crc32c (Castagnoli),
+// a prvi v2 zapisi su pisani sa IEEE crc-om. Kod zapisa sa flegom FlagValuePointer
+// vrednost je pokazivac na vrednost u value logu. Zapis sa flegom FlagRangeTombstone
+// brise sve kljuceve od kljuca zapisa (ukljucivo) do vrednosti zapisa (iskljucivo).
+// Kod zapisa sa flegom FlagMerge vrednost je lista operanada spajanja, svaki
+// operand je velicina (uvarint) | operand, od najstarijeg. Zapisi grupe koja se
+// upisuje atomski imaju fleg FlagBatch, osim poslednjeg koji zavrsava grupu.
+// Zapisi familija kolona osim podrazumevane imaju fleg FlagFamily i id familije.
+// Flegovi manji od 0x80 staju u jedan bajt, kao u prvim v2 zapisima.
+//
+// Kod v1 zapisa je na mestu verzije najvisi bajt tajmstempa, koji je za svaki
+// realan tajmstemp 0, pa citaci po tom bajtu razlikuju verzije.
+const (
+	VersionV1 = 1
+	VersionV2 = 2
+)
+
+const (
+	FlagTombstone = 1 << iota
+	FlagSeqNum
+	FlagExpiry
+	FlagCRC32C
+	FlagValuePointer
+	FlagRangeTombstone
+	FlagMerge
+	FlagBatch
+	FlagFamily
+)
+
+// crc32c ima hardversku podrsku (SSE4.2, ARMv8), pa je brzi od IEEE
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// crc32c nad bajtovima zapisa koji slede posle crc-a
+func Checksum(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoliTable)
+}
+
+// proverava crc v2 zapisa, data pocinje od verzije, a algoritam odredjuju flegovi
+func ValidChecksum(data []byte, crc uint32) bool {
+	if len(data) > 1 && data[1]&FlagCRC32C != 0 {
+		return Checksum(data) == crc
+	}
+	return crc32.ChecksumIEEE(data) == crc
+}
+
 type Record struct {
 	Crc32     uint32
 	Timestamp int64
@@ -16,10 +68,22 @@ type Record struct {
 	ValueSize int64
 	Key       string
 	Value     []byte // sa konzole ucitavamo vrednost kao string, pa posle konvertujemo u niz bajtova
+	SeqNum    uint64 // 0 ako nije dodeljen
+	Expiry    int64  // unix vreme isteka, 0 ako zapis ne istice
+	// vrednost je pokazivac u value log, a ne sama vrednost
+	ValuePointer bool
+	// zapis brise opseg [Key, Value)
+	RangeTombstone bool
+	// vrednost je lista operanada koji se spajaju sa starijom verzijom kljuca
+	Merge bool
+	// zapis grupe posle kog u WAL-u sledi jos zapisa iste grupe
+	Batch bool
+	// id familije kolona kojoj zapis pripada, 0 za podrazumevanu
+	Family uint32
 }
 
 /* Konstruktor za pravljenje novog zapisa */
-func NewRecord(key string, value []byte, delete bool, keyDictionary *map[int]string) *Record {
+func NewRecord(key string, value []byte, delete bool) *Record {
 	record := &Record{
 		Tombstone: delete,
 		Timestamp: time.Now().Unix(),
@@ -28,216 +92,133 @@ func NewRecord(key string, value []byte, delete bool, keyDictionary *map[int]str
 		Key:       key,
 		Value:     value,
 	}
-	cfg := new(config.Config)
-	config.LoadConfig(cfg)
+	record.Crc32 = binary.BigEndian.Uint32(record.ToBytes())
+	return record
+}
 
-	if cfg.Compress {
-		mapKey := putElementToMap(key, keyDictionary)
-		indexBytes := make([]byte, 2)
-		binary.BigEndian.PutUint16(indexBytes, uint16(mapKey))
-		mapKeySize := int64(len(indexBytes))
-		record.Crc32 = CalculateCRC(record.Timestamp, record.Tombstone, mapKeySize, record.ValueSize, record.Key, record.Value)
-	} else {
-		record.Crc32 = CalculateCRC(record.Timestamp, record.Tombstone, record.KeySize, record.ValueSize, record.Key, record.Value)
+/* Konstruktor za zapis cija je vrednost upisana u value log */
+func NewPointerRecord(key string, pointer []byte) *Record {
+	record := &Record{
+		Timestamp:    time.Now().Unix(),
+		KeySize:      int64(len([]byte(key))),
+		ValueSize:    int64(len(pointer)),
+		Key:          key,
+		Value:        pointer,
+		ValuePointer: true,
 	}
+	record.Crc32 = binary.BigEndian.Uint32(record.ToBytes())
 	return record
 }
 
-/* Konstruktor za ucitavanje zapisa u memoriju */
-func LoadRecord(crc32 uint32, timestamp int64, tombstone bool, keySize int64, valueSize int64, key string, value []byte) *Record {
-	return &Record{
-		Crc32:     crc32,
-		Timestamp: timestamp,
-		Tombstone: tombstone,
-		KeySize:   keySize,
-		ValueSize: valueSize,
-		Key:       key,
-		Value:     value,
+/* Konstruktor za brisanje opsega kljuceva [start, end) */
+func NewRangeTombstone(start, end string) *Record {
+	record := &Record{
+		Tombstone:      true,
+		RangeTombstone: true,
+		Timestamp:      time.Now().Unix(),
+		KeySize:        int64(len([]byte(start))),
+		ValueSize:      int64(len([]byte(end))),
+		Key:            start,
+		Value:          []byte(end),
 	}
+	record.Crc32 = binary.BigEndian.Uint32(record.ToBytes())
+	return record
 }
 
-func LoadRecordFromFile(file os.File, keyDictionary *map[int]string) (Record, error) {
-	var record Record
-	cfg := new(config.Config)
-	config.LoadConfig(cfg)
-
-	CRCBytes := make([]byte, 4)
-	_, err := file.Read(CRCBytes)
-	if err != nil {
-		return Record{}, err
+/* Konstruktor za operand spajanja */
+func NewMergeRecord(key string, operand []byte) *Record {
+	value := AppendOperand(nil, operand)
+	record := &Record{
+		Merge:     true,
+		Timestamp: time.Now().Unix(),
+		KeySize:   int64(len([]byte(key))),
+		ValueSize: int64(len(value)),
+		Key:       key,
+		Value:     value,
 	}
-	record.Crc32 = binary.BigEndian.Uint32(CRCBytes)
-
-	timestampBytes := make([]byte, 8)
-	file.Read(timestampBytes)
-	record.Timestamp = int64(binary.BigEndian.Uint64(timestampBytes))
+	record.Crc32 = binary.BigEndian.Uint32(record.ToBytes())
+	return record
+}
 
-	tombstoneBytes := make([]byte, 1)
-	file.Read(tombstoneBytes)
-	record.Tombstone = tombstoneBytes[0] == 1
+// dopisuje operand na kraj liste operanada
+func AppendOperand(operands []byte, operand []byte) []byte {
+	operands = binary.AppendUvarint(operands, uint64(len(operand)))
+	return append(operands, operand...)
+}
 
-	var keySizeBytes []byte
-	if cfg.Compress {
-		keySizeBytes = make([]byte, 2)
-		file.Read(keySizeBytes)
-		record.KeySize = int64(binary.BigEndian.Uint16(keySizeBytes))
-	} else {
-		keySizeBytes = make([]byte, 8)
-		file.Read(keySizeBytes)
-		record.KeySize = int64(binary.BigEndian.Uint64(keySizeBytes))
-	}
-
-	if !record.Tombstone {
-		valueSizeBytes := make([]byte, 8)
-		file.Read(valueSizeBytes)
-		record.ValueSize = int64(binary.BigEndian.Uint64(valueSizeBytes))
-
-		keyBytes := make([]byte, record.KeySize)
-		file.Read(keyBytes)
-		if cfg.Compress {
-			index := binary.BigEndian.Uint16(keyBytes)
-			record.Key = (*keyDictionary)[int(index)]
-		} else {
-			record.Key = string(keyBytes)
+// operandi iz vrednosti zapisa spajanja, od najstarijeg
+func Operands(value []byte) ([][]byte, error) {
+	var operands [][]byte
+	for offset := 0; offset < len(value); {
+		size, err := readUvarint(value, &offset)
+		if err != nil {
+			return nil, err
 		}
-
-		valueBytes := make([]byte, record.ValueSize)
-		file.Read(valueBytes)
-		record.Value = valueBytes
-	} else {
-		record.ValueSize = 0
-
-		keyBytes := make([]byte, record.KeySize)
-		file.Read(keyBytes)
-		record.Key = string(keyBytes)
-
-		record.Value = nil
-	}
-	checkCrc32 := CalculateCRC(record.Timestamp, record.Tombstone, record.KeySize, record.ValueSize, record.Key, record.Value)
-	if checkCrc32 != record.Crc32 {
-		return Record{}, nil
+		if size > uint64(len(value)-offset) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		operands = append(operands, value[offset:offset+int(size)])
+		offset += int(size)
 	}
-
-	return record, nil
+	return operands, nil
 }
 
-func LoadAllRecordsFromFiles(filePaths []*os.File, keyDictionary *map[int]string) []Record {
-	var allRecords []Record
-
-	for i := 0; i < len(filePaths); i++ {
-		record, _ := LoadRecordFromFile(*filePaths[i], keyDictionary)
-		allRecords = append(allRecords, record)
+// verzija zapisa za uslovni upis, seq broj ili tajmstemp kod zapisa bez njega
+func (r *Record) Version() uint64 {
+	if r.SeqNum != 0 {
+		return r.SeqNum
 	}
-
-	return allRecords
+	return uint64(r.Timestamp)
 }
 
-func LoadRecordsFromFile(fileName string, keyDictionary *map[int]string) ([]*Record, error) {
-	var records []*Record
-	cfg := new(config.Config)
-	config.LoadConfig(cfg)
-
-	f, err := os.OpenFile(fileName, os.O_RDONLY, 0644)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
+// da li je zapis istekao, istekao zapis se cita kao tombstone
+func (r *Record) Expired(now time.Time) bool {
+	return r.Expiry != 0 && r.Expiry <= now.Unix()
+}
 
-	stat, err := f.Stat()
-	if err != nil {
-		return nil, err
-	}
+// da li brisanje opsega obuhvata kljuc
+func (r *Record) Covers(key string) bool {
+	return r.RangeTombstone && key >= r.Key && key < string(r.Value)
+}
 
-	data := make([]byte, stat.Size())
-	_, err = f.Read(data)
-	if err != nil {
-		return nil, err
+// da li neko od brisanja opsega obuhvata kljuc
+func Covered(rangeTombstones []Record, key string) bool {
+	for i := range rangeTombstones {
+		if rangeTombstones[i].Covers(key) {
+			return true
+		}
 	}
+	return false
+}
 
-	for len(data) != 0 { // ucitavaj iz fajla sve dok ima nesto
-		if cfg.Compress {
-			crc32 := binary.BigEndian.Uint32(data[0:4])
-			timestamp := int64(binary.BigEndian.Uint64(data[4:12]))
-			tombstone := false
-			if data[12] == 1 {
-				tombstone = true
-			}
-			if tombstone {
-				keySize := int64(binary.BigEndian.Uint16(data[13:15]))
-				index := binary.BigEndian.Uint16(data[15 : 15+keySize])
-				key := (*keyDictionary)[int(index)]
-				var valueSize int64 = 0
-				var value []byte = nil
-				checkCrc32 := CalculateCRC(timestamp, tombstone, keySize, valueSize, key, value)
-
-				if checkCrc32 == crc32 { // potrebno je pri ucitavanju proveriti da li je doslo do promene zapisa
-					loadedRecord := LoadRecord(crc32, timestamp, tombstone, keySize, valueSize, key, value)
-					records = append(records, loadedRecord)
-				}
-
-				data = data[15+keySize:]
-
-			} else {
-				keySize := int64(binary.BigEndian.Uint16(data[13:15]))
-				valueSize := int64(binary.BigEndian.Uint64(data[15:23]))
-				index := binary.BigEndian.Uint16(data[23 : 23+keySize])
-				key := (*keyDictionary)[int(index)]
-				value := data[23+keySize : 23+keySize+valueSize]
-
-				checkCrc32 := CalculateCRC(timestamp, tombstone, keySize, valueSize, key, value)
-
-				if checkCrc32 == crc32 { // potrebno je pri ucitavanju proveriti da li je doslo do promene zapisa
-					var loadedRecord *Record
-					if cfg.Compress {
-						loadedRecord = LoadRecord(crc32, timestamp, tombstone, int64(len(key)), valueSize, key, value)
-					} else {
-						loadedRecord = LoadRecord(crc32, timestamp, tombstone, keySize, valueSize, key, value)
-					}
-					records = append(records, loadedRecord)
-				}
-
-				data = data[23+keySize+valueSize:]
-			}
-		} else {
-			crc32 := binary.BigEndian.Uint32(data[0:4])
-			timestamp := int64(binary.BigEndian.Uint64(data[4:12]))
-			tombstone := false
-			if data[12] == 1 {
-				tombstone = true
-			}
-			if tombstone {
-				var valueSize int64 = 0
-				var value []byte = nil
-
-				keySize := int64(binary.BigEndian.Uint64(data[13:21]))
-				key := string(data[21 : 21+keySize])
-
-				checkCrc32 := CalculateCRC(timestamp, tombstone, keySize, valueSize, key, value)
-				if checkCrc32 == crc32 { // potrebno je pri ucitavanju proveriti da li je doslo do promene zapisa
-					loadedRecord := LoadRecord(crc32, timestamp, tombstone, keySize, valueSize, key, value)
-					records = append(records, loadedRecord)
-				}
-
-				data = data[21+keySize:]
-
-			} else {
-				keySize := int64(binary.BigEndian.Uint64(data[13:21]))
-				valueSize := int64(binary.BigEndian.Uint64(data[21:29]))
-				key := string(data[29 : 29+keySize])
-				value := data[29+keySize : 29+keySize+valueSize]
-
-				checkCrc32 := CalculateCRC(timestamp, tombstone, keySize, valueSize, key, value)
-				if checkCrc32 == crc32 { // potrebno je pri ucitavanju proveriti da li je doslo do promene zapisa
-					loadedRecord := LoadRecord(crc32, timestamp, tombstone, keySize, valueSize, key, value)
-					records = append(records, loadedRecord)
-				}
-
-				data = data[29+keySize+valueSize:]
+// tombstone kljuca sa verzijom najnovijeg brisanja opsega koje ga obuhvata,
+// nil ako ga nijedno ne obuhvata
+func RangeTombstoneFor(rangeTombstones []Record, key string) *Record {
+	var tombstone *Record
+	for i := range rangeTombstones {
+		if rangeTombstones[i].Covers(key) && (tombstone == nil || rangeTombstones[i].Version() > tombstone.Version()) {
+			tombstone = &Record{
+				Key:       key,
+				Tombstone: true,
+				Timestamp: rangeTombstones[i].Timestamp,
+				SeqNum:    rangeTombstones[i].SeqNum,
 			}
 		}
 	}
+	return tombstone
+}
 
-	return records, nil
+/* Konstruktor za ucitavanje zapisa u memoriju */
+func LoadRecord(crc32 uint32, timestamp int64, tombstone bool, keySize int64, valueSize int64, key string, value []byte) *Record {
+	return &Record{
+		Crc32:     crc32,
+		Timestamp: timestamp,
+		Tombstone: tombstone,
+		KeySize:   keySize,
+		ValueSize: valueSize,
+		Key:       key,
+		Value:     value,
+	}
 }
 
 func CalculateCRC(timestamp int64, tombstone bool, keySize int64, valueSize int64, key string, value []byte) uint32 {
@@ -256,92 +237,194 @@ func CalculateCRC(timestamp int64, tombstone bool, keySize int64, valueSize int6
 	return crc32.ChecksumIEEE(buffer)
 }
 
-/* Konvertuje zapis u niz bajtova */
+/* Konvertuje zapis u niz bajtova, uvek u formatu v2 sa crc32c */
 func (r Record) ToBytes() []byte {
-	bufferSize := 29 + r.KeySize + r.ValueSize
-	buffer := make([]byte, bufferSize)
-	binary.BigEndian.PutUint32(buffer[0:4], uint32(r.Crc32))
-	binary.BigEndian.PutUint64(buffer[4:12], uint64(r.Timestamp))
-	buffer[12] = 0
-	if r.Tombstone {
-		buffer[12] = 1
-	}
-	binary.BigEndian.PutUint64(buffer[13:21], uint64(r.KeySize))
-	binary.BigEndian.PutUint64(buffer[21:29], uint64(r.ValueSize))
-	copy(buffer[29:29+r.KeySize], []byte(r.Key))
-	copy(buffer[29+r.KeySize:bufferSize], r.Value)
+	buffer := make([]byte, 4, 16+len(r.Key)+len(r.Value))
+	buffer = r.AppendHeader(buffer)
+	buffer = binary.AppendUvarint(buffer, uint64(len(r.Key)))
+	buffer = binary.AppendUvarint(buffer, uint64(len(r.Value)))
+	buffer = append(buffer, r.Key...)
+	buffer = append(buffer, r.Value...)
+	binary.BigEndian.PutUint32(buffer[0:4], Checksum(buffer[4:]))
 	return buffer
 }
 
-func putElementToMap(ogKey string, keyDictionary *map[int]string) int {
-	for key, value := range *keyDictionary {
-		if value == ogKey {
-			return key
-		}
+// dopisuje deo zaglavlja v2 zapisa od verzije do kljuca, koristi ga i SSTabela.
+// Zapis mora biti potpisan sa Checksum.
+func (r Record) AppendHeader(dst []byte) []byte {
+	flags := uint64(FlagCRC32C)
+	if r.Tombstone {
+		flags |= FlagTombstone
 	}
-
-	nextKey := 0
-	for key := range *keyDictionary {
-		if key >= nextKey {
-			nextKey = key + 1
-		}
+	if r.SeqNum != 0 {
+		flags |= FlagSeqNum
+	}
+	if r.Expiry != 0 {
+		flags |= FlagExpiry
+	}
+	if r.ValuePointer {
+		flags |= FlagValuePointer
+	}
+	if r.RangeTombstone {
+		flags |= FlagRangeTombstone
+	}
+	if r.Merge {
+		flags |= FlagMerge
+	}
+	if r.Batch {
+		flags |= FlagBatch
+	}
+	if r.Family != 0 {
+		flags |= FlagFamily
 	}
-	(*keyDictionary)[nextKey] = ogKey
 
-	return nextKey
+	dst = append(dst, VersionV2)
+	dst = binary.AppendUvarint(dst, flags)
+	dst = binary.AppendUvarint(dst, uint64(r.Timestamp))
+	if r.SeqNum != 0 {
+		dst = binary.AppendUvarint(dst, r.SeqNum)
+	}
+	if r.Expiry != 0 {
+		dst = binary.AppendUvarint(dst, uint64(r.Expiry))
+	}
+	if r.Family != 0 {
+		dst = binary.AppendUvarint(dst, uint64(r.Family))
+	}
+	return dst
 }
 
-func (r Record) ToBytesSSTable(keyDictionary *map[int]string) []byte {
-	cfg := new(config.Config)
-	config.LoadConfig(cfg)
-	var bufferSize int64
-	if r.Tombstone {
-		if cfg.Compress {
-			bufferSize = 15 + r.KeySize
-		} else {
-			bufferSize = 21 + r.KeySize
+// cita zaglavlje koje je upisao AppendHeader i vraca broj procitanih bajtova
+func ReadHeader(data []byte) (Record, int, error) {
+	var r Record
+	if len(data) < 2 || data[0] != VersionV2 {
+		return r, 0, io.ErrUnexpectedEOF
+	}
+	offset := 1
+	flags, err := readUvarint(data, &offset)
+	if err != nil {
+		return r, 0, err
+	}
+	r.Tombstone = flags&FlagTombstone != 0
+	r.ValuePointer = flags&FlagValuePointer != 0
+	r.RangeTombstone = flags&FlagRangeTombstone != 0
+	r.Merge = flags&FlagMerge != 0
+	r.Batch = flags&FlagBatch != 0
+
+	timestamp, err := readUvarint(data, &offset)
+	if err != nil {
+		return r, 0, err
+	}
+	r.Timestamp = int64(timestamp)
+	if flags&FlagSeqNum != 0 {
+		r.SeqNum, err = readUvarint(data, &offset)
+		if err != nil {
+			return r, 0, err
 		}
-		r.Crc32 = CalculateCRC(r.Timestamp, r.Tombstone, r.KeySize, 0, r.Key, nil)
-	} else {
-		if cfg.Compress {
-			bufferSize = 23 + r.KeySize + r.ValueSize
-		} else {
-			bufferSize = 29 + r.KeySize + r.ValueSize
+	}
+	if flags&FlagExpiry != 0 {
+		expiry, err := readUvarint(data, &offset)
+		if err != nil {
+			return r, 0, err
 		}
+		r.Expiry = int64(expiry)
 	}
-	buffer := make([]byte, bufferSize)
-	binary.BigEndian.PutUint32(buffer[0:4], uint32(r.Crc32))
-	binary.BigEndian.PutUint64(buffer[4:12], uint64(r.Timestamp))
-	buffer[12] = 0
-	if r.Tombstone {
-		buffer[12] = 1
-		if cfg.Compress {
-			index := putElementToMap(r.Key, keyDictionary)
-			indexBytes := make([]byte, 2)
-			binary.BigEndian.PutUint16(indexBytes, uint16(index))
-			binary.BigEndian.PutUint16(buffer[13:15], uint16(r.KeySize))
-			binary.BigEndian.PutUint16(buffer[15:15+r.KeySize], uint16(index))
-		} else {
-			binary.BigEndian.PutUint64(buffer[13:21], uint64(r.KeySize))
-			copy(buffer[21:21+r.KeySize], []byte(r.Key))
+	if flags&FlagFamily != 0 {
+		family, err := readUvarint(data, &offset)
+		if err != nil {
+			return r, 0, err
 		}
-		return buffer
-	}
-	if cfg.Compress {
-		index := putElementToMap(r.Key, keyDictionary)
-		indexBytes := make([]byte, 2)
-		binary.BigEndian.PutUint16(indexBytes, uint16(index))
-		binary.BigEndian.PutUint16(buffer[13:15], uint16(r.KeySize))
-		binary.BigEndian.PutUint64(buffer[15:23], uint64(r.ValueSize))
-		binary.BigEndian.PutUint16(buffer[23:23+r.KeySize], uint16(index))
-		copy(buffer[23+r.KeySize:bufferSize], r.Value)
-	} else {
-		binary.BigEndian.PutUint64(buffer[13:21], uint64(r.KeySize))
-		binary.BigEndian.PutUint64(buffer[21:29], uint64(r.ValueSize))
-		copy(buffer[29:29+r.KeySize], []byte(r.Key))
-		copy(buffer[29+r.KeySize:bufferSize], r.Value)
+		r.Family = uint32(family)
 	}
-	return buffer
+	return r, offset, nil
+}
+
+func readUvarint(data []byte, offset *int) (uint64, error) {
+	value, n := binary.Uvarint(data[*offset:])
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	*offset += n
+	return value, nil
+}
+
+// verzija zapisa koji pocinje na pocetku data
+func DetectVersion(data []byte) (byte, error) {
+	if len(data) < 5 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if data[4] == 0 {
+		return VersionV1, nil
+	}
+	return data[4], nil
+}
+
+// dekodira zapis v1 ili v2 sa pocetka data i vraca broj procitanih bajtova.
+// Za zapis sa pogresnim crc-om vraca ErrChecksumMismatch i njegovu duzinu,
+// a za nepotpun zapis io.ErrUnexpectedEOF.
+func DecodeRecord(data []byte) (Record, int, error) {
+	version, err := DetectVersion(data)
+	if err != nil {
+		return Record{}, 0, err
+	}
+	switch version {
+	case VersionV1:
+		return decodeV1(data)
+	case VersionV2:
+		return decodeV2(data)
+	}
+	return Record{}, 0, errors.New("unknown record version")
+}
+
+func decodeV1(data []byte) (Record, int, error) {
+	if len(data) < 29 {
+		return Record{}, 0, io.ErrUnexpectedEOF
+	}
+	crc := binary.BigEndian.Uint32(data[0:4])
+	timestamp := int64(binary.BigEndian.Uint64(data[4:12]))
+	tombstone := data[12] == 1
+	keySize := binary.BigEndian.Uint64(data[13:21])
+	valueSize := binary.BigEndian.Uint64(data[21:29])
+	if keySize > uint64(len(data)-29) || valueSize > uint64(len(data)-29)-keySize {
+		return Record{}, 0, io.ErrUnexpectedEOF
+	}
+	n := 29 + int(keySize) + int(valueSize)
+	key := string(data[29 : 29+keySize])
+	value := append([]byte(nil), data[29+keySize:n]...)
+
+	if CalculateCRC(timestamp, tombstone, int64(keySize), int64(valueSize), key, value) != crc {
+		return Record{}, n, ErrChecksumMismatch
+	}
+	return *LoadRecord(crc, timestamp, tombstone, int64(keySize), int64(valueSize), key, value), n, nil
+}
+
+func decodeV2(data []byte) (Record, int, error) {
+	r, offset, err := ReadHeader(data[4:])
+	if err != nil {
+		return Record{}, 0, err
+	}
+	offset += 4
+	keySize, err := readUvarint(data, &offset)
+	if err != nil {
+		return Record{}, 0, err
+	}
+	valueSize, err := readUvarint(data, &offset)
+	if err != nil {
+		return Record{}, 0, err
+	}
+	if keySize > uint64(len(data)-offset) || valueSize > uint64(len(data)-offset)-keySize {
+		return Record{}, 0, io.ErrUnexpectedEOF
+	}
+	n := offset + int(keySize) + int(valueSize)
+
+	r.Crc32 = binary.BigEndian.Uint32(data[0:4])
+	if !ValidChecksum(data[4:n], r.Crc32) {
+		return Record{}, n, ErrChecksumMismatch
+	}
+	r.KeySize = int64(keySize)
+	r.ValueSize = int64(valueSize)
+	r.Key = string(data[offset : offset+int(keySize)])
+	r.Value = append([]byte(nil), data[offset+int(keySize):n]...)
+	return r, n, nil
 }
 
 func GetNewerRecord(record1, record2 Record) Record {
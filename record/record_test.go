@@ -0,0 +1,102 @@
+package record
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	tests := []Record{
+		{Key: "key", Value: []byte("value"), Timestamp: 1700000000},
+		{Key: "deleted", Tombstone: true, Timestamp: 1700000000, SeqNum: 1 << 62},
+		{Key: "", Value: []byte{}, Timestamp: 1},
+		{Key: "expiring", Value: []byte("v"), Timestamp: 1700000000, SeqNum: 7, Expiry: 1800000000},
+		{Key: "a", Value: []byte("z"), RangeTombstone: true, Tombstone: true, Timestamp: 2, SeqNum: 3},
+		{Key: "counter", Value: AppendOperand(nil, []byte("1")), Merge: true, Batch: true, Family: 300, Timestamp: 4},
+	}
+	for _, want := range tests {
+		data := want.ToBytes()
+		if version, err := DetectVersion(data); err != nil || version != VersionV2 {
+			t.Fatalf("%q: version %d, %v, want v2", want.Key, version, err)
+		}
+		got, n, err := DecodeRecord(data)
+		if err != nil {
+			t.Fatalf("%q: %v", want.Key, err)
+		}
+		if n != len(data) {
+			t.Fatalf("%q: read %d of %d bytes", want.Key, n, len(data))
+		}
+		want.Crc32 = got.Crc32
+		want.KeySize = int64(len(want.Key))
+		want.ValueSize = int64(len(want.Value))
+		if len(want.Value) == 0 {
+			want.Value = nil
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("decoded %+v, want %+v", got, want)
+		}
+
+		// nepotpun zapis i zapis sa promenjenim bajtom
+		_, _, err = DecodeRecord(data[:len(data)-1])
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("%q: truncated record: %v", want.Key, err)
+		}
+		damaged := append([]byte(nil), data...)
+		damaged[0] ^= 1
+		_, _, err = DecodeRecord(damaged)
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("%q: damaged record: %v, want ErrChecksumMismatch", want.Key, err)
+		}
+	}
+}
+
+// v1 zapisi iz starih WAL segmenata i SSTabela se i dalje citaju
+func TestDecodeV1(t *testing.T) {
+	key, value := "key", []byte("value")
+	data := make([]byte, 29, 29+len(key)+len(value))
+	crc := CalculateCRC(1700000000, false, int64(len(key)), int64(len(value)), key, value)
+	binary.BigEndian.PutUint32(data[0:4], crc)
+	binary.BigEndian.PutUint64(data[4:12], 1700000000)
+	binary.BigEndian.PutUint64(data[13:21], uint64(len(key)))
+	binary.BigEndian.PutUint64(data[21:29], uint64(len(value)))
+	data = append(data, key...)
+	data = append(data, value...)
+
+	if version, err := DetectVersion(data); err != nil || version != VersionV1 {
+		t.Fatalf("version %d, %v, want v1", version, err)
+	}
+	r, n, err := DecodeRecord(data)
+	if err != nil || n != len(data) {
+		t.Fatalf("DecodeRecord = %d, %v", n, err)
+	}
+	if r.Key != key || string(r.Value) != string(value) || r.Timestamp != 1700000000 || r.Tombstone {
+		t.Fatalf("decoded %+v", r)
+	}
+	data[len(data)-1] ^= 1
+	if _, _, err = DecodeRecord(data); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("damaged v1 record: %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		expiry  int64
+		expired bool
+	}{
+		{0, false},
+		{now.Unix() - 1, true},
+		{now.Unix(), true},
+		{now.Unix() + 1, false},
+	}
+	for _, tt := range tests {
+		r := Record{Key: "k", Expiry: tt.expiry}
+		if r.Expired(now) != tt.expired {
+			t.Errorf("expiry %d: Expired = %v, want %v", tt.expiry, !tt.expired, tt.expired)
+		}
+	}
+}
@@ -2,13 +2,34 @@ package simhash
 
 import (
 	"crypto/md5"
+	"encoding/hex"
 	"fmt"
+	"main/envelope"
 	"strings"
 )
 
 const HASH_SIZE = 64
 
-func LoadFromBytes(data []byte) []int {
+// verzija formata koji ToBytes upisuje
+const formatVersion = 1
+
+// cita fingerprint upisan sa ToBytes, ili bez zaglavlja iz starijih verzija
+func LoadFromBytes(data []byte) ([]int, error) {
+	// stari zapisi su samo bajtovi fingerprinta
+	if len(data) != HASH_SIZE/8 {
+		version, payload, err := envelope.Unwrap(envelope.SimHash, data)
+		if err != nil {
+			return nil, err
+		}
+		if version != formatVersion {
+			return nil, envelope.UnsupportedVersion(version)
+		}
+		if len(payload) != HASH_SIZE/8 {
+			return nil, envelope.ErrMalformed
+		}
+		data = payload
+	}
+
 	fingerprint := make([]int, HASH_SIZE)
 
 	for i, b := range data {
@@ -17,10 +38,19 @@ func LoadFromBytes(data []byte) []int {
 		}
 	}
 
-	return fingerprint
+	return fingerprint, nil
 }
 
 func ToBytes(fingerprint []int) []byte {
+	return envelope.Wrap(envelope.SimHash, formatVersion, pack(fingerprint))
+}
+
+// fingerprint zapisan heksadecimalno
+func ToHex(fingerprint []int) string {
+	return hex.EncodeToString(pack(fingerprint))
+}
+
+func pack(fingerprint []int) []byte {
 	byteSlice := make([]byte, HASH_SIZE/8)
 
 	for i := 0; i < HASH_SIZE; i++ {
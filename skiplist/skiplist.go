@@ -10,6 +10,7 @@ import (
 type Node struct {
 	Record *record.Record
 	next   []*Node
+	prev   *Node // veza unazad na nultom nivou
 }
 
 func newNode(record record.Record, level int) *Node {
@@ -57,6 +58,13 @@ func (sl *SkipList) Insert(record record.Record) {
 		new.next[i] = current.next[i]
 		current.next[i] = new
 	}
+
+	if current != sl.head {
+		new.prev = current
+	}
+	if new.next[0] != nil {
+		new.next[0].prev = new
+	}
 }
 
 func (sl *SkipList) Delete(key string) (bool, error) {
@@ -71,6 +79,9 @@ func (sl *SkipList) Delete(key string) (bool, error) {
 		}
 		current.next[i] = nodeToDel.next[i]
 	}
+	if nodeToDel.next[0] != nil {
+		nodeToDel.next[0].prev = nodeToDel.prev
+	}
 	return true, nil
 }
 
@@ -96,3 +107,40 @@ func (sl *SkipList) GetRecords() []record.Record {
 
 	return elements
 }
+
+func (sl *SkipList) First() *Node {
+	return sl.head.next[0]
+}
+
+func (n *Node) Next() *Node {
+	return n.next[0]
+}
+
+func (sl *SkipList) Last() *Node {
+	current := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for current.next[i] != nil {
+			current = current.next[i]
+		}
+	}
+	if current == sl.head {
+		return nil
+	}
+	return current
+}
+
+// poslednji cvor sa kljucem manjim ili jednakim zadatom
+func (sl *SkipList) SearchForPrev(key string) *Node {
+	node, found := sl.Search(key)
+	if found {
+		return node
+	}
+	if node == nil {
+		return sl.Last()
+	}
+	return node.prev
+}
+
+func (n *Node) Prev() *Node {
+	return n.prev
+}
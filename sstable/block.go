@@ -0,0 +1,279 @@
+package sstable
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	keydictionary "main/keyDictionary"
+	"main/record"
+)
+
+// Zapis u data fajlu (v2):
+// crc (4) | zaglavlje zapisa v2 (verzija, flegovi, timestamp, seq broj, istek) |
+// deljeni deo kljuca (uvarint) | ostatak kljuca (uvarint) | velicina vrednosti (uvarint, nema je kod tombstone-a) |
+// ostatak kljuca | vrednost
+// crc (crc32c) pokriva sve bajtove zapisa posle njega.
+//
+// Zapis v1 umesto zaglavlja ima crc logickog zapisa | timestamp (8) | tombstone (1),
+// verzije se razlikuju isto kao kod zapisa u WAL-u.
+//
+// Kljuc se cuva samo kao razlika u odnosu na prethodni kljuc u bloku. Prvi zapis
+// svakog bloka (restart tacka) ima ceo kljuc, i na njega pokazuje ulaz u indeksu,
+// pa se svaki blok moze citati nezavisno od ostalih.
+
+// duzina zajednickog prefiksa dva kljuca
+func sharedPrefixLength(a, b []byte) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// ako je prevKey nil, zapis je restart tacka i kljuc se pise ceo
+func appendEntry(dst []byte, r *record.Record, key, prevKey []byte) []byte {
+	start := len(dst)
+	shared := sharedPrefixLength(key, prevKey)
+	dst = append(dst, 0, 0, 0, 0)
+	dst = r.AppendHeader(dst)
+	dst = binary.AppendUvarint(dst, uint64(shared))
+	dst = binary.AppendUvarint(dst, uint64(len(key)-shared))
+	if !r.Tombstone {
+		dst = binary.AppendUvarint(dst, uint64(len(r.Value)))
+	}
+	dst = append(dst, key[shared:]...)
+	if !r.Tombstone {
+		dst = append(dst, r.Value...)
+	}
+	binary.BigEndian.PutUint32(dst[start:start+4], record.Checksum(dst[start+4:]))
+	return dst
+}
+
+// dekodira zapise jednog ili vise uzastopnih blokova, pamti prethodni kljuc
+type entryDecoder struct {
+	keyDictionary *keydictionary.KeyDictionary
+	prevKey       []byte
+}
+
+func readUvarint(data []byte, offset *int) (uint64, error) {
+	value, n := binary.Uvarint(data[*offset:])
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	*offset += n
+	return value, nil
+}
+
+// dekodira zapis sa pocetka data i vraca broj procitanih bajtova,
+// za zapis sa pogresnim crc-om vraca record.ErrChecksumMismatch
+func (d *entryDecoder) decode(data []byte) (record.Record, int, error) {
+	version, err := record.DetectVersion(data)
+	if err != nil {
+		return record.Record{}, 0, err
+	}
+
+	var rec record.Record
+	offset := 4
+	switch version {
+	case record.VersionV1:
+		if len(data) < 13 {
+			return record.Record{}, 0, io.ErrUnexpectedEOF
+		}
+		rec.Timestamp = int64(binary.BigEndian.Uint64(data[4:12]))
+		rec.Tombstone = data[12] == 1
+		offset = 13
+	case record.VersionV2:
+		var n int
+		rec, n, err = record.ReadHeader(data[4:])
+		if err != nil {
+			return record.Record{}, 0, err
+		}
+		offset += n
+	default:
+		return record.Record{}, 0, errors.New("unknown sstable entry version")
+	}
+	rec.Crc32 = binary.BigEndian.Uint32(data[0:4])
+
+	shared, err := readUvarint(data, &offset)
+	if err != nil {
+		return record.Record{}, 0, err
+	}
+	unshared, err := readUvarint(data, &offset)
+	if err != nil {
+		return record.Record{}, 0, err
+	}
+	var valueSize uint64
+	if !rec.Tombstone {
+		valueSize, err = readUvarint(data, &offset)
+		if err != nil {
+			return record.Record{}, 0, err
+		}
+	}
+	if shared > uint64(len(d.prevKey)) || unshared > uint64(len(data)-offset) || valueSize > uint64(len(data)-offset)-unshared {
+		return record.Record{}, 0, io.ErrUnexpectedEOF
+	}
+
+	keyBytes := make([]byte, 0, shared+unshared)
+	keyBytes = append(keyBytes, d.prevKey[:shared]...)
+	keyBytes = append(keyBytes, data[offset:offset+int(unshared)]...)
+	offset += int(unshared)
+	d.prevKey = keyBytes
+
+	if !rec.Tombstone {
+		rec.Value = make([]byte, valueSize)
+		copy(rec.Value, data[offset:offset+int(valueSize)])
+		offset += int(valueSize)
+	}
+
+	rec.Key = decodeKey(keyBytes, d.keyDictionary)
+	rec.KeySize = int64(len(rec.Key))
+	rec.ValueSize = int64(valueSize)
+
+	var valid bool
+	if version == record.VersionV1 {
+		// crc v1 zapisa je racunat nad logickim zapisom
+		valid = record.CalculateCRC(rec.Timestamp, rec.Tombstone, int64(len(keyBytes)), rec.ValueSize, rec.Key, rec.Value) == rec.Crc32
+	} else {
+		valid = record.ValidChecksum(data[4:offset], rec.Crc32)
+	}
+	if !valid {
+		return record.Record{}, offset, record.ErrChecksumMismatch
+	}
+	return rec, offset, nil
+}
+
+func corruption(path string, offset int64, err error) error {
+	return &record.ErrCorruption{File: path, Offset: offset, Err: err}
+}
+
+// dekodira sve zapise bloka koji pocinje na blockOffset u fajlu path
+func decodeBlock(data []byte, path string, blockOffset int64, keyDictionary *keydictionary.KeyDictionary) ([]record.Record, error) {
+	decoder := entryDecoder{keyDictionary: keyDictionary}
+	var records []record.Record
+	for offset := 0; offset < len(data); {
+		rec, n, err := decoder.decode(data[offset:])
+		if err != nil {
+			return nil, corruption(path, blockOffset+int64(offset), err)
+		}
+		records = append(records, rec)
+		offset += n
+	}
+	return records, nil
+}
+
+// Ulaz indeksa: deljeni deo kljuca (uvarint) | ostatak kljuca (uvarint) | ostatak kljuca |
+// offset bloka (uvarint) | velicina bloka (uvarint)
+// Restart tacke indeksa su ulazi na koje pokazuje summary.
+
+func appendIndexEntry(dst []byte, key, prevKey []byte, offset, size int64) []byte {
+	shared := sharedPrefixLength(key, prevKey)
+	dst = binary.AppendUvarint(dst, uint64(shared))
+	dst = binary.AppendUvarint(dst, uint64(len(key)-shared))
+	dst = append(dst, key[shared:]...)
+	dst = binary.AppendUvarint(dst, uint64(offset))
+	return binary.AppendUvarint(dst, uint64(size))
+}
+
+// dekodira deo indeksa koji pocinje na regionOffset u fajlu path
+func decodeIndex(data []byte, path string, regionOffset int64, keyDictionary *keydictionary.KeyDictionary) ([]IndexEntry, error) {
+	var index []IndexEntry
+	var prevKey []byte
+	offset := 0
+	for offset < len(data) {
+		entryOffset := regionOffset + int64(offset)
+		shared, err := readUvarint(data, &offset)
+		if err != nil {
+			return nil, corruption(path, entryOffset, err)
+		}
+		unshared, err := readUvarint(data, &offset)
+		if err != nil {
+			return nil, corruption(path, entryOffset, err)
+		}
+		if shared > uint64(len(prevKey)) || unshared > uint64(len(data)-offset) {
+			return nil, corruption(path, entryOffset, io.ErrUnexpectedEOF)
+		}
+		keyBytes := make([]byte, 0, shared+unshared)
+		keyBytes = append(keyBytes, prevKey[:shared]...)
+		keyBytes = append(keyBytes, data[offset:offset+int(unshared)]...)
+		offset += int(unshared)
+		prevKey = keyBytes
+
+		blockOffset, err := readUvarint(data, &offset)
+		if err != nil {
+			return nil, corruption(path, entryOffset, err)
+		}
+		blockSize, err := readUvarint(data, &offset)
+		if err != nil {
+			return nil, corruption(path, entryOffset, err)
+		}
+
+		index = append(index, IndexEntry{key: decodeKey(keyBytes, keyDictionary), offset: int64(blockOffset), size: int64(blockSize)})
+	}
+	return index, nil
+}
+
+// Ulaz summary-ja: duzina prvog kljuca (uvarint) | prvi kljuc | duzina poslednjeg kljuca (uvarint) |
+// poslednji kljuc | offset dela indeksa (uvarint) | velicina dela indeksa (uvarint)
+
+func appendSummaryEntry(dst []byte, firstKey, lastKey []byte, offset, size int64) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(firstKey)))
+	dst = append(dst, firstKey...)
+	dst = binary.AppendUvarint(dst, uint64(len(lastKey)))
+	dst = append(dst, lastKey...)
+	dst = binary.AppendUvarint(dst, uint64(offset))
+	return binary.AppendUvarint(dst, uint64(size))
+}
+
+func decodeSummary(data []byte, path string, keyDictionary *keydictionary.KeyDictionary) ([]SummaryEntry, error) {
+	var summary []SummaryEntry
+	offset := 0
+	readKey := func() (string, error) {
+		size, err := readUvarint(data, &offset)
+		if err != nil {
+			return "", err
+		}
+		if size > uint64(len(data)-offset) {
+			return "", io.ErrUnexpectedEOF
+		}
+		key := decodeKey(data[offset:offset+int(size)], keyDictionary)
+		offset += int(size)
+		return key, nil
+	}
+
+	for offset < len(data) {
+		entryOffset := int64(offset)
+		firstKey, err := readKey()
+		if err != nil {
+			return nil, corruption(path, entryOffset, err)
+		}
+		lastKey, err := readKey()
+		if err != nil {
+			return nil, corruption(path, entryOffset, err)
+		}
+		indexOffset, err := readUvarint(data, &offset)
+		if err != nil {
+			return nil, corruption(path, entryOffset, err)
+		}
+		indexSize, err := readUvarint(data, &offset)
+		if err != nil {
+			return nil, corruption(path, entryOffset, err)
+		}
+		summary = append(summary, SummaryEntry{firstKey: firstKey, lastKey: lastKey, offset: int64(indexOffset), size: int64(indexSize)})
+	}
+	return summary, nil
+}
+
+// kljuc kako je upisan u tabelu, kod kompresije je to id iz recnika
+func encodeKey(key string, keyDictionary *keydictionary.KeyDictionary) ([]byte, error) {
+	if keyDictionary != nil {
+		return keyDictionary.EncodeKey(key)
+	}
+	return []byte(key), nil
+}
+
+func decodeKey(keyBytes []byte, keyDictionary *keydictionary.KeyDictionary) string {
+	if keyDictionary != nil {
+		return keyDictionary.DecodeKey(keyBytes)
+	}
+	return string(keyBytes)
+}
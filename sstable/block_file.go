@@ -0,0 +1,123 @@
+package sstable
+
+import (
+	"errors"
+	"io"
+	"main/cache"
+	"os"
+)
+
+var blockCache *cache.BlockCache
+
+// postavlja deljeni kes blokova koji koriste sva citanja iz SSTabela
+func SetBlockCache(bc *cache.BlockCache) {
+	blockCache = bc
+}
+
+// izbacuje iz kesa blokove fajla koji je obrisan ili se ponovo pise
+func InvalidateBlockCache(path string) {
+	if blockCache != nil {
+		blockCache.EvictTable(path)
+	}
+}
+
+// fajl SSTabele koji se cita blok po blok preko kesa blokova
+type blockFile struct {
+	file   *os.File
+	path   string
+	size   int64
+	offset int64
+}
+
+func openBlockFile(path string) (*blockFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &blockFile{file: f, path: path, size: stat.Size()}, nil
+}
+
+func (bf *blockFile) Read(p []byte) (int, error) {
+	if bf.offset >= bf.size {
+		return 0, io.EOF
+	}
+
+	if blockCache == nil {
+		n, err := bf.file.ReadAt(p, bf.offset)
+		bf.offset += int64(n)
+		if err == io.EOF && n > 0 {
+			err = nil
+		}
+		return n, err
+	}
+
+	n := 0
+	for n < len(p) && bf.offset < bf.size {
+		blockSize := int64(blockCache.BlockSize())
+		blockStart := bf.offset - bf.offset%blockSize
+
+		block, err := bf.readBlock(blockStart)
+		if err != nil {
+			return n, err
+		}
+
+		copied := copy(p[n:], block[bf.offset-blockStart:])
+		if copied == 0 {
+			break
+		}
+		n += copied
+		bf.offset += int64(copied)
+	}
+
+	return n, nil
+}
+
+func (bf *blockFile) readBlock(blockStart int64) ([]byte, error) {
+	key := cache.BlockKey{Table: bf.path, Offset: blockStart}
+	if block, found := blockCache.Get(key); found {
+		return block, nil
+	}
+
+	length := int64(blockCache.BlockSize())
+	if blockStart+length > bf.size {
+		length = bf.size - blockStart
+	}
+
+	block := make([]byte, length)
+	_, err := bf.file.ReadAt(block, blockStart)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	blockCache.Set(key, block)
+	return block, nil
+}
+
+func (bf *blockFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += bf.offset
+	case io.SeekEnd:
+		offset += bf.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	bf.offset = offset
+	return offset, nil
+}
+
+func (bf *blockFile) Close() error {
+	return bf.file.Close()
+}
@@ -0,0 +1,41 @@
+package sstable
+
+import (
+	"errors"
+	"main/record"
+	"os"
+)
+
+// Brisanja opsega tabele se cuvaju u posebnom fajlu lvl_L_sstable_rangedel_N.db
+// kao niz zapisa u formatu v2, fajl postoji samo ako tabela ima brisanja opsega.
+// Brisanje opsega pokriva zapise starijih tabela, a zapisi iz iste tabele su
+// noviji od njega.
+
+func encodeRangeTombstones(rangeTombstones []record.Record) []byte {
+	var data []byte
+	for _, rangeTombstone := range rangeTombstones {
+		data = append(data, rangeTombstone.ToBytes()...)
+	}
+	return data
+}
+
+func loadRangeTombstones(directory string, level, fileNumber int) ([]record.Record, error) {
+	path := tablePath(directory, level, fileNumber, "rangedel")
+	data, err := readRegion(path, 0, -1)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var rangeTombstones []record.Record
+	for offset := 0; offset < len(data); {
+		rangeTombstone, n, err := record.DecodeRecord(data[offset:])
+		if err != nil {
+			return nil, corruption(path, int64(offset), err)
+		}
+		rangeTombstones = append(rangeTombstones, rangeTombstone)
+		offset += n
+	}
+	return rangeTombstones, nil
+}
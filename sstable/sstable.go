@@ -1,12 +1,11 @@
 package sstable
 
 import (
-	"encoding/binary"
 	"errors"
-	"fmt"
 	"io"
 	"main/bloom-filter"
 	"main/config"
+	keydictionary "main/keyDictionary"
 	"main/merkle"
 	"main/record"
 	"os"
@@ -16,290 +15,222 @@ import (
 )
 
 type SSTable struct {
-	filter        *bloom.BloomFilter
-	metadata      *merkle.MerkleTree
-	config        *config.Config
-	keyDictionary *map[int]string
+	filter          *bloom.BloomFilter
+	metadata        *merkle.MerkleTree
+	rangeTombstones []record.Record
 }
 
 type IndexEntry struct {
 	key    string
 	offset int64 // offset sa kog citamo iz data
+	size   int64 // velicina bloka u data fajlu
 }
 
 type SummaryEntry struct {
 	firstKey string
 	lastKey  string
 	offset   int64 //  offset s kog citamo iz indexa
+	size     int64 // velicina dela indeksa
 }
 
-func LoadSSTable(sstLevel int, fileNumber int, keyDicitonary *map[int]string) (*SSTable, error) {
-	cfg := new(config.Config)
-	err := config.LoadConfig(cfg)
-	if err != nil {
-		return nil, err
+// putanja do recnika kljuceva tabele
+func DictionaryPath(directory string, level, fileNumber int) string {
+	return tablePath(directory, level, fileNumber, "dictionary")
+}
+
+// ucitava recnik kljuceva tabele, tabela bez recnika nije kompresovana
+func LoadTableDictionary(directory string, level, fileNumber int) (*keydictionary.KeyDictionary, error) {
+	keyDictionary, err := keydictionary.ReadKeyDictionary(DictionaryPath(directory, level, fileNumber))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
 	}
+	return keyDictionary, err
+}
 
-	allRecords, err := record.LoadRecordsFromFile(config.SSTABLE_DIRECTORY+"lvl_"+strconv.Itoa(sstLevel)+"_sstable_data_"+strconv.Itoa(fileNumber)+".db", keyDicitonary)
-	if allRecords == nil {
-		return nil, err
+// Tabele pisane pre uvodjenja recnika po tabeli su sa kompresijom koristile
+// globalni recnik. On se kopira uz svaku tabelu koja nema svoj recnik, pa se
+// pri citanju kompresija odredjuje samo po tome da li tabela ima recnik.
+// Bez kompresije globalni recnik ostaje, tabele se citaju kao nekompresovane.
+func MigrateKeyDictionary(directory string, compress bool) error {
+	data, err := os.ReadFile(config.KEY_DICTIONARY_FILE_PATH)
+	if errors.Is(err, os.ErrNotExist) || err == nil && !compress {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, table := range findSSTables(directory) {
+		path := DictionaryPath(directory, table[0], table[1])
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		// prekinuto kopiranje ne sme ostaviti nepotpun recnik
+		err = os.WriteFile(path+".tmp", data, 0644)
+		if err != nil {
+			return err
+		}
+		err = os.Rename(path+".tmp", path)
+		if err != nil {
+			return err
+		}
 	}
+	return os.Remove(config.KEY_DICTIONARY_FILE_PATH)
+}
+
+func LoadSSTable(directory string, sstLevel int, fileNumber int) (*SSTable, error) {
+	data, err := os.ReadFile(tablePath(directory, sstLevel, fileNumber, "data"))
 	if err != nil {
 		return nil, err
 	}
 
+	// listovi merkle stabla su zapisi onako kako su upisani u data fajl
 	var allRecordsBytes [][]byte
-	for _, record := range allRecords {
-		allRecordsBytes = append(allRecordsBytes, record.ToBytesSSTable(keyDicitonary))
+	decoder := entryDecoder{}
+	for offset := 0; offset < len(data); {
+		_, n, err := decoder.decode(data[offset:])
+		if err != nil {
+			return nil, corruption(tablePath(directory, sstLevel, fileNumber, "data"), int64(offset), err)
+		}
+		allRecordsBytes = append(allRecordsBytes, data[offset:offset+n])
+		offset += n
 	}
 	mtNew := merkle.NewMerkleTree(allRecordsBytes)
 
-	mtFile := merkle.ReadFromBinFile(config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(sstLevel) + "_sstable_metadata_" + strconv.Itoa(fileNumber) + ".bin")
+	mtFile := merkle.ReadFromBinFile(tablePath(directory, sstLevel, fileNumber, "metadata"))
 	mtFileNode := merkle.DeserializeMerkleTree(mtFile)
 	check := merkle.CompareMerkleTrees(mtFileNode, mtNew.Root)
-	if !check && !cfg.Compress {
+	if !check {
 		return nil, errors.New("data has been altered")
 	}
 
-	bf := bloom.LoadBloomFilter(config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(sstLevel) + "_sstable_filter_" + strconv.Itoa(fileNumber) + ".bin")
-
-	sst := new(SSTable)
-	sst.filter = bf
+	sst, err := openSSTable(directory, sstLevel, fileNumber)
+	if err != nil {
+		return nil, err
+	}
 	sst.metadata = mtNew
 
 	return sst, nil
 }
 
-func NewSSTable(allRecords []record.Record, config *config.Config, level int, keyDictionary *map[int]string) (*SSTable, error) {
-	sst := new(SSTable)
-	config.NumberOfSSTables++
-	sst.keyDictionary = keyDictionary
-	sst.config = config
-
-	sst.writeDataIndexSummary(allRecords, level)
-	sst.createFilter(allRecords, level)
-	sst.createMetaData(allRecords, level)
-
-	err := config.WriteConfig()
+// ucitava samo filter i brisanja opsega tabele, bez data fajla i merkle stabla,
+// sto je dovoljno za pretragu kljuca
+func openSSTable(directory string, level, fileNumber int) (*SSTable, error) {
+	filterPath := tablePath(directory, level, fileNumber, "filter")
+	data, err := readRegion(filterPath, 0, -1)
 	if err != nil {
 		return nil, err
 	}
+	bf, err := bloom.FromBytes(data)
+	if err != nil {
+		return nil, corruption(filterPath, 0, err)
+	}
 
-	return sst, nil
-}
-
-func (s *SSTable) writeDataIndexSummary(allRecords []record.Record, level int) {
-	count := 0
-	offset := 0
-
-	var index []IndexEntry
-
-	for i, record := range allRecords {
-		// ovde se pravi data, upisujem sve rekorde
-		s.WriteRecord(&record, config.SSTABLE_DIRECTORY+"lvl_"+strconv.Itoa(level)+"_sstable_data_"+strconv.Itoa(s.config.NumberOfSSTables)+".db")
-
-		if count%s.config.IndexInterval == 0 {
-			index = append(index, IndexEntry{key: record.Key, offset: int64(offset)})
-		} else if i+1 == len(allRecords) {
-			index = append(index, IndexEntry{key: record.Key, offset: int64(offset)})
-		}
-		count++
-		offset += len(record.ToBytesSSTable(s.keyDictionary))
+	rangeTombstones, err := loadRangeTombstones(directory, level, fileNumber)
+	if err != nil {
+		return nil, err
 	}
 
-	s.writeIndex(index, level)
-	summary := s.buildSummary(index)
-	s.writeSummaryToFile(summary, level)
+	sst := new(SSTable)
+	sst.filter = bf
+	sst.rangeTombstones = rangeTombstones
+	return sst, nil
 }
 
-func findKeyByValue(myMap map[int]string, targetValue string) (int, bool) {
-	for key, value := range myMap {
-		if value == targetValue {
-			return key, true
-		}
-	}
-	return 0, false
-}
+func NewSSTable(allRecords []record.Record, rangeTombstones []record.Record, config *config.Config, level int) (*SSTable, error) {
+	config.NumberOfSSTables++
 
-func (s *SSTable) writeIndex(index []IndexEntry, level int) {
-	f, err := os.OpenFile(config.SSTABLE_DIRECTORY+"lvl_"+strconv.Itoa(level)+"_sstable_index_"+strconv.Itoa(s.config.NumberOfSSTables)+".db", os.O_CREATE|os.O_APPEND, 0644)
+	w, err := NewWriter(config, level, config.NumberOfSSTables)
 	if err != nil {
-		fmt.Println("Error opening or creating index file:", err)
-		return
+		return nil, err
 	}
-	defer f.Close()
-
-	for _, entry := range index {
-		var keyBytes []byte
-		if s.config.Compress {
-			keyBytes = make([]byte, 2)
-			keyInt, _ := findKeyByValue(*s.keyDictionary, entry.key)
-			binary.BigEndian.PutUint16(keyBytes, uint16(keyInt))
-		} else {
-			keyBytes = []byte(entry.key)
-		}
-
-		if entry.key == "76" {
-			fmt.Printf("entry.key: %v\n", entry.key)
-		}
-
-		var err error
-		if s.config.Compress {
-			err = binary.Write(f, binary.BigEndian, int16(len(keyBytes)))
-		} else {
-			err = binary.Write(f, binary.BigEndian, int64(len(keyBytes)))
-		}
-		if err != nil {
-			fmt.Println("Error writing key size:", err)
-			return
-		}
-		_, err = f.Write(keyBytes)
-		if err != nil {
-			fmt.Println("Error writing key:", err)
-			return
-		}
-
-		err = binary.Write(f, binary.BigEndian, entry.offset)
+	for _, record := range allRecords {
+		err = w.Add(record)
 		if err != nil {
-			fmt.Println("Error writing offset:", err)
-			return
+			w.Abort()
+			return nil, err
 		}
 	}
-}
-
-func (s *SSTable) buildSummary(index []IndexEntry) []SummaryEntry {
-	var summary []SummaryEntry
-
-	offset := 0
-
-	for i := 0; i < len(index); i += s.config.SummaryInterval {
-		endIndex := i + s.config.SummaryInterval - 1
-
-		if endIndex >= len(index) {
-			endIndex = len(index) - 1
-		}
-
-		summaryEntry := SummaryEntry{
-			firstKey: index[i].key,
-			lastKey:  index[endIndex].key,
-			offset:   int64(offset),
-		}
-
-		if s.config.Compress {
-			firstKeyBytes := make([]byte, 2)
-			keyInt, _ := findKeyByValue(*s.keyDictionary, index[i].key)
-			binary.BigEndian.PutUint16(firstKeyBytes, uint16(keyInt))
-
-			lastKeyBytes := make([]byte, 2)
-			keyInt, _ = findKeyByValue(*s.keyDictionary, index[endIndex].key)
-			binary.BigEndian.PutUint16(lastKeyBytes, uint16(keyInt))
+	for _, rangeTombstone := range rangeTombstones {
+		w.AddRangeTombstone(rangeTombstone)
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
 
-			offset = 8 + len(firstKeyBytes) + len(lastKeyBytes)
-		} else {
-			offset = 16 + len([]byte(index[i].key))
-		}
-		summary = append(summary, summaryEntry)
+	err = config.WriteConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	return summary
+	return LoadSSTable(config.SSTableDirectory(), level, config.NumberOfSSTables)
 }
 
-func (s *SSTable) writeSummaryToFile(summary []SummaryEntry, level int) {
-	f, err := os.OpenFile(config.SSTABLE_DIRECTORY+"lvl_"+strconv.Itoa(level)+"_sstable_summary_"+strconv.Itoa(s.config.NumberOfSSTables)+".db", os.O_CREATE|os.O_APPEND, 0644)
+// trazi najnoviju verziju kljuca, od najnovije tabele. Ako je kljuc obuhvacen
+// brisanjem opsega vraca se tombstone.
+func Search(directory, key string) (*record.Record, error) {
+	var newest *record.Record
+	err := SearchVersions(directory, key, func(version *record.Record) bool {
+		newest = version
+		return false
+	})
 	if err != nil {
-		fmt.Println("Error opening or creating summary file:", err)
-		return
+		return nil, err
 	}
-	defer f.Close()
-
-	for _, entry := range summary {
-		var firstKeyBytes []byte
-		var lastKeyBytes []byte
-
-		if s.config.Compress {
-			firstKeyBytes = make([]byte, 2)
-			keyInt, _ := findKeyByValue(*s.keyDictionary, entry.firstKey)
-			binary.BigEndian.PutUint16(firstKeyBytes, uint16(keyInt))
-
-			lastKeyBytes = make([]byte, 2)
-			keyInt, _ = findKeyByValue(*s.keyDictionary, entry.lastKey)
-			binary.BigEndian.PutUint16(lastKeyBytes, uint16(keyInt))
-		} else {
-			firstKeyBytes = []byte(entry.firstKey)
-			lastKeyBytes = []byte(entry.lastKey)
-		}
-
-		if s.config.Compress {
-			err = binary.Write(f, binary.BigEndian, int16(len(firstKeyBytes)))
-		} else {
-			err = binary.Write(f, binary.BigEndian, int64(len(firstKeyBytes)))
-		}
-		if err != nil {
-			fmt.Println("Error writing first key size:", err)
-			return
-		}
+	if newest == nil {
+		return nil, record.ErrNotFound
+	}
+	return newest, nil
+}
 
-		_, err = f.Write(firstKeyBytes)
+// prolazi kroz verzije kljuca od najnovije tabele dok visit ne vrati false,
+// brisanje opsega koje obuhvata kljuc se prosledjuje kao tombstone
+func SearchVersions(directory, key string, visit func(version *record.Record) bool) error {
+	for _, table := range findSSTables(directory) {
+		level, fileNumber := table[0], table[1]
+		sst, err := openSSTable(directory, level, fileNumber)
 		if err != nil {
-			fmt.Println("Error writing first key:", err)
-			return
+			return err
 		}
 
-		if s.config.Compress {
-			err = binary.Write(f, binary.BigEndian, int16(len(lastKeyBytes)))
-		} else {
-			err = binary.Write(f, binary.BigEndian, int64(len(lastKeyBytes)))
-		}
-		if err != nil {
-			fmt.Println("Error writing last key size:", err)
-			return
-		}
-		_, err = f.Write(lastKeyBytes)
-		if err != nil {
-			fmt.Println("Error writing last key:", err)
-			return
+		// zapis tabele je noviji od brisanja opsega iz iste tabele
+		if sst.filter.CheckElement(key) {
+			version, err := searchTable(directory, level, fileNumber, key)
+			if err == nil {
+				if !visit(version) {
+					return nil
+				}
+			} else if err != errKeyNotInTable {
+				return err
+			}
+			// filter je dao laznu pozitivnu vrednost
 		}
 
-		err = binary.Write(f, binary.BigEndian, entry.offset)
-		if err != nil {
-			fmt.Println("Error writing offset:", err)
-			return
+		if tombstone := record.RangeTombstoneFor(sst.rangeTombstones, key); tombstone != nil {
+			visit(tombstone)
+			return nil
 		}
 	}
+	return nil
 }
 
-func Search(key string, keyDicitonary *map[int]string) (*record.Record, error) {
-	cfg := new(config.Config)
-	config.LoadConfig(cfg)
-
-	level, fileNumber, err := findSSTableNumber(key, cfg.NumberOfSSTables, keyDicitonary) // broj tabele u kojoj je zapis
-	if fileNumber == -1 && err == nil {
-		return nil, errors.New("key not found in any of sstables")
-	} else if err != nil {
-		return nil, err
-	}
-
-	lastKey, offset, err := loadAndFindIndexOffset(fileNumber, level, key, keyDicitonary)
-	if err != nil {
-		return nil, err
-	}
-
-	valueOffset, err := loadAndFindValueOffset(fileNumber, level, uint64(offset), key, lastKey, keyDicitonary)
+func searchTable(directory string, level, fileNumber int, key string) (*record.Record, error) {
+	keyDicitonary, err := LoadTableDictionary(directory, level, fileNumber)
 	if err != nil {
 		return nil, err
 	}
 
-	record, err := loadRecord(fileNumber, level, key, uint64(valueOffset), keyDicitonary)
+	block, err := findBlock(directory, level, fileNumber, key, keyDicitonary)
 	if err != nil {
 		return nil, err
 	}
-	return record, nil
+	return loadRecord(directory, fileNumber, level, key, block, keyDicitonary)
 }
 
-// trazimo SSTabelu gde gde je sa velikom verovatnocom nas zapis
-func findSSTableNumber(key string, numOfSSTables int, keyDicitonary *map[int]string) (int, int, error) {
+// sve tabele kao parovi (nivo, redni broj), od najnovije
+func findSSTables(directory string) [][]int {
 	var data [][]int
-	files, _ := os.ReadDir(config.SSTABLE_DIRECTORY)
+	files, _ := os.ReadDir(directory)
 
 	for _, file := range files {
 		if strings.Contains(file.Name(), "sstable_data") {
@@ -307,603 +238,97 @@ func findSSTableNumber(key string, numOfSSTables int, keyDicitonary *map[int]str
 			level, _ := strconv.Atoi(sstable_tokens[1])
 			index, _ := strconv.Atoi(strings.Split(sstable_tokens[4], ".")[0])
 			data = append(data, []int{level, index})
-
-			sort.Slice(data, func(i, j int) bool {
-				return data[i][1] < data[j][1]
-			})
-		}
-	}
-
-	for i := len(data) - 1; i >= 0; i-- {
-		sst, err := LoadSSTable(data[i][0], data[i][1], keyDicitonary)
-		if err != nil {
-			return -1, -1, err
 		}
-
-		if sst.filter.CheckElement(key) {
-			return data[i][0], data[i][1], nil
-		}
-	}
-	return -1, -1, nil
-}
-
-func loadAndFindIndexOffset(fileNumber, level int, key string, keyDictionary *map[int]string) (string, int64, error) {
-	f, err := os.Open(config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(level) + "_sstable_summary_" + strconv.Itoa(fileNumber) + ".db")
-	if err != nil {
-		return "", -1, err
 	}
-	defer f.Close()
-
-	cfg := new(config.Config)
-	config.LoadConfig(cfg)
-
-	var initialOffset int64 = 0
-
-	for {
-		_, seekErr := f.Seek(initialOffset, io.SeekStart)
-		if seekErr != nil {
-			return "", -1, seekErr
-		}
-		if cfg.Compress {
-			firstKeySizeBytes := make([]byte, 2)
-			_, readErr := f.Read(firstKeySizeBytes)
-			if readErr == io.EOF {
-				return "", -1, readErr
-			} else if readErr != nil {
-				return "", -1, readErr
-			}
-			firstKeySize := binary.BigEndian.Uint16(firstKeySizeBytes)
-
-			firstKeyBytes := make([]byte, firstKeySize)
-			_, readErr = f.Read(firstKeyBytes)
-			if readErr != nil {
-				return "", -1, readErr
-			}
-			index, _ := strconv.Atoi(string(firstKeyBytes))
-			// index := binary.BigEndian.Uint16(firstKeyBytes)
-			firstKey := (*keyDictionary)[int(index)]
-
-			lastKeySizeBytes := make([]byte, 2)
-			_, readErr = f.Read(lastKeySizeBytes)
-			if readErr != nil {
-				return "", -1, readErr
-			}
-
-			lastKeySize := binary.BigEndian.Uint16(lastKeySizeBytes)
-
-			lastKeyBytes := make([]byte, lastKeySize)
-			_, readErr = f.Read(lastKeyBytes)
-			if readErr != nil {
-				return "", -1, readErr
-			}
-			index1 := binary.BigEndian.Uint16(lastKeyBytes)
-			lastKey := (*keyDictionary)[int(index1)]
-
-			offsetBytes := make([]byte, 8)
-			_, readErr = f.Read(offsetBytes)
-			if readErr != nil {
-				return "", -1, readErr
-			}
-			offset := int64(binary.BigEndian.Uint64(offsetBytes))
-
-			if key >= firstKey && key <= lastKey {
-				return lastKey, offset, nil
-			}
-
-			initialOffset += 12 + int64(firstKeySize) + int64(lastKeySize)
-		} else {
-			firstKeySizeBytes := make([]byte, 8)
-			_, readErr := f.Read(firstKeySizeBytes)
-			if readErr == io.EOF {
-				return "", -1, readErr
-			} else if readErr != nil {
-				return "", -1, readErr
-			}
-			firstKeySize := binary.BigEndian.Uint64(firstKeySizeBytes)
-
-			firstKeyBytes := make([]byte, firstKeySize)
-			_, readErr = f.Read(firstKeyBytes)
-			if readErr != nil {
-				return "", -1, readErr
-			}
-			firstKey := string(firstKeyBytes)
-
-			lastKeySizeBytes := make([]byte, 8)
-			_, readErr = f.Read(lastKeySizeBytes)
-			if readErr != nil {
-				return "", -1, readErr
-			}
-
-			lastKeySize := binary.BigEndian.Uint64(lastKeySizeBytes)
 
-			lastKeyBytes := make([]byte, lastKeySize)
-			_, readErr = f.Read(lastKeyBytes)
-			if readErr != nil {
-				return "", -1, readErr
-			}
-			lastKey := string(lastKeyBytes)
-			offsetBytes := make([]byte, 8)
-			_, readErr = f.Read(offsetBytes)
-			if readErr != nil {
-				return "", -1, readErr
-			}
-			offset := int64(binary.BigEndian.Uint64(offsetBytes))
-
-			if key >= firstKey && key <= lastKey {
-				return lastKey, offset, nil
-			}
-
-			initialOffset += 24 + int64(firstKeySize) + int64(lastKeySize)
-
-		}
-	}
+	sort.Slice(data, func(i, j int) bool {
+		return data[i][1] > data[j][1]
+	})
+	return data
 }
 
-func loadAndFindValueOffset(fileNumber, level int, summaryOffset uint64, key string, lastKey string, keyDictionary *map[int]string) (int64, error) {
-	f, err := os.Open(config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(level) + "_sstable_index_" + strconv.Itoa(fileNumber) + ".db")
+// cita deo fajla tabele preko kesa blokova
+func readRegion(path string, offset, size int64) ([]byte, error) {
+	f, err := openBlockFile(path)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
 	defer f.Close()
 
-	cfg := new(config.Config)
-	config.LoadConfig(cfg)
-
-	var lastReadOffset int64
-
-	for {
-		_, seekErr := f.Seek(int64(summaryOffset), io.SeekStart)
-		if seekErr != nil {
-			return -1, seekErr
-		}
-
-		if cfg.Compress {
-			keySizeBytes := make([]byte, 2)
-			_, readErr := f.Read(keySizeBytes)
-			if readErr == io.EOF {
-				return -1, readErr
-			} else if readErr != nil {
-				return -1, readErr
-			}
-			keySize := binary.BigEndian.Uint16(keySizeBytes)
-
-			keyBytes := make([]byte, keySize)
-			_, readErr = f.Read(keyBytes)
-			if readErr != nil {
-				return -1, readErr
-			}
-			index := binary.BigEndian.Uint16(keyBytes)
-			foundKey := (*keyDictionary)[int(index)]
-
-			offsetBytes := make([]byte, 8)
-			_, readErr = f.Read(offsetBytes)
-			if readErr != nil {
-				return -1, readErr
-			}
-			offset := int64(binary.BigEndian.Uint64(offsetBytes))
-
-			if key >= foundKey {
-				lastReadOffset = offset
-			} else {
-				return lastReadOffset, nil
-			}
-
-			if foundKey == lastKey {
-				break
-			}
-
-			summaryOffset += 10 + uint64(keySize)
-		} else {
-			keySizeBytes := make([]byte, 8)
-			_, readErr := f.Read(keySizeBytes)
-			if readErr == io.EOF {
-				return -1, readErr
-			} else if readErr != nil {
-				return -1, readErr
-			}
-			keySize := binary.BigEndian.Uint64(keySizeBytes)
-
-			keyBytes := make([]byte, keySize)
-			_, readErr = f.Read(keyBytes)
-			if readErr != nil {
-				return -1, readErr
-			}
-			foundKey := string(keyBytes)
-
-			offsetBytes := make([]byte, 8)
-			_, readErr = f.Read(offsetBytes)
-			if readErr != nil {
-				return -1, readErr
-			}
-			offset := int64(binary.BigEndian.Uint64(offsetBytes))
-
-			if key >= foundKey {
-				lastReadOffset = offset
-			} else {
-				return lastReadOffset, nil
-			}
-
-			if foundKey == lastKey {
-				break
-			}
-
-			summaryOffset += 16 + keySize
-		}
+	if size < 0 {
+		size = f.size - offset
 	}
-
-	return lastReadOffset, nil
-}
-
-func loadRecord(fileNumber, level int, key string, valueOffset uint64, keyDictionary *map[int]string) (*record.Record, error) {
-	f, err := os.Open(config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(level) + "_sstable_data_" + strconv.Itoa(fileNumber) + ".db")
+	data := make([]byte, size)
+	_, err = f.Seek(offset, io.SeekStart)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-
-	cfg := new(config.Config)
-	config.LoadConfig(cfg)
-
-	for {
-		_, seekErr := f.Seek(int64(valueOffset), io.SeekStart)
-		if seekErr != nil {
-			return nil, seekErr
-		}
-
-		if cfg.Compress {
-			headerBytes := make([]byte, 15)
-			_, readErr := f.Read(headerBytes)
-			if readErr == io.EOF {
-				return nil, readErr
-			} else if readErr != nil {
-				return nil, readErr
-			}
-
-			crc32 := binary.BigEndian.Uint32(headerBytes[0:4])
-			timestamp := int64(binary.BigEndian.Uint64(headerBytes[4:12]))
-			tombstone := headerBytes[12] != 0
-			keySize := int64(binary.BigEndian.Uint16(headerBytes[13:15]))
-			var valueSize int64 = 0
-			if !tombstone {
-				extraBytes := make([]byte, 8)
-				_, readErr := f.Read(extraBytes)
-				if readErr == io.EOF {
-					return nil, readErr
-				} else if readErr != nil {
-					return nil, readErr
-				}
-				valueSize = int64(binary.BigEndian.Uint64(extraBytes[0:8]))
-			}
-
-			keyBytes := make([]byte, keySize)
-			_, readErr = f.Read(keyBytes)
-			if readErr != nil {
-				return nil, readErr
-			}
-			index := binary.BigEndian.Uint16(keyBytes)
-			loadedKey := (*keyDictionary)[int(index)]
-
-			var value []byte = nil
-			if !tombstone {
-				value = make([]byte, valueSize)
-				_, readErr = f.Read(value)
-				if readErr != nil {
-					return nil, readErr
-				}
-			}
-
-			checkCrc32 := record.CalculateCRC(timestamp, tombstone, keySize, valueSize, loadedKey, value)
-			if checkCrc32 != crc32 {
-				if !tombstone {
-					valueOffset += 23 + uint64(keySize) + uint64(valueSize)
-				} else {
-					valueOffset += 15 + uint64(keySize)
-				}
-				continue
-			}
-
-			if loadedKey == key {
-				return record.LoadRecord(crc32, timestamp, tombstone, keySize, valueSize, loadedKey, value), nil
-			}
-
-			if !tombstone {
-				valueOffset += 23 + uint64(keySize) + uint64(valueSize)
-			} else {
-				valueOffset += 15 + uint64(keySize)
-			}
-		} else {
-			headerBytes := make([]byte, 21)
-			_, readErr := f.Read(headerBytes)
-			if readErr == io.EOF {
-				return nil, readErr
-			} else if readErr != nil {
-				return nil, readErr
-			}
-
-			crc32 := binary.BigEndian.Uint32(headerBytes[0:4])
-			timestamp := int64(binary.BigEndian.Uint64(headerBytes[4:12]))
-			tombstone := headerBytes[12] != 0
-			keySize := int64(binary.BigEndian.Uint64(headerBytes[13:21]))
-			var valueSize int64 = 0
-			if !tombstone {
-				extraBytes := make([]byte, 8)
-				_, readErr := f.Read(extraBytes)
-				if readErr == io.EOF {
-					return nil, readErr
-				} else if readErr != nil {
-					return nil, readErr
-				}
-				valueSize = int64(binary.BigEndian.Uint64(extraBytes[0:8]))
-			}
-
-			keyBytes := make([]byte, keySize)
-			_, readErr = f.Read(keyBytes)
-			if readErr != nil {
-				return nil, readErr
-			}
-			var loadedKey string
-			if cfg.Compress {
-				index := binary.BigEndian.Uint64(keyBytes)
-				loadedKey = (*keyDictionary)[int(index)]
-			} else {
-				loadedKey = string(keyBytes)
-			}
-
-			var value []byte = nil
-			if !tombstone {
-				value = make([]byte, valueSize)
-				_, readErr = f.Read(value)
-				if readErr != nil {
-					return nil, readErr
-				}
-			}
-
-			checkCrc32 := record.CalculateCRC(timestamp, tombstone, keySize, valueSize, loadedKey, value)
-			if checkCrc32 != crc32 {
-				if !tombstone {
-					valueOffset += 29 + uint64(keySize) + uint64(valueSize)
-				} else {
-					valueOffset += 21 + uint64(keySize)
-				}
-				continue
-			}
-
-			if loadedKey == key {
-				return record.LoadRecord(crc32, timestamp, tombstone, keySize, valueSize, loadedKey, value), nil
-			}
-
-			if !tombstone {
-				valueOffset += 29 + uint64(keySize) + uint64(valueSize)
-			} else {
-				valueOffset += 21 + uint64(keySize)
-			}
-
-		}
-
-	}
-}
-
-func (s *SSTable) createFilter(allRecords []record.Record, level int) {
-	s.filter = bloom.NewBloomFilter(len(allRecords), 0.01)
-
-	for _, record := range allRecords {
-		s.filter.AddElement(record.Key)
-	}
-
-	s.filter.WriteToBinFile(config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(level) + "_sstable_filter_" + strconv.Itoa(s.config.NumberOfSSTables) + ".bin")
-}
-
-func (s *SSTable) createMetaData(allRecords []record.Record, level int) {
-	var allRecordsBytes [][]byte
-	for _, record := range allRecords {
-		allRecordsBytes = append(allRecordsBytes, record.ToBytesSSTable(s.keyDictionary))
-	}
-	s.metadata = merkle.NewMerkleTree(allRecordsBytes)
-	s.metadata.WriteToBinFile(config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(level) + "_sstable_metadata_" + strconv.Itoa(s.config.NumberOfSSTables) + ".bin")
-}
-
-func (s *SSTable) putElementToMap(ogKey string) int {
-	for key, value := range *s.keyDictionary {
-		if value == ogKey {
-			return key
-		}
-	}
-
-	nextKey := 0
-	for key := range *s.keyDictionary {
-		if key >= nextKey {
-			nextKey = key + 1
-		}
-	}
-	(*s.keyDictionary)[nextKey] = ogKey
-
-	return nextKey
-}
-
-func (s *SSTable) ToBytesSSTable(r *record.Record) []byte {
-	var bufferSize int64
-
-	index := s.putElementToMap(r.Key)
-	indexBytes := make([]byte, 2)
-	binary.BigEndian.PutUint16(indexBytes, uint16(index))
-	r.KeySize = int64(len(indexBytes))
-
-	if r.Tombstone {
-		bufferSize = 15 + r.KeySize
-		r.Crc32 = record.CalculateCRC(r.Timestamp, r.Tombstone, r.KeySize, 0, r.Key, nil)
-	} else {
-		bufferSize = 23 + r.KeySize + r.ValueSize
-		if s.config.Compress {
-			r.Crc32 = record.CalculateCRC(r.Timestamp, r.Tombstone, r.KeySize, r.ValueSize, r.Key, r.Value)
-		}
+	_, err = io.ReadFull(f, data)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		// indeks ili summary pokazuju van fajla
+		return nil, corruption(path, offset, io.ErrUnexpectedEOF)
+	} else if err != nil {
+		return nil, err
 	}
-	buffer := make([]byte, bufferSize)
-	binary.BigEndian.PutUint32(buffer[0:4], uint32(r.Crc32))
-	binary.BigEndian.PutUint64(buffer[4:12], uint64(r.Timestamp))
-
-	buffer[12] = 0
-	if r.Tombstone {
-		buffer[12] = 1
-		binary.BigEndian.PutUint16(buffer[13:15], uint16(r.KeySize))
-		copy(buffer[15:15+r.KeySize], []byte(r.Key))
-		return buffer
-	}
-	binary.BigEndian.PutUint16(buffer[13:15], uint16(r.KeySize))
-	binary.BigEndian.PutUint64(buffer[15:23], uint64(r.ValueSize))
-	binary.BigEndian.PutUint16(buffer[23:23+r.KeySize], uint16(index))
-	copy(buffer[23+r.KeySize:bufferSize], r.Value)
-	return buffer
+	return data, nil
 }
 
-func (s *SSTable) WriteRecord(record *record.Record, filepath string) {
-	var recordBytes []byte
-	if s.config.Compress {
-		recordBytes = s.ToBytesSSTable(record)
-	} else {
-		recordBytes = record.ToBytes()
-	}
+var errKeyNotInTable = errors.New("key not found in sstable")
 
-	f, err := os.OpenFile(filepath, os.O_CREATE|os.O_APPEND, 0644)
+// trazi blok data fajla u kom bi mogao biti kljuc, preko summary-ja pa indeksa
+func findBlock(directory string, level, fileNumber int, key string, keyDictionary *keydictionary.KeyDictionary) (IndexEntry, error) {
+	summaryPath := tablePath(directory, level, fileNumber, "summary")
+	data, err := readRegion(summaryPath, 0, -1)
 	if err != nil {
-		fmt.Println("Error opening or creating file:", err)
-		return
-	}
-	defer f.Close()
-
-	_, writeErr := f.Write(recordBytes)
-	if writeErr != nil {
-		fmt.Println("Error writing record to file:", writeErr)
+		return IndexEntry{}, err
 	}
-}
-
-func WriteDataIndexSummaryLSM(path string, level int, cfg config.Config, keyDictionary *map[int]string, recordCounter int) {
-	dataFile, err := os.Open(path)
+	summary, err := decodeSummary(data, summaryPath, keyDictionary)
 	if err != nil {
-		return
+		return IndexEntry{}, err
 	}
-	defer dataFile.Close()
 
-	sstableIndex := strings.Split(strings.Split(path, "_")[4], ".")[0]
-	prefix := config.SSTABLE_DIRECTORY + "lvl_" + strconv.Itoa(level)
-
-	indexFile, err := os.OpenFile(prefix+"_sstable_index_"+sstableIndex+".db", os.O_CREATE|os.O_APPEND, 0644)
-	if err != nil {
-		return
+	// poslednji deo indeksa ciji je prvi kljuc manji ili jednak trazenom
+	i := sort.Search(len(summary), func(i int) bool {
+		return summary[i].firstKey > key
+	}) - 1
+	if i < 0 || key > summary[i].lastKey {
+		return IndexEntry{}, errKeyNotInTable
 	}
-	defer indexFile.Close()
 
-	summaryFile, err := os.OpenFile(prefix+"_sstable_summary_"+sstableIndex+".db", os.O_CREATE|os.O_APPEND, 0644)
+	indexPath := tablePath(directory, level, fileNumber, "index")
+	data, err = readRegion(indexPath, summary[i].offset, summary[i].size)
 	if err != nil {
-		return
+		return IndexEntry{}, err
 	}
-	defer summaryFile.Close()
-
-	filterFile, err := os.OpenFile(prefix+"_sstable_filter_"+sstableIndex+".bin", os.O_CREATE|os.O_APPEND, 0644)
+	index, err := decodeIndex(data, indexPath, summary[i].offset, keyDictionary)
 	if err != nil {
-		return
+		return IndexEntry{}, err
 	}
-	defer filterFile.Close()
-
-	count := 0
-	indexOffset := 0
-	var index []IndexEntry
-	var allRecordsBytes [][]byte
-
-	for {
-		record, err := record.LoadRecordFromFile(*dataFile, keyDictionary)
-		if err != nil {
-			break // procitali smo sve rekorde
-		}
-
-		if count%cfg.IndexInterval == 0 || count+1 == recordCounter {
-			index = append(index, IndexEntry{key: record.Key, offset: int64(indexOffset)})
-
-			var keyBytes []byte
-			if cfg.Compress {
-				keyBytes = make([]byte, 2)
-				keyInt, _ := findKeyByValue(*keyDictionary, record.Key)
-				binary.BigEndian.PutUint16(keyBytes, uint16(keyInt))
-			} else {
-				keyBytes = []byte(record.Key)
-			}
 
-			if cfg.Compress {
-				binary.Write(indexFile, binary.BigEndian, int16(len(keyBytes)))
-			} else {
-				binary.Write(indexFile, binary.BigEndian, int64(len(keyBytes)))
-			}
-			indexFile.Write(keyBytes)
-			binary.Write(indexFile, binary.BigEndian, int64(indexOffset))
-
-		}
-		count++
-		indexOffset += len(record.ToBytesSSTable(keyDictionary))
-		allRecordsBytes = append(allRecordsBytes, record.ToBytesSSTable(keyDictionary))
+	j := sort.Search(len(index), func(j int) bool {
+		return index[j].key > key
+	}) - 1
+	if j < 0 {
+		return IndexEntry{}, errKeyNotInTable
 	}
+	return index[j], nil
+}
 
-	mt := merkle.NewMerkleTree(allRecordsBytes)
-	mt.WriteToBinFile(prefix + "_sstable_metadata_" + sstableIndex + ".bin")
-
-	summaryOffset := 0
-
-	for i := 0; i < len(index); i += cfg.SummaryInterval {
-		endIndex := i + cfg.SummaryInterval - 1
-
-		if endIndex >= len(index) {
-			endIndex = len(index) - 1
-		}
-		var firstKeyBytes []byte
-		var lastKeyBytes []byte
-
-		if cfg.Compress {
-			firstKeyBytes = make([]byte, 2)
-			keyInt, _ := findKeyByValue(*keyDictionary, index[i].key)
-			binary.BigEndian.PutUint16(firstKeyBytes, uint16(keyInt))
-
-			lastKeyBytes = make([]byte, 2)
-			keyInt, _ = findKeyByValue(*keyDictionary, index[endIndex].key)
-			binary.BigEndian.PutUint16(lastKeyBytes, uint16(keyInt))
-		} else {
-			firstKeyBytes = []byte(index[i].key)
-			lastKeyBytes = []byte(index[endIndex].key)
-		}
-
-		if cfg.Compress {
-			binary.Write(summaryFile, binary.BigEndian, int16(len(firstKeyBytes)))
-		} else {
-			binary.Write(summaryFile, binary.BigEndian, int64(len(firstKeyBytes)))
-		}
-		summaryFile.Write(firstKeyBytes)
-		if cfg.Compress {
-			binary.Write(summaryFile, binary.BigEndian, int16(len(lastKeyBytes)))
-		} else {
-			binary.Write(summaryFile, binary.BigEndian, int64(len(lastKeyBytes)))
-		}
-		summaryFile.Write(lastKeyBytes)
-
-		binary.Write(summaryFile, binary.BigEndian, int64(summaryOffset))
-		if cfg.Compress {
-			summaryOffset = 10 + len([]byte(index[i].key))
-		} else {
-			summaryOffset = 16 + len([]byte(index[i].key))
-		}
+func loadRecord(directory string, fileNumber, level int, key string, block IndexEntry, keyDictionary *keydictionary.KeyDictionary) (*record.Record, error) {
+	dataPath := tablePath(directory, level, fileNumber, "data")
+	data, err := readRegion(dataPath, block.offset, block.size)
+	if err != nil {
+		return nil, err
 	}
-
-	bf := bloom.NewBloomFilter(count, 0.01)
-
-	dataFile, err = os.Open(path)
+	records, err := decodeBlock(data, dataPath, block.offset, keyDictionary)
 	if err != nil {
-		return
+		return nil, err
 	}
-	defer dataFile.Close()
 
-	for {
-		record, err := record.LoadRecordFromFile(*dataFile, keyDictionary)
-		if err != nil {
-			break // procitali smo sve rekorde
+	for i := range records {
+		if records[i].Key == key {
+			return &records[i], nil
 		}
-		bf.AddElement(record.Key)
 	}
-
-	filterFile.Write(bf.ToBytes())
+	return nil, errKeyNotInTable
 }
@@ -0,0 +1,197 @@
+package sstable
+
+import (
+	"io"
+	keydictionary "main/keyDictionary"
+	"main/record"
+	"sort"
+)
+
+// iterator kroz zapise jedne SSTabele, data fajl se cita blok po blok
+// gde je blok deo izmedju dva susedna ulaza u indeksu
+type Iterator struct {
+	dataFile        *blockFile
+	index           []IndexEntry
+	keyDictionary   *keydictionary.KeyDictionary
+	rangeTombstones []record.Record
+	block           []record.Record
+	blockIndex      int
+	position        int
+	err             error
+}
+
+func NewIterator(directory string, level, fileNumber int) (*Iterator, error) {
+	keyDictionary, err := LoadTableDictionary(directory, level, fileNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := readIndex(tablePath(directory, level, fileNumber, "index"), keyDictionary)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeTombstones, err := loadRangeTombstones(directory, level, fileNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	dataFile, err := openBlockFile(tablePath(directory, level, fileNumber, "data"))
+	if err != nil {
+		return nil, err
+	}
+
+	it := &Iterator{
+		dataFile:        dataFile,
+		index:           index,
+		keyDictionary:   keyDictionary,
+		rangeTombstones: rangeTombstones,
+	}
+	it.SeekToFirst()
+	return it, nil
+}
+
+// ucitava sve ulaze indeksa, oni odredjuju granice blokova u data fajlu
+func readIndex(path string, keyDictionary *keydictionary.KeyDictionary) ([]IndexEntry, error) {
+	data, err := readRegion(path, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	return decodeIndex(data, path, 0, keyDictionary)
+}
+
+// ucitava i dekodira zapise bloka sa zadatim rednim brojem,
+// ako citanje ne uspe iterator se oznacava kao zavrsen i pamti gresku
+func (it *Iterator) loadBlock(blockIndex int) {
+	it.blockIndex = blockIndex
+	it.position = 0
+	it.block = nil
+	if blockIndex < 0 || blockIndex >= len(it.index) {
+		return
+	}
+	if it.err == nil {
+		it.err = it.readBlock()
+	}
+	if it.err != nil {
+		it.blockIndex = len(it.index)
+		it.block = nil
+	}
+}
+
+func (it *Iterator) readBlock() error {
+	block := it.index[it.blockIndex]
+	data := make([]byte, block.size)
+	if _, err := it.dataFile.Seek(block.offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(it.dataFile, data); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return corruption(it.dataFile.path, block.offset, io.ErrUnexpectedEOF)
+	} else if err != nil {
+		return err
+	}
+
+	records, err := decodeBlock(data, it.dataFile.path, block.offset, it.keyDictionary)
+	if err != nil {
+		return err
+	}
+	it.block = records
+	return nil
+}
+
+// prelazi na sledeci neprazan blok ako je trenutni procitan do kraja
+func (it *Iterator) skipEmptyBlocks() {
+	for it.position >= len(it.block) && it.blockIndex < len(it.index) {
+		it.loadBlock(it.blockIndex + 1)
+	}
+}
+
+// prelazi na prethodni neprazan blok kada se trenutni procita unazad
+func (it *Iterator) skipEmptyBlocksBackward() {
+	for it.position < 0 && it.blockIndex >= 0 && it.blockIndex < len(it.index) {
+		it.loadBlock(it.blockIndex - 1)
+		it.position = len(it.block) - 1
+	}
+}
+
+func (it *Iterator) SeekToFirst() {
+	it.loadBlock(0)
+	it.skipEmptyBlocks()
+}
+
+// pozicionira iterator na prvi zapis ciji je kljuc veci ili jednak zadatom
+func (it *Iterator) Seek(key string) {
+	// poslednji blok ciji je prvi kljuc manji ili jednak trazenom
+	blockIndex := sort.Search(len(it.index), func(i int) bool {
+		return it.index[i].key > key
+	}) - 1
+	if blockIndex < 0 {
+		blockIndex = 0
+	}
+
+	it.loadBlock(blockIndex)
+	it.position = sort.Search(len(it.block), func(i int) bool {
+		return it.block[i].Key >= key
+	})
+	it.skipEmptyBlocks()
+}
+
+func (it *Iterator) SeekToLast() {
+	it.loadBlock(len(it.index) - 1)
+	it.position = len(it.block) - 1
+	it.skipEmptyBlocksBackward()
+}
+
+// pozicionira iterator na poslednji zapis ciji je kljuc manji ili jednak zadatom
+func (it *Iterator) SeekForPrev(key string) {
+	blockIndex := sort.Search(len(it.index), func(i int) bool {
+		return it.index[i].key > key
+	}) - 1
+
+	it.loadBlock(blockIndex)
+	it.position = sort.Search(len(it.block), func(i int) bool {
+		return it.block[i].Key > key
+	}) - 1
+	it.skipEmptyBlocksBackward()
+}
+
+func (it *Iterator) Next() {
+	if !it.Valid() {
+		return
+	}
+	it.position++
+	it.skipEmptyBlocks()
+}
+
+func (it *Iterator) Prev() {
+	if !it.Valid() {
+		return
+	}
+	it.position--
+	it.skipEmptyBlocksBackward()
+}
+
+func (it *Iterator) Valid() bool {
+	return it.blockIndex >= 0 && it.blockIndex < len(it.index) && it.position >= 0 && it.position < len(it.block)
+}
+
+func (it *Iterator) Record() *record.Record {
+	if !it.Valid() {
+		return nil
+	}
+	return &it.block[it.position]
+}
+
+// brisanja opsega tabele, pokrivaju zapise starijih tabela
+func (it *Iterator) RangeTombstones() []record.Record {
+	return it.rangeTombstones
+}
+
+// greska zbog koje je iterator zavrsio pre kraja tabele, npr. *record.ErrCorruption
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+func (it *Iterator) Close() error {
+	it.block = nil
+	return it.dataFile.Close()
+}
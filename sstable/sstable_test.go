@@ -0,0 +1,156 @@
+package sstable
+
+import (
+	"errors"
+	"main/config"
+	"main/record"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tabele se pisu u data/sstable, a config u config, relativno na radni direktorijum
+func newTestConfig(t *testing.T, compress bool) *config.Config {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, sub := range []string{"data/sstable", "config"} {
+		err = os.MkdirAll(filepath.Join(dir, sub), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	var cfg config.Config
+	config.LoadConfig(&cfg)
+	cfg.Compress = compress
+	return &cfg
+}
+
+func records(keys ...string) []record.Record {
+	var all []record.Record
+	for i, key := range keys {
+		all = append(all, record.Record{Key: key, Value: []byte("value of " + key), SeqNum: uint64(i + 1)})
+	}
+	return all
+}
+
+func expectRecord(t *testing.T, cfg *config.Config, key string) {
+	t.Helper()
+	r, err := Search(cfg.SSTableDirectory(), key)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", key, err)
+	}
+	if r.Key != key || string(r.Value) != "value of "+key {
+		t.Fatalf("Search(%q) = %q: %q", key, r.Key, r.Value)
+	}
+}
+
+func TestTableDictionaryHoldsOnlyItsKeys(t *testing.T) {
+	cfg := newTestConfig(t, true)
+	_, err := NewSSTable(records("a", "b", "c"), nil, cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewSSTable(records("x", "y"), nil, cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := cfg.SSTableDirectory()
+	for fileNumber, want := range map[int][]string{1: {"a", "b", "c"}, 2: {"x", "y"}} {
+		kd, err := LoadTableDictionary(directory, 1, fileNumber)
+		if err != nil || kd == nil {
+			t.Fatalf("dictionary of table %d: %v, %v", fileNumber, kd, err)
+		}
+		if kd.Len() != len(want) {
+			t.Fatalf("dictionary of table %d has %d keys, want %d", fileNumber, kd.Len(), len(want))
+		}
+		for _, key := range want {
+			if _, found := kd.ID(key); !found {
+				t.Fatalf("dictionary of table %d is missing %q", fileNumber, key)
+			}
+		}
+	}
+	for _, key := range []string{"a", "b", "c", "x", "y"} {
+		expectRecord(t, cfg, key)
+	}
+
+	// tabela bez recnika se cita kao nekompresovana, i kada su ostale kompresovane
+	cfg.Compress = false
+	_, err = NewSSTable(records("m"), nil, cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kd, err := LoadTableDictionary(directory, 1, 3); kd != nil || err != nil {
+		t.Fatalf("uncompressed table has a dictionary: %v, %v", kd, err)
+	}
+	expectRecord(t, cfg, "m")
+	expectRecord(t, cfg, "a")
+}
+
+// kljucevi sa zajednickim prefiksima kroz vise blokova i delova indeksa
+func TestPrefixCompressedKeysRoundTrip(t *testing.T) {
+	keys := []string{"", "user", "user:1", "user:10", "user:100", "user:2", "userx", "v", "\xff", "\xff\xff"}
+	for _, compress := range []bool{false, true} {
+		cfg := newTestConfig(t, compress)
+		cfg.IndexInterval = 3
+		cfg.SummaryInterval = 2
+		all := records(keys...)
+		all[3].Tombstone = true
+		all[3].Value = nil
+		_, err := NewSSTable(all, nil, cfg, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		it, err := NewIterator(cfg.SSTableDirectory(), 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var read []record.Record
+		for ; it.Valid(); it.Next() {
+			read = append(read, *it.Record())
+		}
+		if err = it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(read) != len(keys) {
+			t.Fatalf("compress %v: read %d records, want %d", compress, len(read), len(keys))
+		}
+		for i, r := range read {
+			if r.Key != keys[i] || r.Tombstone != all[i].Tombstone || string(r.Value) != string(all[i].Value) || r.SeqNum != all[i].SeqNum {
+				t.Fatalf("compress %v: record %d = %+v, want %+v", compress, i, r, all[i])
+			}
+		}
+
+		// restart tacka na pocetku bloka ima ceo kljuc
+		it.Seek("user:2")
+		if !it.Valid() || it.Record().Key != "user:2" {
+			t.Fatalf("compress %v: Seek(user:2) is at %v", compress, it.Record())
+		}
+		it.SeekForPrev("user:0")
+		if !it.Valid() || it.Record().Key != "user" {
+			t.Fatalf("compress %v: SeekForPrev(user:0) is at %v", compress, it.Record())
+		}
+		it.Close()
+
+		for i, key := range keys {
+			if all[i].Tombstone {
+				continue
+			}
+			expectRecord(t, cfg, key)
+		}
+		if _, err = Search(cfg.SSTableDirectory(), "user:0"); !errors.Is(err, record.ErrNotFound) {
+			t.Fatalf("compress %v: Search of a missing key: %v", compress, err)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package sstable
+
+import (
+	"bufio"
+	"main/bloom-filter"
+	"main/config"
+	keydictionary "main/keyDictionary"
+	"main/merkle"
+	"main/record"
+	"os"
+	"strconv"
+)
+
+// Writer pise SSTabelu zapis po zapis, zapisi moraju stizati sortirani po kljucu.
+// Data, indeks i summary se pisu odmah, a filter i metadata pri zatvaranju.
+type Writer struct {
+	config        *config.Config
+	directory     string
+	level         int
+	fileNumber    int
+	keyDictionary *keydictionary.KeyDictionary
+
+	dataFile    *os.File
+	indexFile   *os.File
+	summaryFile *os.File
+	data        *bufio.Writer
+	index       *bufio.Writer
+	summary     *bufio.Writer
+
+	count        int
+	dataOffset   int64
+	indexOffset  int64
+	prevKey      []byte       // prethodni kljuc u bloku, nil na pocetku bloka
+	block        IndexEntry   // blok koji se trenutno pise
+	blockKey     []byte       // prvi kljuc bloka kako je upisan
+	prevIndexKey []byte       // prethodni kljuc u delu indeksa
+	indexCount   int          // broj ulaza u indeksu
+	group        SummaryEntry // deo indeksa koji se trenutno pise
+	groupKey     []byte
+	lastKey      []byte // poslednji upisani kljuc
+	blockLastKey []byte // poslednji kljuc poslednjeg bloka sa ulazom u indeksu
+
+	keys            []string
+	leaves          [][]byte
+	rangeTombstones []record.Record
+}
+
+// directory je direktorijum sa tabelama familije kolona, vidi config.SSTableDirectory
+func tablePath(directory string, level, fileNumber int, part string) string {
+	extension := ".db"
+	if part == "filter" || part == "metadata" {
+		extension = ".bin"
+	}
+	return directory + "lvl_" + strconv.Itoa(level) + "_sstable_" + part + "_" + strconv.Itoa(fileNumber) + extension
+}
+
+func NewWriter(cfg *config.Config, level, fileNumber int) (*Writer, error) {
+	directory := cfg.SSTableDirectory()
+	w := &Writer{config: cfg, directory: directory, level: level, fileNumber: fileNumber}
+
+	var err error
+	w.dataFile, err = createTableFile(tablePath(directory, level, fileNumber, "data"))
+	if err != nil {
+		return nil, err
+	}
+	w.indexFile, err = createTableFile(tablePath(directory, level, fileNumber, "index"))
+	if err != nil {
+		w.dataFile.Close()
+		return nil, err
+	}
+	w.summaryFile, err = createTableFile(tablePath(directory, level, fileNumber, "summary"))
+	if err != nil {
+		w.dataFile.Close()
+		w.indexFile.Close()
+		return nil, err
+	}
+
+	// svaka tabela ima svoj recnik sa kljucevima koje sadrzi, a tabela bez
+	// recnika se cita kao nekompresovana
+	if cfg.Compress {
+		w.keyDictionary, err = keydictionary.NewKeyDictionary(DictionaryPath(directory, level, fileNumber))
+		if err != nil {
+			w.closeFiles()
+			return nil, err
+		}
+	} else {
+		os.Remove(DictionaryPath(directory, level, fileNumber))
+	}
+
+	// broj tabele je mogao biti ranije koriscen
+	os.Remove(tablePath(directory, level, fileNumber, "rangedel"))
+	InvalidateBlockCache(tablePath(directory, level, fileNumber, "rangedel"))
+
+	w.data = bufio.NewWriter(w.dataFile)
+	w.index = bufio.NewWriter(w.indexFile)
+	w.summary = bufio.NewWriter(w.summaryFile)
+	return w, nil
+}
+
+// broj tabele je mogao biti ranije koriscen, pa izbacujemo stare blokove iz kesa
+func createTableFile(path string) (*os.File, error) {
+	InvalidateBlockCache(path)
+	return os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+}
+
+func (w *Writer) Add(r record.Record) error {
+	key, err := encodeKey(r.Key, w.keyDictionary)
+	if err != nil {
+		return err
+	}
+
+	// svaki IndexInterval-ti zapis pocinje novi blok i dobija ulaz u indeksu
+	if w.count%w.config.IndexInterval == 0 {
+		err := w.finishBlock()
+		if err != nil {
+			return err
+		}
+		w.block = IndexEntry{key: r.Key, offset: w.dataOffset}
+		w.blockKey = key
+		w.prevKey = nil
+	}
+
+	entry := appendEntry(nil, &r, key, w.prevKey)
+	_, err = w.data.Write(entry)
+	if err != nil {
+		return err
+	}
+
+	w.count++
+	w.dataOffset += int64(len(entry))
+	w.prevKey = key
+	w.lastKey = key
+	w.keys = append(w.keys, r.Key)
+	w.leaves = append(w.leaves, entry)
+	return nil
+}
+
+func (w *Writer) finishBlock() error {
+	if w.count == 0 {
+		return nil
+	}
+
+	// svaki SummaryInterval-ti ulaz indeksa pocinje novi deo indeksa i dobija ulaz u summary-ju,
+	// prethodni deo se zatvara pre ulaza za ovaj blok, pa se ne koristi lastKey
+	if w.indexCount%w.config.SummaryInterval == 0 {
+		err := w.finishGroup()
+		if err != nil {
+			return err
+		}
+		w.group = SummaryEntry{firstKey: w.block.key, offset: w.indexOffset}
+		w.groupKey = w.blockKey
+		w.prevIndexKey = nil
+	}
+
+	w.block.size = w.dataOffset - w.block.offset
+	entry := appendIndexEntry(nil, w.blockKey, w.prevIndexKey, w.block.offset, w.block.size)
+	_, err := w.index.Write(entry)
+	if err != nil {
+		return err
+	}
+
+	w.indexCount++
+	w.indexOffset += int64(len(entry))
+	w.prevIndexKey = w.blockKey
+	w.blockLastKey = w.lastKey
+	return nil
+}
+
+func (w *Writer) finishGroup() error {
+	if w.indexCount == 0 {
+		return nil
+	}
+	w.group.size = w.indexOffset - w.group.offset
+	_, err := w.summary.Write(appendSummaryEntry(nil, w.groupKey, w.blockLastKey, w.group.offset, w.group.size))
+	return err
+}
+
+// dodaje brisanje opsega, ona se upisuju u poseban fajl pri zatvaranju
+func (w *Writer) AddRangeTombstone(r record.Record) {
+	w.rangeTombstones = append(w.rangeTombstones, r)
+}
+
+func (w *Writer) Count() int {
+	return w.count
+}
+
+// da li tabela nema ni zapise ni brisanja opsega
+func (w *Writer) Empty() bool {
+	return w.count == 0 && len(w.rangeTombstones) == 0
+}
+
+// zavrsava tabelu, tabela bez ijednog zapisa i brisanja opsega se brise
+func (w *Writer) Close() error {
+	err := w.finishBlock()
+	if err == nil {
+		err = w.finishGroup()
+	}
+	for _, buffer := range []*bufio.Writer{w.data, w.index, w.summary} {
+		if flushErr := buffer.Flush(); flushErr != nil && err == nil {
+			err = flushErr
+		}
+	}
+	if closeErr := w.closeFiles(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if w.Empty() {
+		w.remove()
+		return nil
+	}
+
+	if len(w.rangeTombstones) > 0 {
+		err = os.WriteFile(tablePath(w.directory, w.level, w.fileNumber, "rangedel"), encodeRangeTombstones(w.rangeTombstones), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	newFilter := bloom.NewBloomFilter
+	if w.config.BlockedFilter {
+		newFilter = bloom.NewBlockedBloomFilter
+	}
+	filter := newFilter(max(1, len(w.keys)), 0.01, w.config.HashSeed)
+	for _, key := range w.keys {
+		filter.AddElement(key)
+	}
+	// filter se cita preko kesa blokova, a broj tabele je mogao biti ranije koriscen
+	filterPath := tablePath(w.directory, w.level, w.fileNumber, "filter")
+	InvalidateBlockCache(filterPath)
+	err = os.WriteFile(filterPath, filter.ToBytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	metadata := merkle.NewMerkleTree(w.leaves)
+	metadata.WriteToBinFile(tablePath(w.directory, w.level, w.fileNumber, "metadata"))
+	return nil
+}
+
+// odustaje od tabele i brise sve sto je upisano
+func (w *Writer) Abort() {
+	w.closeFiles()
+	w.remove()
+}
+
+func (w *Writer) closeFiles() error {
+	var err error
+	for _, file := range []*os.File{w.dataFile, w.indexFile, w.summaryFile} {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if w.keyDictionary != nil {
+		if closeErr := w.keyDictionary.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (w *Writer) remove() {
+	for _, part := range []string{"data", "index", "summary", "dictionary", "rangedel"} {
+		os.Remove(tablePath(w.directory, w.level, w.fileNumber, part))
+	}
+}
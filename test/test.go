@@ -16,7 +16,7 @@ func GenerateRandomRecordsForEvery100(engine engine.Engine) {
 	numberOfRecords := 100000
 	j := 1
 	for i := 1; i <= numberOfRecords; i += 1 {
-		record := record.NewRecord(strconv.Itoa(j), value, false, &engine.KeyDictionary)
+		record := record.NewRecord(strconv.Itoa(j), value, false)
 		listOfRecords = append(listOfRecords, *record)
 		if i%1000 == 0 {
 			j++
@@ -43,7 +43,7 @@ func GenerateRandomRecordsForEvery50000(engine engine.Engine) {
 		if i%20 == 0 {
 			j++
 		}
-		record := record.NewRecord(strconv.Itoa(j), value, false, &engine.KeyDictionary)
+		record := record.NewRecord(strconv.Itoa(j), value, false)
 		listOfRecords = append(listOfRecords, *record)
 	}
 	//shuffling records in random order
@@ -71,7 +71,7 @@ func GenerateRandomRecords(kvlength int, engine engine.Engine) []record.Record {
 	var listOfRecords []record.Record
 	numberOfRecords := 500
 	for i := 0; i < numberOfRecords; i += 1 {
-		record := record.NewRecord(strconv.Itoa(i), value, false, &engine.KeyDictionary)
+		record := record.NewRecord(strconv.Itoa(i), value, false)
 		listOfRecords = append(listOfRecords, *record)
 	}
 	return listOfRecords
@@ -0,0 +1,257 @@
+package valuelog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"main/config"
+	"main/record"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value log cuva velike vrednosti odvojeno od LSM stabla (WiscKey), pa se pri
+// kompakciji prepisuje samo mali pokazivac umesto cele vrednosti.
+// Log je niz fajlova vlog_N.log u koje se samo dopisuje, ulaz u fajlu je:
+// crc (4) | velicina kljuca (uvarint) | velicina vrednosti (uvarint) | kljuc | vrednost
+// crc (crc32c) pokriva sve bajtove ulaza posle njega. Kljuc se cuva zbog
+// sakupljanja smeca, da bi se proverilo da li je vrednost jos uvek aktuelna.
+type ValueLog struct {
+	fileSize   int64
+	files      []int // redni brojevi fajlova, od najstarijeg
+	activeSize int64 // velicina poslednjeg fajla, u njega se dopisuje
+}
+
+// Pokazivac na ulaz u value logu, Size je velicina celog ulaza
+type Pointer struct {
+	File   int
+	Offset int64
+	Size   int64
+}
+
+// Ulaz procitan iz fajla value loga
+type Entry struct {
+	Key     string
+	Value   []byte
+	Pointer Pointer
+}
+
+var errInvalidPointer = errors.New("invalid value log pointer")
+
+func (p Pointer) ToBytes() []byte {
+	data := binary.AppendUvarint(nil, uint64(p.File))
+	data = binary.AppendUvarint(data, uint64(p.Offset))
+	return binary.AppendUvarint(data, uint64(p.Size))
+}
+
+func PointerFromBytes(data []byte) (Pointer, error) {
+	var values [3]uint64
+	offset := 0
+	for i := range values {
+		value, n := binary.Uvarint(data[offset:])
+		if n <= 0 {
+			return Pointer{}, errInvalidPointer
+		}
+		values[i] = value
+		offset += n
+	}
+	return Pointer{File: int(values[0]), Offset: int64(values[1]), Size: int64(values[2])}, nil
+}
+
+func getPath(fileNumber int) string {
+	return config.VALUE_LOG_DIRECTORY + "vlog_" + strconv.Itoa(fileNumber) + ".log"
+}
+
+// ucitava postojece fajlove value loga, fileSize je velicina posle koje se prelazi na novi fajl
+func LoadValueLog(fileSize int) (*ValueLog, error) {
+	err := os.MkdirAll(config.VALUE_LOG_DIRECTORY, 0755)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(config.VALUE_LOG_DIRECTORY)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &ValueLog{fileSize: int64(fileSize)}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "vlog_") || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		fileNumber, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "vlog_"), ".log"))
+		if err != nil {
+			continue
+		}
+		v.files = append(v.files, fileNumber)
+	}
+	sort.Ints(v.files)
+
+	// posle ponovnog pokretanja se pise u novi fajl, jer poslednji moze
+	// imati nepotpun ulaz na kraju
+	next := 1
+	if len(v.files) > 0 {
+		next = v.active() + 1
+	}
+	v.files = append(v.files, next)
+	return v, nil
+}
+
+func (v *ValueLog) active() int {
+	return v.files[len(v.files)-1]
+}
+
+// dopisuje vrednost na kraj loga i vraca pokazivac na nju
+func (v *ValueLog) Append(key string, value []byte) (Pointer, error) {
+	entry := make([]byte, 4, 4+2*binary.MaxVarintLen64+len(key)+len(value))
+	entry = binary.AppendUvarint(entry, uint64(len(key)))
+	entry = binary.AppendUvarint(entry, uint64(len(value)))
+	entry = append(entry, key...)
+	entry = append(entry, value...)
+	binary.BigEndian.PutUint32(entry[0:4], record.Checksum(entry[4:]))
+
+	// pun fajl se vise ne menja, pa ga sakupljanje smeca moze obrisati
+	if v.activeSize > 0 && v.activeSize+int64(len(entry)) > v.fileSize {
+		v.files = append(v.files, v.active()+1)
+		v.activeSize = 0
+	}
+
+	f, err := os.OpenFile(getPath(v.active()), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return Pointer{}, err
+	}
+	defer f.Close()
+
+	_, err = f.Write(entry)
+	if err != nil {
+		return Pointer{}, err
+	}
+
+	pointer := Pointer{File: v.active(), Offset: v.activeSize, Size: int64(len(entry))}
+	v.activeSize += int64(len(entry))
+	return pointer, nil
+}
+
+// cita vrednost na koju pokazuje pokazivac
+func (v *ValueLog) Read(pointer Pointer) ([]byte, error) {
+	path := getPath(pointer.File)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data := make([]byte, pointer.Size)
+	_, err = f.ReadAt(data, pointer.Offset)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, &record.ErrCorruption{File: path, Offset: pointer.Offset, Err: err}
+	}
+
+	entry, _, err := decodeEntry(data)
+	if err != nil {
+		return nil, &record.ErrCorruption{File: path, Offset: pointer.Offset, Err: err}
+	}
+	return entry.Value, nil
+}
+
+// vraca zapis sa vrednoscu umesto pokazivaca, ostali zapisi se vracaju nepromenjeni
+func (v *ValueLog) Resolve(r *record.Record) (*record.Record, error) {
+	if !r.ValuePointer {
+		return r, nil
+	}
+	pointer, err := PointerFromBytes(r.Value)
+	if err != nil {
+		return nil, err
+	}
+	value, err := v.Read(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := *r
+	resolved.Value = value
+	resolved.ValueSize = int64(len(value))
+	resolved.ValuePointer = false
+	return &resolved, nil
+}
+
+func decodeEntry(data []byte) (Entry, int, error) {
+	if len(data) < 4 {
+		return Entry{}, 0, io.ErrUnexpectedEOF
+	}
+	offset := 4
+	keySize, n := binary.Uvarint(data[offset:])
+	if n <= 0 {
+		return Entry{}, 0, io.ErrUnexpectedEOF
+	}
+	offset += n
+	valueSize, n := binary.Uvarint(data[offset:])
+	if n <= 0 {
+		return Entry{}, 0, io.ErrUnexpectedEOF
+	}
+	offset += n
+	if keySize > uint64(len(data)-offset) || valueSize > uint64(len(data)-offset)-keySize {
+		return Entry{}, 0, io.ErrUnexpectedEOF
+	}
+	end := offset + int(keySize) + int(valueSize)
+
+	if record.Checksum(data[4:end]) != binary.BigEndian.Uint32(data[0:4]) {
+		return Entry{}, end, record.ErrChecksumMismatch
+	}
+	entry := Entry{
+		Key:   string(data[offset : offset+int(keySize)]),
+		Value: append([]byte(nil), data[offset+int(keySize):end]...),
+	}
+	return entry, end, nil
+}
+
+// najstariji fajl koji se vise ne menja, false ako postoji samo fajl u koji se dopisuje
+func (v *ValueLog) OldestFile() (int, bool) {
+	if len(v.files) < 2 {
+		return 0, false
+	}
+	return v.files[0], true
+}
+
+// cita sve ulaze fajla, nepotpun ulaz na kraju je prekinut upis i odbacuje se
+func (v *ValueLog) ReadFile(fileNumber int) ([]Entry, error) {
+	path := getPath(fileNumber)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for offset := 0; offset < len(data); {
+		entry, n, err := decodeEntry(data[offset:])
+		if err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return nil, &record.ErrCorruption{File: path, Offset: int64(offset), Err: err}
+		}
+		entry.Pointer = Pointer{File: fileNumber, Offset: int64(offset), Size: int64(n)}
+		entries = append(entries, entry)
+		offset += n
+	}
+	return entries, nil
+}
+
+// brise fajl ciji su zivi ulazi vec prepisani
+func (v *ValueLog) RemoveFile(fileNumber int) error {
+	if fileNumber == v.active() {
+		return fmt.Errorf("value log file %d is still being written", fileNumber)
+	}
+	for i, file := range v.files {
+		if file == fileNumber {
+			v.files = append(v.files[:i], v.files[i+1:]...)
+			break
+		}
+	}
+	return os.Remove(getPath(fileNumber))
+}
@@ -1,14 +1,16 @@
 package wal
 
 import (
-	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"main/config"
 	"main/record"
-	"math"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 type Wal struct {
@@ -19,6 +21,10 @@ type Wal struct {
 }
 
 func LoadWal(segmentSize int) (*Wal, error) {
+	err := finishRewrite()
+	if err != nil {
+		return nil, err
+	}
 	w := new(Wal)
 	w.segmentSize = segmentSize
 	w.numberOfSegments = countFilesInDirectory(config.WAL_DIRECTORY)
@@ -27,14 +33,21 @@ func LoadWal(segmentSize int) (*Wal, error) {
 	return w, nil
 }
 
+// broji segmente, bez privremenih fajlova prepisivanja
 func countFilesInDirectory(dirPath string) int {
 	files, _ := os.ReadDir(dirPath)
 
-	if len(files) == 0 {
+	count := 0
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".log") {
+			count++
+		}
+	}
+	if count == 0 {
 		return 1
 	}
 
-	return len(files)
+	return count
 }
 
 func getFileSize(filePath string) int {
@@ -48,31 +61,55 @@ func getFileSize(filePath string) int {
 }
 
 /* Dodaje zapis u segment, ako je segment pun pravi novi segment */
-func (w *Wal) AddRecord(key string, value []byte, delete bool, keyDictionary *map[int]string) *record.Record {
-	record := record.NewRecord(key, value, delete, keyDictionary)
-	recordBytes := record.ToBytes()
+func (w *Wal) AddRecord(key string, value []byte, delete bool) (*record.Record, error) {
+	record := record.NewRecord(key, value, delete)
+	err := w.Append(record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
 
-	remainingSpaceInLastSegment := w.segmentSize - w.lastSegmentSize
+/* Dodaje vec napravljen zapis, npr. zapis sa pokazivacem u value log */
+func (w *Wal) Append(r *record.Record) error {
+	return w.appendRecordBytes(r.ToBytes())
+}
 
-	if remainingSpaceInLastSegment < len(recordBytes) {
-		w.AddRecordToSegment(recordBytes[:remainingSpaceInLastSegment])
+/* Dodaje grupu zapisa jednim upisom, svi zapisi osim poslednjeg moraju imati Batch */
+func (w *Wal) AppendBatch(records []*record.Record) error {
+	var batchBytes []byte
+	for _, r := range records {
+		batchBytes = append(batchBytes, r.ToBytes()...)
+	}
+	return w.appendRecordBytes(batchBytes)
+}
+
+// zapis moze biti duzi od segmenta, pa se deli na onoliko segmenata koliko je potrebno
+func (w *Wal) appendRecordBytes(recordBytes []byte) error {
+	for w.segmentSize-w.lastSegmentSize < len(recordBytes) {
+		remainingSpaceInLastSegment := w.segmentSize - w.lastSegmentSize
+		err := w.AddRecordToSegment(recordBytes[:remainingSpaceInLastSegment])
+		if err != nil {
+			return err
+		}
+		recordBytes = recordBytes[remainingSpaceInLastSegment:]
 		w.numberOfSegments++
 		w.lastSegmentSize = 0
-		w.AddRecordToSegment(recordBytes[remainingSpaceInLastSegment:])
-	} else {
-		w.AddRecordToSegment(recordBytes)
 	}
-
-	return record
+	return w.AddRecordToSegment(recordBytes)
 }
 
-func (w *Wal) AddRecordToSegment(recordBytes []byte) {
+func (w *Wal) AddRecordToSegment(recordBytes []byte) error {
+	err := w.WriteToLastSegment(recordBytes)
+	if err != nil {
+		return err
+	}
 	w.lastSegmentSize += len(recordBytes)
-	w.WriteToLastSegment(recordBytes)
+	return nil
 }
 
 func (w Wal) WriteToLastSegment(recordBytes []byte) error {
-	f, err := os.OpenFile(getPath(w.numberOfSegments), os.O_CREATE|os.O_APPEND, 0644)
+	f, err := os.OpenFile(getPath(w.numberOfSegments), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
@@ -82,182 +119,256 @@ func (w Wal) WriteToLastSegment(recordBytes []byte) error {
 	return err
 }
 
+/* Ucitava zapise svih segmenata, zapis moze biti prelomljen izmedju dva segmenta */
+// Ako naidje na ostecen zapis vraca zapise pre njega i *record.ErrCorruption.
+// WAL se skracuje na poslednji ispravan zapis, jer bi zapisi dopisani iza
+// nepotpunog ili ostecenog zapisa bili izgubljeni pri sledecem oporavku.
 func (w *Wal) IndependentLoadAllRecords() ([]record.Record, error) {
-	i := 1
-	var allRecords []record.Record
-	var rec record.Record
-	f, err := os.Open(getPath(i))
+	data, segments, err := w.loadAllSegments()
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
-	for {
-		CRCBytes := make([]byte, 4)
-		n, _ := f.Read(CRCBytes)
-		if n != 4 {
-			partOfPrevious := CRCBytes[len(CRCBytes)-n:]
-			f, err = os.Open(getPath(i + 1))
-			if err != nil {
-				break
-			}
-			defer f.Close()
-			i++
-			CRCBytesNext := make([]byte, 4-len(partOfPrevious))
-			_, err = f.Read(CRCBytesNext)
-			if err != nil {
-				return nil, err
-			}
-			rec.Crc32 = binary.BigEndian.Uint32(append(partOfPrevious[:], CRCBytesNext[:]...))
-		} else {
-			rec.Crc32 = binary.BigEndian.Uint32(CRCBytes)
+	records, end, legacy, err := decodeRecords(data, segments)
+	if end < len(data) {
+		cause := err
+		if cause == nil {
+			cause = corruptionAt(segments, end, io.ErrUnexpectedEOF)
 		}
+		log.Printf("wal: dropping %d bytes after the last valid record: %v", len(data)-end, cause)
+	}
 
-		timestampBytes := make([]byte, 8)
-		n, _ = f.Read(timestampBytes)
-		if n != 8 {
-			partOfPrevious := timestampBytes[len(timestampBytes)-n:]
-			f, _ = os.Open(getPath(i + 1))
-			defer f.Close()
-			i++
-			timestampBytesNext := make([]byte, 8-len(partOfPrevious))
-			_, err := f.Read(timestampBytesNext)
-			if err != nil {
-				return nil, err
-			}
-			rec.Timestamp = int64(binary.BigEndian.Uint64(append(partOfPrevious[:], timestampBytesNext[:]...)))
-		} else {
-			rec.Timestamp = int64(binary.BigEndian.Uint32(timestampBytes))
-		}
+	var repairErr error
+	if legacy {
+		// memtabela racuna velicinu zapisa u WAL-u po v2 formatu, pa stari zapisi moraju biti prepisani
+		repairErr = w.rewrite(records)
+	} else if end < len(data) {
+		repairErr = w.truncate(segments, end)
+	}
+	if err == nil {
+		err = repairErr
+	}
+	return records, err
+}
 
-		tombstoneBytes := make([]byte, 1)
-		n, _ = f.Read(tombstoneBytes)
-		if n != 1 {
-			partOfPrevious := tombstoneBytes[len(tombstoneBytes)-n:]
-			f, _ = os.Open(getPath(i + 1))
-			defer f.Close()
-			i++
-			tombstoneBytesNext := make([]byte, 1-len(partOfPrevious))
-			_, err := f.Read(tombstoneBytesNext)
-			if err != nil {
-				return nil, err
-			}
-			rec.Tombstone = (append(partOfPrevious[:], tombstoneBytesNext[:]...))[0] == 1
-		} else {
-			rec.Tombstone = tombstoneBytes[0] == 1
+// ostavlja samo prvih end bajtova spojenih segmenata, kasniji segmenti se brisu
+func (w *Wal) truncate(segments []segmentStart, end int) error {
+	last := 0
+	for i, s := range segments {
+		if s.offset <= end {
+			last = i
 		}
-
-		keySizeBytes := make([]byte, 8)
-		n, _ = f.Read(keySizeBytes)
-		if n != 8 {
-			partOfPrevious := keySizeBytes[len(keySizeBytes)-n:]
-			f, _ = os.Open(getPath(i + 1))
-			defer f.Close()
-			i++
-			keySizeBytesNext := make([]byte, 8-len(partOfPrevious))
-			_, err := f.Read(keySizeBytesNext)
-			if err != nil {
-				return nil, err
-			}
-			rec.KeySize = int64(binary.BigEndian.Uint64(append(partOfPrevious[:], keySizeBytesNext[:]...)))
-		} else {
-			rec.KeySize = int64(binary.BigEndian.Uint64(keySizeBytes))
+	}
+	size := end - segments[last].offset
+	err := os.Truncate(segments[last].path, int64(size))
+	if err != nil {
+		return err
+	}
+	for _, s := range segments[last+1:] {
+		err = os.Remove(s.path)
+		if err != nil {
+			return err
 		}
+	}
+	w.numberOfSegments = segments[last].number
+	w.lastSegmentSize = size
+	return nil
+}
 
-		valueSizeBytes := make([]byte, 8)
-		n, _ = f.Read(valueSizeBytes)
-		if n != 8 {
-			partOfPrevious := valueSizeBytes[len(valueSizeBytes)-n:]
-			f, _ = os.Open(getPath(i + 1))
-			defer f.Close()
-			i++
-			valueSizeBytesBytesNext := make([]byte, 8-len(partOfPrevious))
-			_, err := f.Read(valueSizeBytesBytesNext)
-			if err != nil {
-				return nil, err
-			}
-			rec.ValueSize = int64(binary.BigEndian.Uint64(append(partOfPrevious[:], valueSizeBytesBytesNext[:]...)))
-		} else {
-			rec.ValueSize = int64(binary.BigEndian.Uint64(valueSizeBytes))
+// Prepisivanje segmenata: novi segmenti se upisuju u privremene fajlove, pa se
+// upisuje oznaka sa brojem novih segmenata. Od oznake se prepisivanje zavrsava
+// i posle pada (finishRewrite), a pre nje stari segmenti ostaju netaknuti.
+const (
+	tmpSuffix     = ".tmp"
+	rewriteMarker = config.WAL_DIRECTORY + "rewrite"
+)
+
+/* Ponovo upisuje zapise u formatu v2 */
+func (w *Wal) rewrite(records []record.Record) error {
+	var data []byte
+	for _, rec := range records {
+		data = append(data, rec.ToBytes()...)
+	}
+	return w.rewriteData(data)
+}
+
+// zamenjuje sve segmente novim sa podacima data
+func (w *Wal) rewriteData(data []byte) error {
+	// segmenti se pune do kraja kao u appendRecordBytes
+	var segments [][]byte
+	for len(data) > 0 {
+		n := min(len(data), w.segmentSize)
+		segments = append(segments, data[:n])
+		data = data[n:]
+	}
+
+	for i, segment := range segments {
+		err := writeSynced(getPath(i+1)+tmpSuffix, segment)
+		if err != nil {
+			return err
 		}
+	}
+	err := writeSynced(rewriteMarker, []byte(strconv.Itoa(len(segments))))
+	if err != nil {
+		return err
+	}
+	err = syncDirectory(config.WAL_DIRECTORY)
+	if err != nil {
+		return err
+	}
+	err = finishRewrite()
+	if err != nil {
+		return err
+	}
+
+	w.numberOfSegments = max(1, len(segments))
+	w.lastSegmentSize = 0
+	if len(segments) > 0 {
+		w.lastSegmentSize = len(segments[len(segments)-1])
+	}
+	return nil
+}
 
-		keyBytes := make([]byte, rec.KeySize)
-		n, _ = f.Read(keyBytes)
-		if n != int(rec.KeySize) {
-			partOfPrevious := keyBytes[len(keyBytes)-n:]
-			f, _ = os.Open(getPath(i + 1))
-			defer f.Close()
-			i++
-			keyBytesNext := make([]byte, int(rec.KeySize)-len(partOfPrevious))
-			_, err := f.Read(keyBytesNext)
+// zavrsava prepisivanje prekinuto padom, bez oznake privremeni fajlovi nisu
+// svi upisani pa se brisu
+func finishRewrite() error {
+	data, err := os.ReadFile(rewriteMarker)
+	if errors.Is(err, os.ErrNotExist) {
+		tmpFiles, _ := filepath.Glob(config.SEGMENT_FILE_PATH + "*" + tmpSuffix)
+		for _, tmpFile := range tmpFiles {
+			err = os.Remove(tmpFile)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			rec.Key = string(append(partOfPrevious[:], keyBytesNext[:]...))
-		} else {
-			rec.Key = string(keyBytes)
 		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	count, err := strconv.Atoi(string(data))
+	if err != nil {
+		return err
+	}
 
-		valueBytes := make([]byte, rec.ValueSize)
-		n, _ = f.Read(valueBytes)
-		if n != int(rec.ValueSize) {
-			partOfPrevious := valueBytes[len(valueBytes)-n:]
-			f, _ = os.Open(getPath(i + 1))
-			defer f.Close()
-			i++
-			valueBytesNext := make([]byte, int(rec.ValueSize)-len(partOfPrevious))
-			_, err := f.Read(valueBytesNext)
-			if err != nil {
-				return nil, err
-			}
-			rec.Value = append(partOfPrevious[:], valueBytesNext[:]...)
-		} else {
-			rec.Value = valueBytes
+	for i := 1; i <= count; i++ {
+		// segment je mogao biti preimenovan pre pada
+		err = os.Rename(getPath(i)+tmpSuffix, getPath(i))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
 		}
+	}
+	// stari segmenti posle novih
+	for i := count + 1; ; i++ {
+		err = os.Remove(getPath(i))
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	err = syncDirectory(config.WAL_DIRECTORY)
+	if err != nil {
+		return err
+	}
+	return os.Remove(rewriteMarker)
+}
 
-		allRecords = append(allRecords, rec)
-		rec = record.Record{}
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
 	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
 
-	return allRecords, nil
+// preimenovanja i brisanja u direktorijumu su trajna tek posle sync-a direktorijuma
+func syncDirectory(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
 }
 
 /* Ucitavanje svih zapisa odjednom */
 func (w *Wal) LoadAllRecords() ([]*record.Record, error) {
+	data, segments, err := w.loadAllSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	loadedRecords, _, _, err := decodeRecords(data, segments)
 	var records []*record.Record
-	var data []byte
+	for i := range loadedRecords {
+		records = append(records, &loadedRecords[i])
+	}
+	return records, err
+}
 
+// pocetak segmenta u spojenim podacima svih segmenata
+type segmentStart struct {
+	path   string
+	number int
+	offset int
+}
+
+// ucitavam sve segmente u veliki niz bajtova, ovako radim da bih lakse resio prelamanje rekorda
+func (w *Wal) loadAllSegments() ([]byte, []segmentStart, error) {
+	var data []byte
+	var segments []segmentStart
 	for i := 1; i <= w.numberOfSegments; i++ {
 		loadedData, err := w.LoadDataFromSegment(getPath(i))
-		if err != nil {
-			return nil, err
+		if errors.Is(err, os.ErrNotExist) {
+			continue // u segment jos nista nije upisano
+		} else if err != nil {
+			return nil, nil, err
 		}
-		data = append(data, loadedData...) // ucitavam sve segmente u veliki niz bajtova, ovako radim da bih lakse resio prelamanje rekorda
+		segments = append(segments, segmentStart{path: getPath(i), number: i, offset: len(data)})
+		data = append(data, loadedData...)
 	}
+	return data, segments, nil
+}
 
-	for len(data) != 0 {
-		crc32 := binary.BigEndian.Uint32(data[0:4])
-		timestamp := int64(binary.BigEndian.Uint64(data[4:12]))
-		tombstone := false
-		if data[12] == 1 {
-			tombstone = true
+// dekodira zapise obe verzije i vraca kraj poslednjeg ispravnog zapisa i da li
+// je bilo v1 zapisa. Nepotpun zapis na kraju je prekinut upis i odbacuje se,
+// a ostecen zapis prekida citanje.
+func decodeRecords(data []byte, segments []segmentStart) ([]record.Record, int, bool, error) {
+	var records []record.Record
+	legacy := false
+	offset := 0
+	for offset < len(data) {
+		version, _ := record.DetectVersion(data[offset:])
+		loadedRecord, n, err := record.DecodeRecord(data[offset:])
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		} else if err != nil {
+			return records, offset, legacy, corruptionAt(segments, offset, err)
 		}
-		keySize := int64(binary.BigEndian.Uint64(data[13:21]))
-		valueSize := int64(binary.BigEndian.Uint64(data[21:29]))
-		key := string(data[29 : 29+keySize])
-		value := data[29+keySize : 29+keySize+valueSize]
-
-		checkCrc32 := record.CalculateCRC(timestamp, tombstone, keySize, valueSize, key, value)
+		legacy = legacy || version == record.VersionV1
+		records = append(records, loadedRecord)
+		offset += n
+	}
+	return records, offset, legacy, nil
+}
 
-		if checkCrc32 == crc32 { // potrebno je pri ucitavanju proveriti da li je doslo do promene zapisa
-			loadedRecord := record.LoadRecord(crc32, timestamp, tombstone, keySize, valueSize, key, value)
-			records = append(records, loadedRecord)
+// pretvara poziciju u spojenim podacima u segment i poziciju u njemu
+func corruptionAt(segments []segmentStart, offset int, err error) error {
+	segment := segments[0]
+	for _, s := range segments {
+		if s.offset <= offset {
+			segment = s
 		}
-
-		data = data[29+keySize+valueSize:]
 	}
-
-	return records, nil
+	return &record.ErrCorruption{File: segment.path, Offset: int64(offset - segment.offset), Err: err}
 }
 
 /* Ucitava sve zapise segmenta u memoriju */
@@ -274,7 +385,7 @@ func (w *Wal) LoadDataFromSegment(fileName string) ([]byte, error) {
 	}
 
 	data := make([]byte, stat.Size())
-	_, err = f.Read(data)
+	_, err = io.ReadFull(f, data)
 	if err != nil {
 		return nil, err
 	}
@@ -301,137 +412,19 @@ func (w *Wal) DeleteSegments(newLowWaterMark int) {
 
 /* Na osnovu rednog broja segmenta kreira filePath za segment */
 func getPath(numberOfSegment int) string {
-	path := config.SEGMENT_FILE_PATH
-
-	stringNumberOfSegment := strconv.Itoa(numberOfSegment)
-	lenString := len(stringNumberOfSegment)
-
-	switch lenString {
-	case 1:
-		path += "00" + stringNumberOfSegment + ".log"
-	case 2:
-		path += "0" + stringNumberOfSegment + ".log"
-	case 3:
-		path += stringNumberOfSegment + ".log"
-	default:
-		err := errors.New("number of segments exceededs") // mozemo imati do 1000 segmenata
-		fmt.Println("Error: ", err)
-	}
-
-	return path
+	// broj je dopunjen nulama do tri cifre, posle 999 segmenata ima vise cifara
+	return fmt.Sprintf("%s%03d.log", config.SEGMENT_FILE_PATH, numberOfSegment)
 }
 
-func (w *Wal) DeleteWalSegmentsEngine(SizeOfRecordsInWal int) {
-	walsToDelete := int(math.Floor(float64(SizeOfRecordsInWal) / float64(w.segmentSize)))
-	remainingBytesToTruncate := SizeOfRecordsInWal - walsToDelete*w.segmentSize
-	// slucaj ako brisemo ceo wal
-	if walsToDelete+1 == w.numberOfSegments && remainingBytesToTruncate == w.lastSegmentSize {
-		walsToDelete += 1
-		w.DeleteSegments(int(walsToDelete))
-		return
-	}
-	w.DeleteSegments(int(walsToDelete))
-	for i := 1; i <= w.numberOfSegments-1; i++ {
-		f, err := os.OpenFile(getPath(i), os.O_RDWR|os.O_CREATE, 0644)
-		if err != nil {
-			continue
-		}
-
-		// shiftovanje byteova na pocetak trenutnog filea
-		remainingFileData := w.getSegmentSize(i) - remainingBytesToTruncate
-		f.Seek(int64(remainingBytesToTruncate), 0)
-
-		data := make([]byte, remainingFileData)
-		_, err = f.Read(data)
-		if err != nil {
-			fmt.Println("Error reading file: ", err)
-			continue
-		}
-		f.Seek(0, 0)
-		f.Write(data)
-
-		// uzimanje remainingBytesToTruncate iz sledeceg filea sa pocetka
-		f2, err := os.OpenFile(getPath(i+1), os.O_RDWR|os.O_CREATE, 0644)
-		if err != nil {
-			continue
-		}
-
-		var data2 []byte
-		if i+1 == w.numberOfSegments &&
-			remainingBytesToTruncate >= w.lastSegmentSize {
-			data2 = make([]byte, w.lastSegmentSize)
-			_, err = f2.Read(data2)
-			if err != nil {
-				fmt.Println("Error reading file: ", err)
-				continue
-			}
-
-			f.Write(data2)
-			f2.Close()
-			err := os.Remove(getPath(w.numberOfSegments))
-			if err != nil {
-				continue
-			}
-			os.Truncate(getPath(w.numberOfSegments-1), int64(remainingFileData+w.lastSegmentSize))
-			w.numberOfSegments--
-			w.lastSegmentSize = int(remainingFileData + w.lastSegmentSize)
-			f.Close()
-			f2.Close()
-			return
-		} else {
-			data2 = make([]byte, remainingBytesToTruncate)
-			_, err = f2.Read(data2)
-			if err != nil {
-				fmt.Println("Error reading file: ", err)
-				continue
-			}
-
-			f.Write(data2)
-			if i+1 == w.numberOfSegments {
-				f2.Seek(int64(remainingBytesToTruncate), 0)
-				data3 := make([]byte, w.lastSegmentSize-remainingBytesToTruncate)
-				_, err = f2.Read(data3)
-				if err != nil {
-					fmt.Println("Error reading file: ", err)
-					continue
-				}
-
-				f2.Seek(0, 0)
-				f2.Write(data3)
-				os.Truncate(getPath(w.numberOfSegments), int64(w.lastSegmentSize-remainingBytesToTruncate))
-				w.lastSegmentSize -= remainingBytesToTruncate
-				f.Close()
-				f2.Close()
-				return
-			}
-		}
-		f.Close()
-		f2.Close()
-	}
-	//if there is only one file left
-	if w.numberOfSegments == 1 && remainingBytesToTruncate != 0 {
-		f, err := os.OpenFile(getPath(1), os.O_RDWR|os.O_CREATE, 0644)
-		if err != nil {
-			return
-		}
-		data := make([]byte, w.lastSegmentSize-remainingBytesToTruncate)
-		f.Seek(int64(remainingBytesToTruncate), 0)
-		_, err = f.Read(data)
-		if err != nil {
-			fmt.Println("Error reading file: ", err)
-			return
-		}
-		f.Seek(0, 0)
-		f.Write(data)
-		os.Truncate(getPath(1), int64(w.lastSegmentSize-remainingBytesToTruncate))
-		w.lastSegmentSize -= remainingBytesToTruncate
-		f.Close()
+// brise prvih SizeOfRecordsInWal bajtova WAL-a, ostatak se prepisuje od prvog
+// segmenta preko privremenih fajlova kao kod rewrite
+func (w *Wal) DeleteWalSegmentsEngine(SizeOfRecordsInWal int) error {
+	data, _, err := w.loadAllSegments()
+	if err != nil {
+		return err
 	}
-}
-
-func (w *Wal) getSegmentSize(segmentIndex int) int {
-	if segmentIndex == w.numberOfSegments {
-		return w.lastSegmentSize
+	if SizeOfRecordsInWal > len(data) {
+		return fmt.Errorf("wal: cannot delete %d bytes of %d", SizeOfRecordsInWal, len(data))
 	}
-	return w.segmentSize
+	return w.rewriteData(data[SizeOfRecordsInWal:])
 }
@@ -0,0 +1,143 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"main/config"
+	"main/record"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// putanje WAL-a su relativne, pa test radi u privremenom direktorijumu
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	err = os.MkdirAll(filepath.Join(dir, config.WAL_DIRECTORY), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func addRecords(t *testing.T, w *Wal, prefix string, count, valueSize int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		_, err := w.AddRecord(fmt.Sprintf("%s%d", prefix, i), []byte(strings.Repeat("v", valueSize)), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func loadKeys(t *testing.T, segmentSize int) []string {
+	t.Helper()
+	w, err := LoadWal(segmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := w.IndependentLoadAllRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, r := range records {
+		keys = append(keys, r.Key)
+	}
+	return keys
+}
+
+func TestTornTailIsTruncated(t *testing.T) {
+	tests := []struct {
+		name        string
+		segmentSize int
+		valueSize   int
+	}{
+		{"record inside one segment", 1 << 16, 10},
+		// zapis je duzi od segmenta, pa je poslednji prelomljen kroz vise segmenata
+		{"record across segments", 64, 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdirTemp(t)
+			w, err := LoadWal(tt.segmentSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			addRecords(t, w, "a", 5, tt.valueSize)
+
+			// pad usred upisa poslednjeg zapisa
+			last := getPath(w.numberOfSegments)
+			err = os.Truncate(last, int64(getFileSize(last)-3))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w, err = LoadWal(tt.segmentSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			records, err := w.IndependentLoadAllRecords()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 4 {
+				t.Fatalf("recovered %d records, want 4", len(records))
+			}
+			// zapisi posle oporavka se nastavljaju na poslednji ispravan zapis
+			addRecords(t, w, "b", 3, tt.valueSize)
+
+			got := strings.Join(loadKeys(t, tt.segmentSize), " ")
+			want := "a0 a1 a2 a3 b0 b1 b2"
+			if got != want {
+				t.Fatalf("keys after recovery = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// ostecen zapis usred WAL-a nije prekinut upis, pa se prijavljuje, a zapisi
+// pre njega se i dalje citaju
+func TestCorruptRecordIsReported(t *testing.T) {
+	chdirTemp(t)
+	w, err := LoadWal(1 << 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addRecords(t, w, "a", 5, 10)
+
+	path := getPath(w.numberOfSegments)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := strings.Index(string(data), "a2")
+	data[i+2] ^= 1
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = LoadWal(1 << 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := w.IndependentLoadAllRecords()
+	var corruption *record.ErrCorruption
+	if !errors.As(err, &corruption) {
+		t.Fatalf("IndependentLoadAllRecords: %v, want *record.ErrCorruption", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("read %d records before the damaged one, want 2", len(records))
+	}
+}